@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/alican-uelger/deep-scan/internal/scanner"
+	"github.com/spf13/viper"
+)
+
+const (
+	flagBaseline       = "baseline"
+	flagWriteBaseline  = "write-baseline"
+	flagFailOnFindings = "fail-on-findings"
+)
+
+/*
+searchBaseline returns the baseline of --baseline, an empty one to collect the findings for --write-baseline
+or nil. The fingerprints of the findings are only computed with a baseline.
+*/
+func searchBaseline() (*scanner.Baseline, error) {
+	if name := viper.GetString(flagBaseline); name != "" {
+		return loadBaseline(name)
+	}
+	if name := viper.GetString(flagWriteBaseline); name != "" {
+		return emptyBaseline(name), nil
+	}
+	return nil, nil
+}
+
+/*
+emptyBaseline keeps the key of the baseline written by a previous search, so the fingerprints stay the same
+and the search options of an incremental scan do not change. A new key is created without a valid baseline.
+*/
+func emptyBaseline(name string) *scanner.Baseline {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return scanner.NewBaseline()
+	}
+	previous, err := scanner.ReadBaseline(data)
+	if err != nil {
+		slog.Debug(fmt.Sprintf("creating a new key for the baseline %s: %s", name, err))
+		return scanner.NewBaseline()
+	}
+	return previous.WithoutFindings()
+}
+
+// loadBaseline reads the accepted findings, in contrast to the state file the baseline has to exist
+func loadBaseline(name string) (*scanner.Baseline, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	baseline, err := scanner.ReadBaseline(data)
+	if err != nil {
+		return nil, fmt.Errorf("reading baseline %s failed: %w", name, err)
+	}
+	return baseline, nil
+}
+
+/*
+saveBaseline accepts the findings of a search and writes them together with the findings of the loaded baseline,
+the resolved findings of incremental scans are not added.
+*/
+func saveBaseline(name string, baseline *scanner.Baseline, files []scanner.FileMatch) error {
+	baseline.Add(files)
+	data, err := json.MarshalIndent(baseline, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(name, data); err != nil {
+		return err
+	}
+	slog.Info(fmt.Sprintf("Baseline with %d findings written to %s", len(baseline.Findings), name))
+	return nil
+}
+
+// checkFindings fails a search with --fail-on-findings, when findings are left which are not in the baseline
+func checkFindings(files []scanner.FileMatch) error {
+	if !viper.GetBool(flagFailOnFindings) {
+		return nil
+	}
+	count := 0
+	for _, file := range files {
+		if file.Status != scanner.StatusResolved {
+			count++
+		}
+	}
+	if count == 0 {
+		return nil
+	}
+	return fmt.Errorf("found %d files with findings which are not in the baseline", count)
+}
@@ -0,0 +1,67 @@
+//go:build unit
+
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/alican-uelger/deep-scan/internal/matcher"
+	"github.com/alican-uelger/deep-scan/internal/scanner"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBaselineFile(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	name := filepath.Join(t.TempDir(), "baseline.json")
+
+	// without baseline flags no fingerprints are computed
+	baseline, err := searchBaseline()
+	require.NoError(t, err)
+	assert.Nil(t, baseline)
+
+	viper.Set(flagWriteBaseline, name)
+	baseline, err = searchBaseline()
+	require.NoError(t, err)
+	require.NotNil(t, baseline)
+
+	files := []scanner.FileMatch{{
+		File:    scanner.File{Name: "config.yaml", Path: "org/repo", Repository: "org/repo"},
+		Matches: []matcher.MatchResult{{Fingerprint: "abc", Rule: scanner.RuleContent}},
+	}}
+	require.NoError(t, saveBaseline(name, baseline, files))
+
+	// writing the baseline again keeps its key, but not its findings
+	rewritten, err := searchBaseline()
+	require.NoError(t, err)
+	assert.Equal(t, baseline.Key, rewritten.Key)
+	assert.Empty(t, rewritten.Findings)
+	assert.False(t, rewritten.Contains("abc"))
+
+	viper.Set(flagBaseline, name)
+	loaded, err := searchBaseline()
+	require.NoError(t, err)
+	assert.True(t, loaded.Contains("abc"))
+	// the key of the fingerprints is kept
+	assert.Equal(t, baseline.Key, loaded.Key)
+	assert.Equal(t, []scanner.BaselineEntry{{Fingerprint: "abc", Repository: "org/repo", Path: "org/repo/config.yaml", Rule: scanner.RuleContent}}, loaded.Findings)
+
+	// in contrast to the state file, a missing baseline is an error
+	viper.Set(flagBaseline, filepath.Join(t.TempDir(), "missing.json"))
+	_, err = searchBaseline()
+	assert.Error(t, err)
+}
+
+func TestCheckFindings(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	files := []scanner.FileMatch{{File: scanner.File{Name: "config.yaml"}}}
+
+	assert.NoError(t, checkFindings(files))
+
+	viper.Set(flagFailOnFindings, true)
+	assert.EqualError(t, checkFindings(files), "found 1 files with findings which are not in the baseline")
+	assert.NoError(t, checkFindings([]scanner.FileMatch{{File: scanner.File{Name: "config.yaml"}, Status: scanner.StatusResolved}}))
+	assert.NoError(t, checkFindings(nil))
+}
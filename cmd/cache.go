@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alican-uelger/deep-scan/internal/cache"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+const (
+	flagCacheDir     = "cache-dir"
+	flagCacheMaxSize = "cache-max-size"
+	flagCacheTTL     = "cache-ttl"
+)
+
+const (
+	defaultCacheMaxSize = "1GB"
+	defaultCacheTTL     = "7d"
+)
+
+// NewCacheCmd manages the cache of the git hosts
+func NewCacheCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the cache of trees and file contents of the git hosts",
+	}
+	prune := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove the cached files older than --cache-ttl and the oldest files exceeding --cache-max-size",
+		PreRun: func(cmd *cobra.Command, args []string) {
+			bindFlags(cmd)
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if viper.GetString(flagCacheDir) == "" {
+				return fmt.Errorf("--%s is required", flagCacheDir)
+			}
+			c, err := openCache()
+			if err != nil {
+				return err
+			}
+			stats, err := c.Prune()
+			if err != nil {
+				return err
+			}
+			slog.Info(fmt.Sprintf("cache %s: %s", viper.GetString(flagCacheDir), stats))
+			return nil
+		},
+	}
+	addCacheFlags(prune.PersistentFlags())
+	bindFlags(prune)
+	cmd.AddCommand(prune)
+	return cmd
+}
+
+func addCacheFlags(flagSet *pflag.FlagSet) {
+	flagSet.String(flagCacheDir, "", "Cache trees and file contents of the git hosts in this directory, unchanged files are not fetched again")
+	flagSet.String(flagCacheMaxSize, defaultCacheMaxSize, "Maximum size of the cache (e.g. 500MB, 2GB), the oldest files are removed first (0 is unlimited)")
+	flagSet.String(flagCacheTTL, defaultCacheTTL, "Cached files older than this age are not used anymore (e.g. 7d or 12h, 0 keeps them forever)")
+}
+
+// openCache opens the cache of --cache-dir, it returns nil without a cache directory
+func openCache() (*cache.Cache, error) {
+	dir := viper.GetString(flagCacheDir)
+	if dir == "" {
+		return nil, nil
+	}
+	maxSize, err := parseSize(viper.GetString(flagCacheMaxSize))
+	if err != nil {
+		return nil, fmt.Errorf("--%s: %w", flagCacheMaxSize, err)
+	}
+	ttl, err := parseAge(viper.GetString(flagCacheTTL))
+	if err != nil {
+		return nil, fmt.Errorf("--%s: %w", flagCacheTTL, err)
+	}
+	return cache.New(dir, cache.Options{MaxSize: maxSize, TTL: ttl})
+}
+
+// closeCache logs the cache usage of a search and applies the limits of the cache
+func closeCache(c *cache.Cache) {
+	if c == nil {
+		return
+	}
+	hits, misses := c.Stats()
+	slog.Info(fmt.Sprintf("cache: %d hits, %d misses", hits, misses))
+	stats, err := c.Prune()
+	if err != nil {
+		slog.Warn(fmt.Sprintf("pruning the cache failed: %s", err))
+		return
+	}
+	slog.Debug(fmt.Sprintf("cache pruned: %s", stats))
+}
+
+var sizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"TB", 1 << 40},
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// parseSize parses a size in bytes with an optional unit (B, KB, MB, GB, TB), the units are powers of 1024
+func parseSize(value string) (int64, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	multiplier := int64(1)
+	for _, unit := range sizeUnits {
+		if number, ok := strings.CutSuffix(value, unit.suffix); ok {
+			value = strings.TrimSpace(number)
+			multiplier = unit.bytes
+			break
+		}
+	}
+	size, err := strconv.ParseFloat(value, 64)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("invalid size %q, use e.g. 500MB or 2GB", value)
+	}
+	return int64(size * float64(multiplier)), nil
+}
+
+// parseAge parses an age in days (e.g. 7d) or as duration (e.g. 12h)
+func parseAge(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q, use e.g. 7d or 12h", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q, use e.g. 7d or 12h", value)
+	}
+	return d, nil
+}
@@ -0,0 +1,62 @@
+//go:build unit
+
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected int64
+		wantErr  bool
+	}{
+		{value: "0", expected: 0},
+		{value: "512", expected: 512},
+		{value: "100B", expected: 100},
+		{value: "500MB", expected: 500 << 20},
+		{value: "1.5gb", expected: 3 << 29},
+		{value: "2 TB", expected: 2 << 40},
+		{value: "lots", wantErr: true},
+		{value: "-1GB", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			result, err := parseSize(tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{value: "0", expected: 0},
+		{value: "7d", expected: 7 * 24 * time.Hour},
+		{value: "12h", expected: 12 * time.Hour},
+		{value: "a week", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			result, err := parseAge(tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
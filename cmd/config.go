@@ -1,10 +1,20 @@
 package cmd
 
 import (
+	"fmt"
 	"log/slog"
+	"os"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/alican-uelger/deep-scan/internal/git"
+	"github.com/alican-uelger/deep-scan/internal/history"
+	"github.com/alican-uelger/deep-scan/internal/matcher"
+	"github.com/alican-uelger/deep-scan/internal/redact"
 	"github.com/alican-uelger/deep-scan/internal/scanner"
+	"github.com/alican-uelger/deep-scan/internal/sops"
 	"github.com/spf13/viper"
 )
 
@@ -20,13 +30,52 @@ const (
 	flagSops                        = "sops"
 	flagSopsOnly                    = "sops-only"
 	flagSopsContentBeforeDecryption = "sops-content-before-decryption"
+	flagSopsKey                     = "sops-key"
+	flagSopsKeyPath                 = "sops-key-path"
+	flagSopsValue                   = "sops-value"
+	flagSopsValueRegex              = "sops-value-regex"
+	flagSopsPolicy                  = "sops-policy"
+	flagSopsFormat                  = "sops-format"
 	flagNoSnippets                  = "no-snippets"
+	flagRedact                      = "redact"
+	flagRedactReveal                = "redact-reveal"
+	flagRedactSalt                  = "redact-salt"
+	flagRedactAll                   = "redact-all"
+	flagEntropy                     = "entropy"
+	flagEntropyMinLength            = "entropy-min-length"
 	flagExcludeName                 = "exclude-name"
 	flagExcludeNameContains         = "exclude-name-contains"
 	flagExcludePath                 = "exclude-path"
 	flagExcludePathContains         = "exclude-path-contains"
 	flagExcludeContent              = "exclude-content"
 	flagLogLate                     = "log-late"
+	flagFormat                      = "format"
+	flagConcurrency                 = "concurrency"
+	flagSopsConcurrency             = "sops-concurrency"
+	flagTimeout                     = "timeout"
+	flagRequestTimeout              = "request-timeout"
+	flagRateLimit                   = "rate-limit"
+	flagMaxRetries                  = "max-retries"
+	flagFailOnSkipped               = "fail-on-skipped"
+)
+
+const (
+	flagArchived         = "archived"
+	flagForks            = "forks"
+	flagVisibility       = "visibility"
+	flagTopic            = "topic"
+	flagLanguage         = "language"
+	flagPushedAfter      = "pushed-after"
+	flagRepoRegex        = "repo-regex"
+	flagExcludeRepoRegex = "exclude-repo-regex"
+	flagRef              = "ref"
+	flagAllBranches      = "all-branches"
+	flagBranchRegex      = "branch-regex"
+	flagFetch            = "fetch"
+)
+
+const (
+	envRedactSalt = "DEEP_SCAN_REDACT_SALT"
 )
 
 const (
@@ -34,27 +83,259 @@ const (
 	flagOutputName = "output-name"
 )
 
-func searchOptions() scanner.SearchOptions {
+func searchOptions() (scanner.SearchOptions, error) {
+	nameRegex, err := regexFlag(flagNameRegex)
+	if err != nil {
+		return scanner.SearchOptions{}, err
+	}
+	pathRegex, err := regexFlag(flagPathRegex)
+	if err != nil {
+		return scanner.SearchOptions{}, err
+	}
+	contentRegex, err := regexFlag(flagContentRegex)
+	if err != nil {
+		return scanner.SearchOptions{}, err
+	}
+	sopsValueRegex, err := regexFlag(flagSopsValueRegex)
+	if err != nil {
+		return scanner.SearchOptions{}, err
+	}
+	sopsFormats, err := sops.ParseFormatRules(viper.GetStringSlice(flagSopsFormat))
+	if err != nil {
+		return scanner.SearchOptions{}, err
+	}
+	redactor, err := redactor()
+	if err != nil {
+		return scanner.SearchOptions{}, err
+	}
+	format, err := consoleFormat()
+	if err != nil {
+		return scanner.SearchOptions{}, err
+	}
+	projectFilter, err := projectFilter(time.Now())
+	if err != nil {
+		return scanner.SearchOptions{}, err
+	}
+	branchRegex, err := branchRegex()
+	if err != nil {
+		return scanner.SearchOptions{}, err
+	}
+	fetch, err := fetchStrategy()
+	if err != nil {
+		return scanner.SearchOptions{}, err
+	}
+	rules, err := loadRules()
+	if err != nil {
+		return scanner.SearchOptions{}, err
+	}
+	entropy, err := entropyMatcher()
+	if err != nil {
+		return scanner.SearchOptions{}, err
+	}
+	contentPatterns, err := loadContentFiles()
+	if err != nil {
+		return scanner.SearchOptions{}, err
+	}
 	return scanner.SearchOptions{
 		Name:                        viper.GetStringSlice(flagName),
 		NameContains:                viper.GetStringSlice(flagNameContains),
-		NameRegex:                   viper.GetStringSlice(flagNameRegex),
+		NameRegex:                   nameRegex,
 		Path:                        viper.GetStringSlice(flagPath),
 		PathContains:                viper.GetStringSlice(flagPathContains),
-		PathRegex:                   viper.GetStringSlice(flagPathRegex),
+		PathRegex:                   pathRegex,
 		Content:                     viper.GetStringSlice(flagContent),
-		ContentRegex:                viper.GetStringSlice(flagContentRegex),
+		ContentRegex:                contentRegex,
+		ContentPatterns:             contentPatterns,
 		Sops:                        viper.GetBool(flagSops),
 		SopsOnly:                    viper.GetBool(flagSopsOnly),
 		SopsContentBeforeDecryption: viper.GetStringSlice(flagSopsContentBeforeDecryption),
+		SopsKey:                     viper.GetStringSlice(flagSopsKey),
+		SopsKeyPath:                 viper.GetStringSlice(flagSopsKeyPath),
+		SopsValue:                   viper.GetStringSlice(flagSopsValue),
+		SopsValueRegex:              sopsValueRegex,
+		SopsPolicy:                  viper.GetBool(flagSopsPolicy),
+		SopsFormats:                 sopsFormats,
 		ExcludeName:                 viper.GetStringSlice(flagExcludeName),
 		ExcludeNameContains:         viper.GetStringSlice(flagExcludeNameContains),
 		ExcludePath:                 viper.GetStringSlice(flagExcludePath),
 		ExcludePathContains:         viper.GetStringSlice(flagExcludePathContains),
 		ExcludeContent:              viper.GetStringSlice(flagExcludeContent),
+		Rules:                       rules,
+		Entropy:                     entropy,
 		NoSnippets:                  viper.GetBool(flagNoSnippets),
+		Redact:                      redactor,
+		RedactAll:                   viper.GetBool(flagRedactAll),
 		LogLate:                     viper.GetBool(flagLogLate),
-		Project:                     viper.GetString(flagGitProject),
+		Format:                      format,
+		Projects:                    viper.GetStringSlice(flagGitProject),
+		ProjectFilter:               projectFilter,
+		Ref:                         viper.GetString(flagRef),
+		AllBranches:                 viper.GetBool(flagAllBranches),
+		BranchRegex:                 branchRegex,
+		Fetch:                       fetch,
+		History: history.LogOptions{
+			All:      viper.GetBool(flagAllRefs),
+			Since:    viper.GetString(flagSince),
+			MaxCount: viper.GetInt(flagMaxCommits),
+		},
+		Concurrency:     viper.GetInt(flagConcurrency),
+		SopsConcurrency: viper.GetInt(flagSopsConcurrency),
+	}, nil
+}
+
+// transportOptions throttles and retries the API requests of the git hosts
+func transportOptions() git.TransportOptions {
+	options := git.DefaultTransportOptions()
+	options.RequestsPerSecond = viper.GetFloat64(flagRateLimit)
+	options.Burst = max(int(options.RequestsPerSecond), 1)
+	options.MaxRetries = viper.GetInt(flagMaxRetries)
+	options.RequestTimeout = viper.GetDuration(flagRequestTimeout)
+	return options
+}
+
+// regexFlag compiles the regexes of a search flag once before the search, invalid regexes fail with their position
+func regexFlag(flag string) ([]*regexp.Regexp, error) {
+	regexes, err := matcher.CompileRegexes(viper.GetStringSlice(flag))
+	if err != nil {
+		return nil, fmt.Errorf("--%s: %w", flag, err)
+	}
+	return regexes, nil
+}
+
+// entropyMatcher creates the matcher of --entropy, it returns nil without charsets
+func entropyMatcher() (*matcher.Entropy, error) {
+	charsets := viper.GetStringSlice(flagEntropy)
+	if len(charsets) == 0 {
+		return nil, nil
+	}
+	thresholds, err := matcher.ParseEntropyThresholds(charsets)
+	if err != nil {
+		return nil, fmt.Errorf("--%s: %w", flagEntropy, err)
+	}
+	entropy, err := matcher.NewEntropy(thresholds, viper.GetInt(flagEntropyMinLength))
+	if err != nil {
+		return nil, fmt.Errorf("--%s: %w", flagEntropyMinLength, err)
+	}
+	return entropy, nil
+}
+
+func redactor() (redact.Redactor, error) {
+	mode := viper.GetString(flagRedact)
+	if mode == "" {
+		mode = string(redact.Mask)
+	}
+	m, err := redact.ParseMode(mode)
+	if err != nil {
+		return redact.Redactor{}, err
+	}
+	salt := viper.GetString(flagRedactSalt)
+	if salt == "" {
+		salt = os.Getenv(envRedactSalt)
+	}
+	// an unsalted hash of a short secret can be brute forced
+	if m == redact.Hash && salt == "" {
+		return redact.Redactor{}, fmt.Errorf("--%s %s requires a salt, set --%s or %s", flagRedact, redact.Hash, flagRedactSalt, envRedactSalt)
+	}
+	return redact.Redactor{
+		Mode:   m,
+		Reveal: viper.GetInt(flagRedactReveal),
+		Salt:   salt,
+	}, nil
+}
+
+func projectFilter(now time.Time) (git.ProjectFilter, error) {
+	archived, err := git.ParseInclusion(viper.GetString(flagArchived))
+	if err != nil {
+		return git.ProjectFilter{}, fmt.Errorf("--%s: %w", flagArchived, err)
+	}
+	forks, err := git.ParseInclusion(viper.GetString(flagForks))
+	if err != nil {
+		return git.ProjectFilter{}, fmt.Errorf("--%s: %w", flagForks, err)
+	}
+	pushedAfter, err := parsePushedAfter(viper.GetString(flagPushedAfter), now)
+	if err != nil {
+		return git.ProjectFilter{}, fmt.Errorf("--%s: %w", flagPushedAfter, err)
+	}
+	filter := git.ProjectFilter{
+		Archived:    archived,
+		Forks:       forks,
+		Visibility:  viper.GetStringSlice(flagVisibility),
+		Topics:      viper.GetStringSlice(flagTopic),
+		Languages:   viper.GetStringSlice(flagLanguage),
+		PushedAfter: pushedAfter,
+	}
+	if pattern := viper.GetString(flagRepoRegex); pattern != "" {
+		filter.Name, err = matcher.CompileRegex(pattern)
+		if err != nil {
+			return git.ProjectFilter{}, fmt.Errorf("--%s: %w", flagRepoRegex, err)
+		}
+	}
+	if pattern := viper.GetString(flagExcludeRepoRegex); pattern != "" {
+		filter.ExcludeName, err = matcher.CompileRegex(pattern)
+		if err != nil {
+			return git.ProjectFilter{}, fmt.Errorf("--%s: %w", flagExcludeRepoRegex, err)
+		}
+	}
+	return filter, nil
+}
+
+// branchRegex compiles --branch-regex, it selects branches and can not be combined with --ref
+func branchRegex() (*regexp.Regexp, error) {
+	pattern := viper.GetString(flagBranchRegex)
+	if viper.GetString(flagRef) != "" && (pattern != "" || viper.GetBool(flagAllBranches)) {
+		return nil, fmt.Errorf("--%s can not be combined with --%s or --%s", flagRef, flagAllBranches, flagBranchRegex)
+	}
+	if pattern == "" {
+		return nil, nil
+	}
+	regex, err := matcher.CompileRegex(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("--%s: %w", flagBranchRegex, err)
+	}
+	return regex, nil
+}
+
+func fetchStrategy() (string, error) {
+	fetch := strings.ToLower(viper.GetString(flagFetch))
+	switch fetch {
+	case "":
+		return scanner.FetchAuto, nil
+	case scanner.FetchAuto, scanner.FetchArchive, scanner.FetchFiles:
+		return fetch, nil
+	}
+	return "", fmt.Errorf("unsupported --%s %s (supported: %s, %s, %s)", flagFetch, fetch, scanner.FetchAuto, scanner.FetchArchive, scanner.FetchFiles)
+}
+
+// parsePushedAfter accepts a date (2024-01-31), a timestamp (RFC 3339) or an age (e.g. 90d or 720h)
+func parsePushedAfter(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date or age: %s", value)
+}
+
+func consoleFormat() (string, error) {
+	switch format := strings.ToLower(viper.GetString(flagFormat)); format {
+	case "":
+		return scanner.FormatText, nil
+	case scanner.FormatText, scanner.FormatNDJSON:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unsupported format: %s (supported: %s, %s)", format, scanner.FormatText, scanner.FormatNDJSON)
 	}
 }
 
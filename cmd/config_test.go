@@ -0,0 +1,150 @@
+//go:build unit
+
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alican-uelger/deep-scan/internal/git"
+	"github.com/alican-uelger/deep-scan/internal/scanner"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePushedAfter(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value    string
+		expected time.Time
+		wantErr  bool
+	}{
+		{value: "", expected: time.Time{}},
+		{value: "2024-01-31", expected: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{value: "2024-01-31T10:00:00Z", expected: time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC)},
+		{value: "90d", expected: now.AddDate(0, 0, -90)},
+		{value: "36h", expected: now.Add(-36 * time.Hour)},
+		{value: "last year", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			result, err := parsePushedAfter(tt.value, now)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestProjectFilter(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Set(flagArchived, "exclude")
+	viper.Set(flagForks, "only")
+	viper.Set(flagTopic, []string{"backend"})
+	viper.Set(flagRepoRegex, "^org/")
+
+	filter, err := projectFilter(time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, git.Exclude, filter.Archived)
+	assert.Equal(t, git.Only, filter.Forks)
+	assert.Equal(t, []string{"backend"}, filter.Topics)
+	assert.True(t, filter.Name.MatchString("org/api"))
+	assert.Nil(t, filter.ExcludeName)
+
+	viper.Set(flagExcludeRepoRegex, "(")
+	_, err = projectFilter(time.Now())
+	assert.ErrorContains(t, err, flagExcludeRepoRegex)
+
+	viper.Set(flagExcludeRepoRegex, "")
+	viper.Set(flagForks, "sometimes")
+	_, err = projectFilter(time.Now())
+	assert.ErrorContains(t, err, flagForks)
+}
+
+func TestBranchRegex(t *testing.T) {
+	t.Cleanup(viper.Reset)
+
+	regex, err := branchRegex()
+	require.NoError(t, err)
+	assert.Nil(t, regex)
+
+	viper.Set(flagBranchRegex, "^release/")
+	regex, err = branchRegex()
+	require.NoError(t, err)
+	assert.True(t, regex.MatchString("release/1.0"))
+
+	viper.Set(flagRef, "v1.0.0")
+	_, err = branchRegex()
+	assert.ErrorContains(t, err, "can not be combined")
+
+	viper.Set(flagBranchRegex, "")
+	viper.Set(flagAllBranches, true)
+	_, err = branchRegex()
+	assert.ErrorContains(t, err, "can not be combined")
+
+	viper.Set(flagRef, "")
+	viper.Set(flagBranchRegex, "(")
+	_, err = branchRegex()
+	assert.ErrorContains(t, err, flagBranchRegex)
+}
+
+func TestFetchStrategy(t *testing.T) {
+	t.Cleanup(viper.Reset)
+
+	fetch, err := fetchStrategy()
+	require.NoError(t, err)
+	assert.Equal(t, scanner.FetchAuto, fetch)
+
+	viper.Set(flagFetch, "Archive")
+	fetch, err = fetchStrategy()
+	require.NoError(t, err)
+	assert.Equal(t, scanner.FetchArchive, fetch)
+
+	viper.Set(flagFetch, "clone")
+	_, err = fetchStrategy()
+	assert.ErrorContains(t, err, "unsupported --fetch clone")
+}
+
+func TestEntropyMatcher(t *testing.T) {
+	t.Cleanup(viper.Reset)
+
+	entropy, err := entropyMatcher()
+	require.NoError(t, err)
+	assert.Nil(t, entropy)
+
+	viper.Set(flagEntropy, []string{"base64", "hex=3.5"})
+	viper.Set(flagEntropyMinLength, 16)
+	entropy, err = entropyMatcher()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"base64=4.5", "hex=3.5"}, entropy.Charsets())
+
+	viper.Set(flagEntropy, []string{"base32"})
+	_, err = entropyMatcher()
+	assert.EqualError(t, err, `--entropy: invalid charset "base32" (base64, hex)`)
+
+	viper.Set(flagEntropy, []string{"hex"})
+	viper.Set(flagEntropyMinLength, 0)
+	_, err = entropyMatcher()
+	assert.EqualError(t, err, "--entropy-min-length: invalid minimum token length 0")
+}
+
+func TestSearchOptionsRegexes(t *testing.T) {
+	t.Cleanup(viper.Reset)
+
+	viper.Set(flagNameRegex, []string{`\.ya?ml$`})
+	viper.Set(flagContentRegex, []string{`password:\s*\S+`})
+	options, err := searchOptions()
+	require.NoError(t, err)
+	require.Len(t, options.NameRegex, 1)
+	assert.True(t, options.NameRegex[0].MatchString("values.yaml"))
+	require.Len(t, options.ContentRegex, 1)
+	assert.Equal(t, `password:\s*\S+`, options.ContentRegex[0].String())
+
+	viper.Set(flagPathRegex, []string{`^deploy/(prod`})
+	_, err = searchOptions()
+	assert.EqualError(t, err, "--path-regex: invalid regex \"^deploy/(prod\" at position 9: missing closing ) `^deploy/(prod`")
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/alican-uelger/deep-scan/internal/matcher"
+	"github.com/spf13/viper"
+)
+
+const flagContentFile = "content-file"
+
+// contentFileRegexPrefix marks the lines of a content file which are regexes
+const contentFileRegexPrefix = "regex:"
+
+// loadContentFiles reads the patterns of the --content-file files
+func loadContentFiles() ([]matcher.Pattern, error) {
+	var patterns []matcher.Pattern
+	for _, name := range viper.GetStringSlice(flagContentFile) {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("--%s: %w", flagContentFile, err)
+		}
+		filePatterns, err := parseContentFile(name, data)
+		if err != nil {
+			return nil, fmt.Errorf("--%s: %w", flagContentFile, err)
+		}
+		patterns = append(patterns, filePatterns...)
+	}
+	return patterns, nil
+}
+
+/*
+parseContentFile parses one pattern per line, lines starting with "regex:" are regexes.
+Surrounding whitespace is trimmed, empty lines and lines starting with # are skipped.
+*/
+func parseContentFile(name string, data []byte) ([]matcher.Pattern, error) {
+	var patterns []matcher.Pattern
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if regex, ok := strings.CutPrefix(text, contentFileRegexPrefix); ok {
+			compiled, err := matcher.CompileRegex(regex)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", name, line, err)
+			}
+			patterns = append(patterns, matcher.NewRegexPattern(compiled))
+			continue
+		}
+		patterns = append(patterns, matcher.Pattern{Search: text, Type: matcher.TextSearch})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading %s failed: %w", name, err)
+	}
+	return patterns, nil
+}
@@ -0,0 +1,50 @@
+//go:build unit
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/alican-uelger/deep-scan/internal/matcher"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseContentFile(t *testing.T) {
+	patterns, err := parseContentFile("patterns.txt", []byte("# leaked tokens\nghp_abc\n\n  xoxb-123  \r\nregex:tok_[0-9]+\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []matcher.Pattern{
+		{Search: "ghp_abc", Type: matcher.TextSearch},
+		{Search: "xoxb-123", Type: matcher.TextSearch},
+		matcher.NewRegexPattern(regexp.MustCompile("tok_[0-9]+")),
+	}, patterns)
+
+	_, err = parseContentFile("patterns.txt", []byte("a\nregex:tok_(\n"))
+	assert.EqualError(t, err, "patterns.txt:2: invalid regex \"tok_(\" at position 5: missing closing ) `tok_(`")
+}
+
+func TestLoadContentFiles(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.txt")
+	second := filepath.Join(dir, "second.txt")
+	require.NoError(t, os.WriteFile(first, []byte("a\n"), 0o600))
+	require.NoError(t, os.WriteFile(second, []byte("regex:b+\n"), 0o600))
+
+	patterns, err := loadContentFiles()
+	require.NoError(t, err)
+	assert.Empty(t, patterns)
+
+	viper.Set(flagContentFile, []string{first, second})
+	patterns, err = loadContentFiles()
+	require.NoError(t, err)
+	assert.Equal(t, []matcher.Pattern{{Search: "a", Type: matcher.TextSearch}, matcher.NewRegexPattern(regexp.MustCompile("b+"))}, patterns)
+
+	viper.Set(flagContentFile, []string{filepath.Join(dir, "missing.txt")})
+	_, err = loadContentFiles()
+	assert.ErrorContains(t, err, "--content-file: open ")
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/alican-uelger/deep-scan/internal/scanner"
 	"github.com/spf13/cobra"
 	"log/slog"
+	"net/http"
 	"os"
 )
 
@@ -21,11 +22,14 @@ func NewGitHubScannerCmd() *cobra.Command {
 			return requireEnvs(envGitHubToken)
 		},
 	}
-	githubClient, err := git.NewGitHub(os.Getenv(envGitHubToken), os.Getenv(envGitHubHost))
+	addGitSourceFlags(cmd.PersistentFlags())
+	bindFlags(cmd)
+	githubClient, err := git.NewGitHub(os.Getenv(envGitHubToken), os.Getenv(envGitHubHost), git.NewRateLimitTransport(http.DefaultTransport, transportOptions))
 	if err != nil {
 		slog.Error(fmt.Sprintf("Error creating github client: %v", err))
 	}
 	githubScanner := scanner.NewGitlab(githubClient)
 	cmd.AddCommand(NewSearchCmd(flagGitOrg, githubScanner))
+	cmd.AddCommand(NewSopsKeysCmd(flagGitOrg, githubScanner))
 	return cmd
 }
@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
 
 	"github.com/alican-uelger/deep-scan/internal/git"
@@ -22,12 +23,15 @@ func NewGitLabScannerCmd() *cobra.Command {
 			return requireEnvs(envGitlabToken)
 		},
 	}
-	gitlabClient, err := git.NewGitLab(os.Getenv(envGitlabToken), os.Getenv(envGitlabHost))
+	addGitSourceFlags(cmd.PersistentFlags())
+	bindFlags(cmd)
+	gitlabClient, err := git.NewGitLab(os.Getenv(envGitlabToken), os.Getenv(envGitlabHost), git.NewRateLimitTransport(http.DefaultTransport, transportOptions))
 	if err != nil {
 		slog.Error(fmt.Sprintf("Error creating gitlab client: %v", err))
 	}
 	gitlabScanner := scanner.NewGitlab(gitlabClient)
 	cmd.AddCommand(NewSearchCmd(flagGitOrg, gitlabScanner))
+	cmd.AddCommand(NewSopsKeysCmd(flagGitOrg, gitlabScanner))
 	return cmd
 }
 
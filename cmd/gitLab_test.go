@@ -19,12 +19,16 @@ func TestNewGitlabScannerCmd(t *testing.T) {
 	cmd := NewGitLabScannerCmd()
 	assert.NotNil(t, cmd)
 	assert.Equal(t, "gitlab", cmd.Use)
+	assert.NotNil(t, cmd.PersistentFlags().Lookup(flagConcurrency))
 }
 
 func TestAddGitlabScannerFlags(t *testing.T) {
 	cmd := &cobra.Command{}
 	addGitScannerFlags(cmd.PersistentFlags())
 	assert.NotNil(t, cmd.PersistentFlags().Lookup(flagGitOrg))
+	for _, flag := range []string{flagArchived, flagForks, flagVisibility, flagTopic, flagLanguage, flagPushedAfter, flagRepoRegex, flagExcludeRepoRegex, flagRef, flagAllBranches, flagBranchRegex, flagFetch} {
+		assert.NotNil(t, cmd.PersistentFlags().Lookup(flag), "Flag %s should be present", flag)
+	}
 }
 
 func TestRequireEnvs(t *testing.T) {
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/alican-uelger/deep-scan/internal/scanner"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+const (
+	flagAllRefs    = "all-refs"
+	flagSince      = "since"
+	flagMaxCommits = "max-commits"
+)
+
+// NewHistoryScannerCmd scans the git history of a local repository, it works offline on any clone
+func NewHistoryScannerCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "git",
+		Short: "Scan the lines added by the commits of a local git repository",
+	}
+	addHistoryScannerFlags(cmd.PersistentFlags())
+	bindFlags(cmd)
+	historyScanner := scanner.NewHistory()
+	cmd.AddCommand(NewHistorySearchCmd(historyScanner))
+	return cmd
+}
+
+// NewHistorySearchCmd searches the history of a local repository, the flags of the git hosts do not apply to it
+func NewHistorySearchCmd(scanner Scanner) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:  "search",
+		RunE: historySearch(scanner),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			bindFlags(cmd)
+		},
+	}
+	addSearchFlags(cmd.PersistentFlags())
+	addOutputFLags(cmd.PersistentFlags())
+	bindFlags(cmd)
+	return cmd
+}
+
+func historySearch(scanner Scanner) RunE {
+	run := search(flagDir, scanner)
+	return func(cmd *cobra.Command, args []string) error {
+		if err := checkHistoryFlags(); err != nil {
+			return err
+		}
+		return run(cmd, args)
+	}
+}
+
+// checkHistoryFlags rejects the flags which need SOPS decryption or a sops config, the history scan does not support them
+func checkHistoryFlags() error {
+	for _, flag := range []string{flagSops, flagSopsPolicy} {
+		if viper.GetBool(flag) {
+			return fmt.Errorf("--%s is not supported for the git history", flag)
+		}
+	}
+	for _, flag := range []string{flagSopsKeyPath, flagSopsValue, flagSopsValueRegex} {
+		if len(viper.GetStringSlice(flag)) > 0 {
+			return fmt.Errorf("--%s is not supported for the git history, it needs SOPS decryption", flag)
+		}
+	}
+	return nil
+}
+
+func addHistoryScannerFlags(flagSet *pflag.FlagSet) {
+	flagSet.StringP(flagDir, "d", ".", "The directory of the git repository to scan")
+	flagSet.Int(flagConcurrency, defaultIOConcurrency, "Maximum number of concurrently scanned files")
+	flagSet.Bool(flagAllRefs, false, "Scan the commits of all branches and tags instead of HEAD")
+	flagSet.String(flagSince, "", "Only scan commits after this date (e.g. 2024-01-31 or '2 weeks ago')")
+	flagSet.Int(flagMaxCommits, 0, "Maximum number of commits to scan, starting with the newest (0 scans all)")
+}
@@ -0,0 +1,59 @@
+//go:build unit
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHistoryScannerCmd(t *testing.T) {
+	cmd := NewHistoryScannerCmd()
+	assert.NotNil(t, cmd)
+	assert.Equal(t, "git", cmd.Use)
+}
+
+func TestAddHistoryScannerFlags(t *testing.T) {
+	cmd := &cobra.Command{}
+	addHistoryScannerFlags(cmd.PersistentFlags())
+	for _, flag := range []string{flagDir, flagConcurrency, flagAllRefs, flagSince, flagMaxCommits} {
+		assert.NotNil(t, cmd.PersistentFlags().Lookup(flag), "Flag %s should be present", flag)
+	}
+}
+
+func TestNewHistorySearchCmdFlags(t *testing.T) {
+	cmd := NewHistorySearchCmd(NewScannerMock(t))
+	for _, flag := range []string{flagContent, flagRules, flagSopsKey, flagOutput} {
+		assert.NotNil(t, cmd.PersistentFlags().Lookup(flag), "Flag %s should be present", flag)
+	}
+	// the flags of the git hosts do nothing for a local repository
+	for _, flag := range []string{flagGitOrg, flagGitProject, flagRef, flagFetch, flagCacheDir, flagArchived, flagRateLimit} {
+		assert.Nil(t, cmd.PersistentFlags().Lookup(flag), "Flag %s should not be present", flag)
+	}
+}
+
+func TestHistorySearchRejectsSopsDecryption(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{args: []string{"--sops"}, expected: "--sops is not supported for the git history"},
+		{args: []string{"--sops-policy"}, expected: "--sops-policy is not supported for the git history"},
+		{args: []string{"--sops-value", "secret"}, expected: "--sops-value is not supported for the git history, it needs SOPS decryption"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.args[0], func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+
+			// the scanner is never called
+			cmd := NewHistorySearchCmd(NewScannerMock(t))
+			cmd.SetArgs(tt.args)
+			err := cmd.Execute()
+			assert.EqualError(t, err, tt.expected)
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"sync"
+
+	"github.com/alican-uelger/deep-scan/internal/scanner"
+)
+
+// ignoredMatches collects the matches ignored by inline annotations, they are listed after the search for reviews
+type ignoredMatches struct {
+	mu      sync.Mutex
+	matches []scanner.IgnoredMatch
+}
+
+func (i *ignoredMatches) add(match scanner.IgnoredMatch) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.matches = append(i.matches, match)
+}
+
+// summarize logs the number of ignored matches and where they were ignored, the snippets are not logged
+func (i *ignoredMatches) summarize() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if len(i.matches) == 0 {
+		return
+	}
+	files := map[string]bool{}
+	lines := make([]string, 0, len(i.matches))
+	for _, match := range i.matches {
+		location := filepath.Join(match.Path, match.Name)
+		if match.Ref != "" {
+			location += "@" + match.Ref
+		}
+		files[location] = true
+		lines = append(lines, fmt.Sprintf("\t%s:%d: %s by deep-scan:%s", location, match.Line, match.Rule, match.Annotation))
+	}
+	slog.Info(fmt.Sprintf("%d matches in %d files ignored by deep-scan:ignore annotations:", len(i.matches), len(files)))
+	for _, line := range lines {
+		slog.Info(line)
+	}
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+
+	"github.com/alican-uelger/deep-scan/internal/scanner"
+)
+
+// ndjsonStream writes every file found to the output file while the search is running
+type ndjsonStream struct {
+	mu   sync.Mutex
+	file *os.File
+	err  error
+}
+
+func newNDJSONStream(name string) (*ndjsonStream, error) {
+	if name == "" {
+		name = "output.ndjson"
+	}
+	file, err := os.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	return &ndjsonStream{file: file}, nil
+}
+
+func (s *ndjsonStream) write(fileMatch scanner.FileMatch) {
+	line, err := scanner.NDJSONLine(fileMatch)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return
+	}
+	if err == nil {
+		_, err = s.file.Write(line)
+	}
+	// the first error aborts the stream, it is returned by close
+	s.err = err
+}
+
+func (s *ndjsonStream) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.file.Close()
+	if s.err != nil {
+		return s.err
+	}
+	if err != nil {
+		return err
+	}
+	slog.Info(fmt.Sprintf("Output written to %s", s.file.Name()))
+	return nil
+}
+
+// ndjsonOutput writes every element of a slice as one line
+func ndjsonOutput(name string, data any) error {
+	if name == "" {
+		name = "output.ndjson"
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	var elements []json.RawMessage
+	if err := json.Unmarshal(raw, &elements); err != nil {
+		return fmt.Errorf("ndjson output requires a list: %w", err)
+	}
+	var content []byte
+	for _, element := range elements {
+		content = append(content, element...)
+		content = append(content, '\n')
+	}
+	err = os.WriteFile(name, content, 0644)
+	if err != nil {
+		return err
+	}
+	slog.Info(fmt.Sprintf("Output written to %s", name))
+	return nil
+}
@@ -0,0 +1,64 @@
+//go:build unit
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alican-uelger/deep-scan/internal/scanner"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearch_StreamsNDJSONOutput(t *testing.T) {
+	t.Cleanup(viper.Reset)
+
+	name := filepath.Join(t.TempDir(), "output.ndjson")
+	scannerMock := NewScannerMock(t)
+	scannerMock.
+		On("Search", mock.Anything, "myorg", mock.MatchedBy(func(options scanner.SearchOptions) bool {
+			return options.OnMatch != nil
+		})).
+		Run(func(args mock.Arguments) {
+			options := args.Get(2).(scanner.SearchOptions)
+			options.OnMatch(scanner.FileMatch{File: scanner.File{Name: "a.txt", Path: "dir", Type: scanner.FILE}})
+			options.OnMatch(scanner.FileMatch{File: scanner.File{Name: "b.txt", Path: "dir", Type: scanner.FILE}})
+		}).
+		Return([]scanner.FileMatch{}, nil)
+
+	cmd := NewSearchCmd(flagGitOrg, scannerMock)
+	cmd.SetArgs([]string{"--org", "myorg", "--output", "ndjson", "--output-name", name})
+	require.NoError(t, cmd.Execute())
+
+	content, err := os.ReadFile(name)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"name":"a.txt"`)
+	assert.Contains(t, lines[1], `"name":"b.txt"`)
+}
+
+func TestSearch_InvalidFormat(t *testing.T) {
+	t.Cleanup(viper.Reset)
+
+	cmd := NewSearchCmd(flagGitOrg, NewScannerMock(t))
+	cmd.SetArgs([]string{"--org", "myorg", "--format", "xml"})
+	assert.ErrorContains(t, cmd.Execute(), "unsupported format")
+}
+
+func TestNDJSONOutput(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "output.ndjson")
+	usages := []scanner.SopsKeyUsage{{Repositories: []string{"a"}}, {Repositories: []string{"b"}}}
+
+	require.NoError(t, ndjsonOutput(name, usages))
+
+	content, err := os.ReadFile(name)
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(content), "\n"))
+	assert.Error(t, ndjsonOutput(name, map[string]string{}))
+}
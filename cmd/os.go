@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"context"
+
 	"github.com/alican-uelger/deep-scan/internal/scanner"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -9,7 +11,7 @@ import (
 const flagDir = "dir"
 
 type Scanner interface {
-	Search(string, scanner.SearchOptions) ([]scanner.FileMatch, error)
+	Search(context.Context, string, scanner.SearchOptions) ([]scanner.FileMatch, error)
 }
 
 func NewOsScannerCmd() *cobra.Command {
@@ -20,9 +22,11 @@ func NewOsScannerCmd() *cobra.Command {
 	bindFlags(cmd)
 	osScanner := scanner.NewOs()
 	cmd.AddCommand(NewSearchCmd(flagDir, osScanner))
+	cmd.AddCommand(NewSopsKeysCmd(flagDir, osScanner))
 	return cmd
 }
 
 func addOsScannerFlags(flagSet *pflag.FlagSet) {
 	flagSet.StringP(flagDir, "d", ".", "The directory to scan")
+	flagSet.Int(flagConcurrency, defaultIOConcurrency, "Maximum number of concurrent file system operations")
 }
@@ -19,6 +19,7 @@ func TestAddOsScannerFlags(t *testing.T) {
 	cmd := &cobra.Command{}
 	addOsScannerFlags(cmd.PersistentFlags())
 	assert.NotNil(t, cmd.PersistentFlags().Lookup(flagDir))
+	assert.NotNil(t, cmd.PersistentFlags().Lookup(flagConcurrency))
 }
 
 func BenchmarkReadAndAnalyzeFiles(b *testing.B) {
@@ -2,9 +2,14 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/alican-uelger/deep-scan/internal/git"
+	"github.com/alican-uelger/deep-scan/internal/scanner"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
@@ -16,6 +21,14 @@ const (
 	flagGitProject = "project"
 )
 
+const (
+	defaultIOConcurrency  = 32
+	defaultAPIConcurrency = 8
+	defaultRequestTimeout = 30 * time.Second
+	defaultRateLimit      = 10
+	defaultMaxRetries     = 5
+)
+
 // NewRootCmd creates the root command for the CLI application.
 func NewRootCmd() *cobra.Command {
 	cmd := &cobra.Command{
@@ -30,12 +43,40 @@ func NewRootCmd() *cobra.Command {
 	cmd.AddCommand(NewOsScannerCmd())
 	cmd.AddCommand(NewGitLabScannerCmd())
 	cmd.AddCommand(NewGitHubScannerCmd())
+	cmd.AddCommand(NewHistoryScannerCmd())
+	cmd.AddCommand(NewCacheCmd())
+	cmd.AddCommand(NewRulesCmd())
 	return cmd
 }
 
 func addGitScannerFlags(flagSet *pflag.FlagSet) {
 	flagSet.StringP(flagGitOrg, "o", "", "The git org to scan")
-	flagSet.StringP(flagGitProject, "r", "", "The specific project/repository to scan (owner/repo or group/project)")
+	flagSet.StringSliceP(flagGitProject, "r", []string{}, "The specific projects/repositories to scan (owner/repo, group/subgroup/project or numeric ID), can be repeated")
+	flagSet.String(flagRef, "", "The branch, tag or commit SHA to scan instead of the default branch")
+	flagSet.Bool(flagAllBranches, false, "Scan all branches")
+	flagSet.String(flagBranchRegex, "", "Scan all branches matching this regex")
+	flagSet.String(flagFetch, scanner.FetchAuto, "How files are fetched: archive (one download per repository), files (one request per file) or auto")
+	addCacheFlags(flagSet)
+	addRepoFilterFlags(flagSet)
+}
+
+// addRepoFilterFlags adds the flags selecting the repositories of an org scan
+func addRepoFilterFlags(flagSet *pflag.FlagSet) {
+	flagSet.String(flagArchived, string(git.Include), "Archived repositories: include, exclude or only")
+	flagSet.String(flagForks, string(git.Include), "Forked repositories: include, exclude or only")
+	flagSet.StringSlice(flagVisibility, []string{}, "Only scan repositories with this visibility (public, private, internal)")
+	flagSet.StringSlice(flagTopic, []string{}, "Only scan repositories with at least one of these topics")
+	flagSet.StringSlice(flagLanguage, []string{}, "Only scan repositories with this primary language")
+	flagSet.String(flagPushedAfter, "", "Only scan repositories pushed after this date (2024-01-31) or within this age (e.g. 90d)")
+	flagSet.String(flagRepoRegex, "", "Only scan repositories whose full path matches this regex")
+	flagSet.String(flagExcludeRepoRegex, "", "Exclude repositories whose full path matches this regex")
+}
+
+func addGitSourceFlags(flagSet *pflag.FlagSet) {
+	flagSet.Int(flagConcurrency, defaultAPIConcurrency, "Maximum number of concurrent API calls")
+	flagSet.Duration(flagRequestTimeout, defaultRequestTimeout, "Timeout of a single attempt of an API request (0 disables it)")
+	flagSet.Float64(flagRateLimit, defaultRateLimit, "Maximum number of API requests per second (0 disables the throttling)")
+	flagSet.Int(flagMaxRetries, defaultMaxRetries, "Retries of API requests failing with a rate limit, a 5xx status or a network error")
 }
 
 func addRootFlags(flagSet *pflag.FlagSet) {
@@ -47,12 +88,26 @@ func bindFlags(cmd *cobra.Command) {
 	if err != nil {
 		slog.Error(fmt.Sprintf("failed to bind flags: %v", err))
 	}
+	// flags of the parent commands (e.g. the per source concurrency) are shared by name,
+	// rebinding them ensures the values of the executed command are used
+	err = viper.BindPFlags(cmd.InheritedFlags())
+	if err != nil {
+		slog.Error(fmt.Sprintf("failed to bind inherited flags: %v", err))
+	}
 }
 
 func setupLogger() {
 	l := logLevel()
 	options := &slog.HandlerOptions{Level: l}
-	handler := slog.NewJSONHandler(os.Stdout, options)
+	handler := slog.NewJSONHandler(logWriter(), options)
 	slog.SetDefault(slog.New(handler))
 	slog.Info("using loglevel " + l.String())
 }
+
+// logWriter keeps stdout machine-parseable when results are streamed as ndjson
+func logWriter() io.Writer {
+	if strings.EqualFold(viper.GetString(flagFormat), scanner.FormatNDJSON) {
+		return os.Stderr
+	}
+	return os.Stdout
+}
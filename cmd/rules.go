@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/alican-uelger/deep-scan/internal/matcher"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const flagRules = "rules"
+
+// NewRulesCmd lists the rules of the rule packs
+func NewRulesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rules",
+		Short: "Manage the secret detection rules",
+	}
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List the rules of the rule packs of --rules",
+		PreRun: func(cmd *cobra.Command, args []string) {
+			bindFlags(cmd)
+		},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			rules, err := loadRules()
+			if err != nil {
+				return err
+			}
+			if rules == nil {
+				return fmt.Errorf("--%s is required", flagRules)
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tSEVERITY\tDESCRIPTION")
+			for _, rule := range rules.List() {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", rule.ID, rule.Severity, rule.Description)
+			}
+			return w.Flush()
+		},
+	}
+	list.PersistentFlags().StringSlice(flagRules, []string{matcher.DefaultRulePack}, "The rule packs to list")
+	bindFlags(list)
+	cmd.AddCommand(list)
+	return cmd
+}
+
+// loadRules loads the rule packs of --rules, it returns nil without rule packs
+func loadRules() (*matcher.Rules, error) {
+	names := viper.GetStringSlice(flagRules)
+	if len(names) == 0 {
+		return nil, nil
+	}
+	packs := make([]*matcher.RulePack, 0, len(names))
+	for _, name := range names {
+		pack, err := matcher.LoadRulePack(name)
+		if err != nil {
+			return nil, fmt.Errorf("--%s: %w", flagRules, err)
+		}
+		packs = append(packs, pack)
+	}
+	return matcher.NewRules(packs...)
+}
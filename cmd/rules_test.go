@@ -0,0 +1,30 @@
+//go:build unit
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/alican-uelger/deep-scan/internal/matcher"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadRules(t *testing.T) {
+	t.Cleanup(viper.Reset)
+
+	rules, err := loadRules()
+	require.NoError(t, err)
+	assert.Nil(t, rules)
+
+	viper.Set(flagRules, []string{matcher.DefaultRulePack})
+	rules, err = loadRules()
+	require.NoError(t, err)
+	_, ok := rules.Rule("aws-access-key-id")
+	assert.True(t, ok)
+
+	viper.Set(flagRules, []string{"unknown"})
+	_, err = loadRules()
+	assert.EqualError(t, err, `--rules: unknown rule pack "unknown", bundled rule packs: default`)
+}
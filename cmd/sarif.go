@@ -0,0 +1,372 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strings"
+
+	"github.com/alican-uelger/deep-scan/internal/matcher"
+	"github.com/alican-uelger/deep-scan/internal/scanner"
+	"github.com/spf13/viper"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	toolName     = "deep-scan"
+	toolURI      = "https://github.com/alican-uelger/deep-scan"
+)
+
+// rules of files reported without a match, e.g. by --sops or --sops-policy
+const (
+	ruleFile            = "file"
+	ruleSopsSecret      = "sops-secret"
+	ruleSopsConfig      = "sops-config"
+	ruleSopsUnencrypted = "sops-unencrypted"
+)
+
+var sarifRuleDescriptions = map[string]string{
+	scanner.RuleName:           "File name matches --name",
+	scanner.RuleNameContains:   "File name matches --name-contains",
+	scanner.RuleNameRegex:      "File name matches --name-regex",
+	scanner.RulePath:           "File path matches --path",
+	scanner.RulePathContains:   "File path matches --path-contains",
+	scanner.RulePathRegex:      "File path matches --path-regex",
+	scanner.RuleContent:        "Content matches --content",
+	scanner.RuleContentRegex:   "Content matches --content-regex",
+	scanner.RuleContentFile:    "Content matches a pattern of --content-file",
+	scanner.RuleEntropy:        "Content contains a high-entropy string found by --entropy",
+	scanner.RuleSopsKeyPath:    "Decrypted SOPS key matches --sops-key-path",
+	scanner.RuleSopsValue:      "Decrypted SOPS value matches --sops-value",
+	scanner.RuleSopsValueRegex: "Decrypted SOPS value matches --sops-value-regex",
+	ruleFile:                   "File matches the search filters",
+	ruleSopsSecret:             "SOPS encrypted file",
+	ruleSopsConfig:             "SOPS configuration (.sops.yaml)",
+	ruleSopsUnencrypted:        "Unencrypted file matching a SOPS creation rule",
+}
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool                     sarifTool                    `json:"tool"`
+	Results                  []sarifResult                `json:"results"`
+	VersionControlProvenance []sarifVersionControlDetails `json:"versionControlProvenance,omitempty"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+	// BaselineState is only set for incremental scans: new, unchanged or absent
+	BaselineState string `json:"baselineState,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int           `json:"startLine"`
+	StartColumn int           `json:"startColumn,omitempty"`
+	EndColumn   int           `json:"endColumn,omitempty"`
+	Snippet     *sarifMessage `json:"snippet,omitempty"`
+}
+
+type sarifVersionControlDetails struct {
+	RepositoryURI string `json:"repositoryUri"`
+	RevisionID    string `json:"revisionId,omitempty"`
+	Branch        string `json:"branch,omitempty"`
+}
+
+func sarifOutput(name string, data any) error {
+	files, ok := data.([]scanner.FileMatch)
+	if !ok {
+		return fmt.Errorf("sarif output is only supported for search results")
+	}
+	if name == "" {
+		name = "output.sarif"
+	}
+	rules, err := loadRules()
+	if err != nil {
+		return err
+	}
+	report, err := json.MarshalIndent(buildSarifLog(files, viper.GetBool(flagNoSnippets), rules), "", "\t")
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(name, report, 0644)
+	if err != nil {
+		return err
+	}
+	slog.Info(fmt.Sprintf("Output written to %s", name))
+	return nil
+}
+
+// sarifRunKey identifies the run of a file, a repository scanned at several refs has one run per ref
+type sarifRunKey struct {
+	repository string
+	ref        string
+}
+
+/*
+buildSarifLog creates one run per repository and ref, so the results of org scans can be uploaded per repository.
+The rules of the rule packs describe the matches found by them, the rules may be nil.
+*/
+func buildSarifLog(files []scanner.FileMatch, noSnippets bool, rules *matcher.Rules) sarifLog {
+	var keys []sarifRunKey
+	filesByRun := map[sarifRunKey][]scanner.FileMatch{}
+	for _, file := range files {
+		key := sarifRunKey{repository: file.Repository, ref: file.Ref}
+		if _, ok := filesByRun[key]; !ok {
+			keys = append(keys, key)
+		}
+		filesByRun[key] = append(filesByRun[key], file)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].repository != keys[j].repository {
+			return keys[i].repository < keys[j].repository
+		}
+		return keys[i].ref < keys[j].ref
+	})
+	// an empty run reports a clean scan
+	if len(keys) == 0 {
+		keys = append(keys, sarifRunKey{})
+	}
+
+	runs := []sarifRun{}
+	for _, key := range keys {
+		runs = append(runs, buildSarifRun(filesByRun[key], noSnippets, rules))
+	}
+	return sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs:    runs,
+	}
+}
+
+func buildSarifRun(files []scanner.FileMatch, noSnippets bool, rules *matcher.Rules) sarifRun {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           toolName,
+			Version:        toolVersion(),
+			InformationURI: toolURI,
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+	ruleIndex := map[string]int{}
+	addResult := func(ruleID string, result sarifResult) {
+		index, ok := ruleIndex[ruleID]
+		if !ok {
+			index = len(run.Tool.Driver.Rules)
+			ruleIndex[ruleID] = index
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+				ID:               ruleID,
+				ShortDescription: sarifMessage{Text: sarifRuleDescription(ruleID, rules)},
+			})
+		}
+		result.RuleID = ruleID
+		result.RuleIndex = index
+		run.Results = append(run.Results, result)
+	}
+
+	for _, file := range files {
+		if file.RepositoryURL != "" && len(run.VersionControlProvenance) == 0 {
+			run.VersionControlProvenance = []sarifVersionControlDetails{{
+				RepositoryURI: file.RepositoryURL,
+				RevisionID:    file.Commit,
+				Branch:        file.Ref,
+			}}
+		}
+		uri := sarifURI(file.File)
+		if len(file.Matches) == 0 {
+			ruleID := fileRule(file)
+			addResult(ruleID, sarifResult{
+				Level:         sarifLevel(ruleID),
+				Message:       sarifMessage{Text: fileMessage(file)},
+				Locations:     []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: uri}}}},
+				BaselineState: sarifBaselineState(file.Status),
+			})
+			continue
+		}
+		for _, m := range file.Matches {
+			ruleID := m.Rule
+			if m.RuleID != "" {
+				ruleID = m.RuleID
+			} else if ruleID == "" {
+				ruleID = fileRule(file)
+			}
+			location := sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: uri}}
+			if isContentRule(ruleID) {
+				location.Region = sarifMatchRegion(m, noSnippets)
+			}
+			level := sarifLevel(ruleID)
+			if m.Severity != "" {
+				level = severityLevel(m.Severity)
+			}
+			addResult(ruleID, sarifResult{
+				Level:         level,
+				Message:       sarifMessage{Text: matchMessage(m)},
+				Locations:     []sarifLocation{{PhysicalLocation: location}},
+				BaselineState: sarifBaselineState(file.Status),
+			})
+		}
+	}
+	return run
+}
+
+// sarifBaselineState maps the status of an incremental scan to the SARIF baseline state
+func sarifBaselineState(status string) string {
+	if status == scanner.StatusResolved {
+		return "absent"
+	}
+	return status
+}
+
+// sarifURI returns the path of the file relative to its repository, or the local path
+func sarifURI(file scanner.File) string {
+	location := filepath.ToSlash(filepath.Join(file.Path, file.Name))
+	if file.Repository != "" {
+		return strings.TrimPrefix(location, file.Repository+"/")
+	}
+	if filepath.IsAbs(location) {
+		return "file://" + location
+	}
+	return location
+}
+
+func sarifMatchRegion(m matcher.MatchResult, noSnippets bool) *sarifRegion {
+	region := &sarifRegion{
+		StartLine:   m.Line,
+		StartColumn: m.StartCol,
+		// the end column of sarif is exclusive
+		EndColumn: m.EndCol + 1,
+	}
+	if !noSnippets {
+		region.Snippet = &sarifMessage{Text: m.Snippet}
+	}
+	return region
+}
+
+func isContentRule(ruleID string) bool {
+	switch ruleID {
+	case scanner.RuleName, scanner.RuleNameContains, scanner.RuleNameRegex,
+		scanner.RulePath, scanner.RulePathContains, scanner.RulePathRegex:
+		return false
+	}
+	return true
+}
+
+func fileRule(file scanner.FileMatch) string {
+	switch file.Type {
+	case scanner.SOPS_SECRET:
+		return ruleSopsSecret
+	case scanner.SOPS_CONFIG:
+		return ruleSopsConfig
+	case scanner.SOPS_UNENCRYPTED:
+		return ruleSopsUnencrypted
+	}
+	return ruleFile
+}
+
+func sarifLevel(ruleID string) string {
+	switch ruleID {
+	case ruleSopsUnencrypted:
+		return "error"
+	case ruleFile, ruleSopsSecret, ruleSopsConfig,
+		scanner.RuleName, scanner.RuleNameContains, scanner.RuleNameRegex,
+		scanner.RulePath, scanner.RulePathContains, scanner.RulePathRegex:
+		return "note"
+	}
+	return "warning"
+}
+
+// severityLevel maps the severity of a rule of a rule pack to the SARIF level
+func severityLevel(severity matcher.Severity) string {
+	switch severity {
+	case matcher.SeverityHigh, matcher.SeverityCritical:
+		return "error"
+	case matcher.SeverityLow:
+		return "note"
+	}
+	return "warning"
+}
+
+func sarifRuleDescription(ruleID string, rules *matcher.Rules) string {
+	if description, ok := sarifRuleDescriptions[ruleID]; ok {
+		return description
+	}
+	if rules != nil {
+		if rule, ok := rules.Rule(ruleID); ok {
+			return rule.Description
+		}
+	}
+	return ""
+}
+
+func fileMessage(file scanner.FileMatch) string {
+	if file.SopsRule != nil {
+		return fmt.Sprintf("Not encrypted, but matches the SOPS creation rule path_regex '%s'", file.SopsRule.PathRegex)
+	}
+	return sarifRuleDescriptions[fileRule(file)]
+}
+
+func matchMessage(m matcher.MatchResult) string {
+	message := fmt.Sprintf("Match of --%s '%s'", m.Rule, m.Search)
+	if m.RuleID != "" {
+		message = fmt.Sprintf("Match of rule %s (%s)", m.RuleID, m.Severity)
+	} else if m.Rule == "" {
+		message = "Match"
+	}
+	if m.KeyPath != "" {
+		message += fmt.Sprintf(" in key %s", m.KeyPath)
+	}
+	return message
+}
+
+func toolVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return ""
+}
@@ -0,0 +1,115 @@
+//go:build unit
+
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alican-uelger/deep-scan/internal/matcher"
+	"github.com/alican-uelger/deep-scan/internal/scanner"
+	"github.com/alican-uelger/deep-scan/internal/sops"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildSarifLog(t *testing.T) {
+	files := []scanner.FileMatch{
+		{
+			File: scanner.File{Name: "main.go", Path: "group/api/cmd", Type: scanner.FILE, Repository: "group/api", RepositoryURL: "https://gitlab.com/group/api", Ref: "main", Commit: "abc123"},
+			Matches: []matcher.MatchResult{
+				{Line: 3, StartCol: 5, EndCol: 12, Snippet: "token = abc", Rule: scanner.RuleContentRegex, Search: "token"},
+				{Line: 1, StartCol: 1, EndCol: 4, Snippet: "main.go", Rule: scanner.RuleNameContains, Search: "main"},
+			},
+		},
+		{
+			File:     scanner.File{Name: "values.yaml", Path: "group/api", Type: scanner.SOPS_UNENCRYPTED, Repository: "group/api", RepositoryURL: "https://gitlab.com/group/api", Ref: "main", Commit: "abc123"},
+			SopsRule: &sops.CreationRule{PathRegex: `values\.yaml$`},
+		},
+		{
+			File: scanner.File{Name: "values.yaml", Path: "group/api", Type: scanner.FILE, Repository: "group/api", RepositoryURL: "https://gitlab.com/group/api", Ref: "release/1.0", Commit: "def456"},
+		},
+		{
+			File: scanner.File{Name: "README.md", Path: "group/web", Type: scanner.FILE, Repository: "group/web", RepositoryURL: "https://gitlab.com/group/web"},
+		},
+	}
+
+	log := buildSarifLog(files, false, nil)
+
+	assert.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 3)
+
+	api := log.Runs[0]
+	assert.Equal(t, []sarifVersionControlDetails{{RepositoryURI: "https://gitlab.com/group/api", RevisionID: "abc123", Branch: "main"}}, api.VersionControlProvenance)
+	require.Len(t, api.Results, 3)
+	require.Len(t, api.Tool.Driver.Rules, 3)
+
+	content := api.Results[0]
+	assert.Equal(t, scanner.RuleContentRegex, content.RuleID)
+	assert.Equal(t, "warning", content.Level)
+	assert.Equal(t, "cmd/main.go", content.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Equal(t, &sarifRegion{StartLine: 3, StartColumn: 5, EndColumn: 13, Snippet: &sarifMessage{Text: "token = abc"}}, content.Locations[0].PhysicalLocation.Region)
+
+	name := api.Results[1]
+	assert.Equal(t, scanner.RuleNameContains, name.RuleID)
+	assert.Nil(t, name.Locations[0].PhysicalLocation.Region)
+
+	unencrypted := api.Results[2]
+	assert.Equal(t, ruleSopsUnencrypted, unencrypted.RuleID)
+	assert.Equal(t, 2, unencrypted.RuleIndex)
+	assert.Equal(t, "error", unencrypted.Level)
+	assert.Equal(t, "values.yaml", unencrypted.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+
+	release := log.Runs[1]
+	assert.Equal(t, []sarifVersionControlDetails{{RepositoryURI: "https://gitlab.com/group/api", RevisionID: "def456", Branch: "release/1.0"}}, release.VersionControlProvenance)
+	require.Len(t, release.Results, 1)
+
+	web := log.Runs[2]
+	assert.Equal(t, ruleFile, web.Results[0].RuleID)
+	assert.Equal(t, "README.md", web.Results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI)
+}
+
+func TestBuildSarifLogWithoutFiles(t *testing.T) {
+	log := buildSarifLog(nil, false, nil)
+	require.Len(t, log.Runs, 1)
+	assert.Empty(t, log.Runs[0].Results)
+}
+
+func TestOutputSarif(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	name := filepath.Join(t.TempDir(), "output.sarif")
+	files := []scanner.FileMatch{{File: scanner.File{Name: "file.txt", Path: "dir", Type: scanner.FILE}}}
+
+	require.NoError(t, output("sarif", name, files))
+
+	content, err := os.ReadFile(name)
+	require.NoError(t, err)
+	var log map[string]any
+	require.NoError(t, json.Unmarshal(content, &log))
+	assert.Equal(t, "2.1.0", log["version"])
+
+	assert.Error(t, output("sarif", name, []scanner.SopsKeyUsage{}))
+}
+
+func TestBuildSarifLogRulePack(t *testing.T) {
+	pack, err := matcher.BundledRulePack(matcher.DefaultRulePack)
+	require.NoError(t, err)
+	rules, err := matcher.NewRules(pack)
+	require.NoError(t, err)
+	files := []scanner.FileMatch{{
+		File:    scanner.File{Name: ".env", Path: "dir", Type: scanner.FILE},
+		Matches: []matcher.MatchResult{{Line: 2, StartCol: 1, EndCol: 40, Rule: scanner.RuleRules, RuleID: "github-pat", Severity: matcher.SeverityHigh}},
+	}}
+
+	log := buildSarifLog(files, true, rules)
+
+	require.Len(t, log.Runs[0].Results, 1)
+	result := log.Runs[0].Results[0]
+	assert.Equal(t, "github-pat", result.RuleID)
+	assert.Equal(t, "error", result.Level)
+	assert.Equal(t, "Match of rule github-pat (high)", result.Message.Text)
+	assert.Equal(t, []sarifRule{{ID: "github-pat", ShortDescription: sarifMessage{Text: "GitHub personal access token (classic)"}}}, log.Runs[0].Tool.Driver.Rules)
+}
@@ -3,6 +3,8 @@
 package cmd
 
 import (
+	context "context"
+
 	scanner "github.com/alican-uelger/deep-scan/internal/scanner"
 	mock "github.com/stretchr/testify/mock"
 )
@@ -20,9 +22,9 @@ func (_m *ScannerMock) EXPECT() *ScannerMock_Expecter {
 	return &ScannerMock_Expecter{mock: &_m.Mock}
 }
 
-// Search provides a mock function with given fields: _a0, _a1
-func (_m *ScannerMock) Search(_a0 string, _a1 scanner.SearchOptions) ([]scanner.FileMatch, error) {
-	ret := _m.Called(_a0, _a1)
+// Search provides a mock function with given fields: _a0, _a1, _a2
+func (_m *ScannerMock) Search(_a0 context.Context, _a1 string, _a2 scanner.SearchOptions) ([]scanner.FileMatch, error) {
+	ret := _m.Called(_a0, _a1, _a2)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Search")
@@ -30,19 +32,19 @@ func (_m *ScannerMock) Search(_a0 string, _a1 scanner.SearchOptions) ([]scanner.
 
 	var r0 []scanner.FileMatch
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, scanner.SearchOptions) ([]scanner.FileMatch, error)); ok {
-		return rf(_a0, _a1)
+	if rf, ok := ret.Get(0).(func(context.Context, string, scanner.SearchOptions) ([]scanner.FileMatch, error)); ok {
+		return rf(_a0, _a1, _a2)
 	}
-	if rf, ok := ret.Get(0).(func(string, scanner.SearchOptions) []scanner.FileMatch); ok {
-		r0 = rf(_a0, _a1)
+	if rf, ok := ret.Get(0).(func(context.Context, string, scanner.SearchOptions) []scanner.FileMatch); ok {
+		r0 = rf(_a0, _a1, _a2)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]scanner.FileMatch)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string, scanner.SearchOptions) error); ok {
-		r1 = rf(_a0, _a1)
+	if rf, ok := ret.Get(1).(func(context.Context, string, scanner.SearchOptions) error); ok {
+		r1 = rf(_a0, _a1, _a2)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -56,15 +58,16 @@ type ScannerMock_Search_Call struct {
 }
 
 // Search is a helper method to define mock.On call
-//   - _a0 string
-//   - _a1 scanner.SearchOptions
-func (_e *ScannerMock_Expecter) Search(_a0 interface{}, _a1 interface{}) *ScannerMock_Search_Call {
-	return &ScannerMock_Search_Call{Call: _e.mock.On("Search", _a0, _a1)}
+//   - _a0 context.Context
+//   - _a1 string
+//   - _a2 scanner.SearchOptions
+func (_e *ScannerMock_Expecter) Search(_a0 interface{}, _a1 interface{}, _a2 interface{}) *ScannerMock_Search_Call {
+	return &ScannerMock_Search_Call{Call: _e.mock.On("Search", _a0, _a1, _a2)}
 }
 
-func (_c *ScannerMock_Search_Call) Run(run func(_a0 string, _a1 scanner.SearchOptions)) *ScannerMock_Search_Call {
+func (_c *ScannerMock_Search_Call) Run(run func(_a0 context.Context, _a1 string, _a2 scanner.SearchOptions)) *ScannerMock_Search_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string), args[1].(scanner.SearchOptions))
+		run(args[0].(context.Context), args[1].(string), args[2].(scanner.SearchOptions))
 	})
 	return _c
 }
@@ -74,7 +77,7 @@ func (_c *ScannerMock_Search_Call) Return(_a0 []scanner.FileMatch, _a1 error) *S
 	return _c
 }
 
-func (_c *ScannerMock_Search_Call) RunAndReturn(run func(string, scanner.SearchOptions) ([]scanner.FileMatch, error)) *ScannerMock_Search_Call {
+func (_c *ScannerMock_Search_Call) RunAndReturn(run func(context.Context, string, scanner.SearchOptions) ([]scanner.FileMatch, error)) *ScannerMock_Search_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -1,8 +1,12 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/alican-uelger/deep-scan/internal/matcher"
+	"github.com/alican-uelger/deep-scan/internal/redact"
 	"github.com/alican-uelger/deep-scan/internal/scanner"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -10,6 +14,7 @@ import (
 	"gopkg.in/yaml.v2"
 	"log/slog"
 	"os"
+	"runtime"
 	"strings"
 )
 
@@ -29,59 +34,161 @@ func NewSearchCmd(flagStartingPoint string, scanner Scanner) *cobra.Command {
 }
 
 const (
-	JSON string = "json"
-	YAML string = "yaml"
+	JSON   string = "json"
+	YAML   string = "yaml"
+	SARIF  string = "sarif"
+	NDJSON string = "ndjson"
 )
 
 func search(flagStartingPoint string, scanner Scanner) RunE {
-	return func(_ *cobra.Command, _ []string) error {
-		options := searchOptions()
-		org := viper.GetString(flagStartingPoint)
-		project := viper.GetString(flagGitProject)
-
-		if org == "" && project == "" {
-			return fmt.Errorf("provide at least one of --%s or --%s", flagGitOrg, flagGitProject)
+	return func(cmd *cobra.Command, _ []string) error {
+		options, err := searchOptions()
+		if err != nil {
+			return err
 		}
-		if org != "" && project != "" {
-			return fmt.Errorf("--%s and --%s are mutually exclusive", flagGitOrg, flagGitProject)
+		org, err := startingPoint(flagStartingPoint)
+		if err != nil {
+			return err
 		}
 
-		slog.Debug(fmt.Sprintf("running search with %s=%s, %s=%s, options: %v", flagStartingPoint, org, flagGitProject, project, options))
-		files, err := scanner.Search(org, options)
+		// ndjson files are written while searching, instead of at the end
+		o := viper.GetString(flagOutput)
+		var stream *ndjsonStream
+		if strings.EqualFold(o, NDJSON) {
+			stream, err = newNDJSONStream(viper.GetString(flagOutputName))
+			if err != nil {
+				return err
+			}
+			options.OnMatch = stream.write
+		}
+
+		skipped := &skippedFiles{}
+		options.OnSkip = skipped.add
+		ignored := &ignoredMatches{}
+		options.OnIgnore = ignored.add
+		c, err := openCache()
+		if err != nil {
+			return err
+		}
+		if c != nil {
+			options.Cache = c
+		}
+		stateFile := viper.GetString(flagStateFile)
+		if stateFile != "" {
+			options.State, err = loadState(stateFile)
+			if err != nil {
+				return err
+			}
+		}
+		options.Baseline, err = searchBaseline()
 		if err != nil {
+			return err
+		}
+
+		ctx, cancel := searchContext(cmd)
+		defer cancel()
+
+		slog.Debug(fmt.Sprintf("running search with %s=%s, %s=%v, options: %v", flagStartingPoint, org, flagGitProject, options.Projects, options))
+		files, err := scanner.Search(ctx, org, options)
+		closeCache(c)
+		if stream != nil {
+			if closeErr := stream.close(); closeErr != nil {
+				slog.Error(fmt.Sprintf("Error outputting files: %v", closeErr))
+				return closeErr
+			}
+		}
+		canceled := isCanceled(err)
+		if err != nil && !canceled {
 			slog.Error(fmt.Sprintf("Error searching for files: %v", err))
 			return err
 		}
+		if canceled {
+			// flush the partial results, so a canceled scan is not lost
+			slog.Warn(fmt.Sprintf("search canceled: %v - writing %d files found so far", err, len(files)))
+		}
 		slog.Debug(fmt.Sprintf("found %d files", len(files)))
-		o := viper.GetString(flagOutput)
-		if o != "" {
+		ignored.summarize()
+		if viper.GetString(flagBaseline) != "" {
+			slog.Info(fmt.Sprintf("%d findings suppressed by the baseline", options.Baseline.Suppressed()))
+		}
+		if o != "" && stream == nil {
 			name := viper.GetString(flagOutputName)
-			err = output(o, name, files)
-			if err != nil {
-				slog.Error(fmt.Sprintf("Error outputting files: %v", err))
-				return err
+			outputErr := output(o, name, files)
+			if outputErr != nil {
+				slog.Error(fmt.Sprintf("Error outputting files: %v", outputErr))
+				return outputErr
+			}
+		}
+		// a canceled search did not scan all refs, its state is incomplete
+		if stateFile != "" && !canceled {
+			if stateErr := saveState(stateFile, options.State); stateErr != nil {
+				slog.Error(fmt.Sprintf("Error writing state file: %v", stateErr))
+				return stateErr
+			}
+		}
+		// a canceled search would drop the findings of the files not scanned yet
+		if name := viper.GetString(flagWriteBaseline); name != "" && !canceled {
+			if baselineErr := saveBaseline(name, options.Baseline, files); baselineErr != nil {
+				slog.Error(fmt.Sprintf("Error writing baseline: %v", baselineErr))
+				return baselineErr
 			}
 		}
-		return nil
+		if skippedErr := skipped.summarize(); err == nil {
+			err = skippedErr
+		}
+		if err == nil {
+			err = checkFindings(files)
+		}
+		return err
+	}
+}
+
+// startingPoint returns the org/dir to scan, it is empty when projects are scanned
+func startingPoint(flagStartingPoint string) (string, error) {
+	org := viper.GetString(flagStartingPoint)
+	projects := viper.GetStringSlice(flagGitProject)
+
+	if org == "" && len(projects) == 0 {
+		return "", fmt.Errorf("provide at least one of --%s or --%s", flagGitOrg, flagGitProject)
+	}
+	if org != "" && len(projects) > 0 {
+		return "", fmt.Errorf("--%s and --%s are mutually exclusive", flagGitOrg, flagGitProject)
+	}
+	return org, nil
+}
+
+// searchContext applies the global --timeout to the context of the command
+func searchContext(cmd *cobra.Command) (context.Context, context.CancelFunc) {
+	if timeout := viper.GetDuration(flagTimeout); timeout > 0 {
+		return context.WithTimeout(cmd.Context(), timeout)
 	}
+	return context.WithCancel(cmd.Context())
 }
 
-func output(outputType string, name string, files []scanner.FileMatch) error {
+func isCanceled(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+func output(outputType string, name string, data any) error {
 	switch strings.ToLower(outputType) {
 	case JSON:
-		return jsonOutput(name, files)
+		return jsonOutput(name, data)
 	case YAML:
-		return yamlOutput(name, files)
+		return yamlOutput(name, data)
+	case SARIF:
+		return sarifOutput(name, data)
+	case NDJSON:
+		return ndjsonOutput(name, data)
 	default:
 		return fmt.Errorf("unsupported output type: %s", outputType)
 	}
 }
 
-func jsonOutput(name string, files []scanner.FileMatch) error {
+func jsonOutput(name string, data any) error {
 	if name == "" {
 		name = "output.json"
 	}
-	filesJson, err := json.MarshalIndent(files, "", "\t")
+	filesJson, err := json.MarshalIndent(data, "", "\t")
 	if err != nil {
 		return err
 	}
@@ -93,11 +200,11 @@ func jsonOutput(name string, files []scanner.FileMatch) error {
 	return nil
 }
 
-func yamlOutput(name string, files []scanner.FileMatch) error {
+func yamlOutput(name string, data any) error {
 	if name == "" {
 		name = "output.yaml"
 	}
-	filesYaml, err := yaml.Marshal(files)
+	filesYaml, err := yaml.Marshal(data)
 	if err != nil {
 		return err
 	}
@@ -110,32 +217,66 @@ func yamlOutput(name string, files []scanner.FileMatch) error {
 }
 
 func addOutputFLags(flagSet *pflag.FlagSet) {
-	flagSet.String(flagOutput, "", "Output to file (JSON, YAML)")
-	flagSet.String(flagOutputName, "", "Output file name (default: output.json / output.yaml)")
+	flagSet.String(flagOutput, "", "Output to file (JSON, YAML, SARIF, NDJSON)")
+	flagSet.String(flagOutputName, "", "Output file name (default: output.json / output.yaml / output.sarif / output.ndjson)")
 }
 
 func addSearchFlags(flagSet *pflag.FlagSet) {
-	// filename flags
-	flagSet.StringSliceP(flagName, "n", []string{}, "Search for files with specific names (exact match)")
-	flagSet.StringSlice(flagNameContains, []string{}, "Search for files with names containing this string")
-	flagSet.StringSlice(flagNameRegex, []string{}, "Search for files with names matching this regex")
-
-	// file path flags
-	flagSet.StringSliceP(flagPath, "p", []string{}, "Search in specific directories (exact match)")
-	flagSet.StringSlice(flagPathContains, []string{}, "Search in directories containing this string")
-	flagSet.StringSlice(flagPathRegex, []string{}, "Search in directories matching this regex")
+	addFileFilterFlags(flagSet)
 
 	// content flags
 	flagSet.StringSliceP(flagContent, "c", []string{}, "Search for files containing specific content")
 	flagSet.StringSlice(flagContentRegex, []string{}, "Search for files containing content matching this regex")
+	flagSet.StringSlice(flagContentFile, []string{}, "Search for files containing at least one of the patterns of this file (one per line, 'regex:' prefixes regexes, # comments)")
+	flagSet.StringSlice(flagRules, []string{}, "Search for secrets with the rules of these bundled rule packs (default) or rule pack files (deep-scan YAML, gitleaks TOML, trufflehog YAML), see 'deep-scan rules list'")
+	flagSet.StringSlice(flagEntropy, []string{}, "Search for high-entropy strings of these charsets as <charset>[=<threshold>] (base64 default 4.5, hex default 3.0), e.g. 'base64,hex=3.5'")
+	flagSet.Int(flagEntropyMinLength, matcher.DefaultEntropyMinLength, "Minimum length of the strings checked by --entropy")
 
 	// sops flags
 	flagSet.BoolP(flagSops, "s", false, "Search for SOPS-encrypted files")
 	flagSet.Bool(flagSopsOnly, false, "Search for files that are only SOPS-encrypted")
 	flagSet.StringSlice(flagSopsContentBeforeDecryption, []string{}, "Search for content in SOPS-encrypted files before decryption")
+	flagSet.StringSlice(flagSopsKey, []string{}, "Search for files encrypted with a specific key (age recipient, PGP fingerprint, AWS/GCP KMS key, Azure Key Vault URL or Vault transit path)")
+	flagSet.StringSlice(flagSopsKeyPath, []string{}, "Search for keys in decrypted SOPS documents by key path, e.g. 'db.*.password' ('*' matches one key, '**' any number of keys)")
+	flagSet.StringSlice(flagSopsValue, []string{}, "Search for values in decrypted SOPS documents containing this string")
+	flagSet.StringSlice(flagSopsValueRegex, []string{}, "Search for values in decrypted SOPS documents matching this regex")
+	flagSet.StringSlice(flagSopsFormat, []string{}, "Map file names to a SOPS format as <glob>=<format> (yaml, json, dotenv, ini, binary), e.g. '*.enc=json'")
+	flagSet.Bool(flagSopsPolicy, false, "Report unencrypted files matching a path_regex of a .sops.yaml creation rule")
+	flagSet.Int(flagSopsConcurrency, runtime.NumCPU(), "Maximum number of concurrent SOPS decryptions")
 
 	// output flags
 	flagSet.Bool(flagNoSnippets, false, "Suppress match snippets in output")
+	flagSet.String(flagRedact, string(redact.Mask), "Redaction of decrypted SOPS values in snippets (none, mask, partial, hash)")
+	flagSet.Int(flagRedactReveal, 4, "Number of characters revealed at the start and end of a value with --redact partial")
+	flagSet.String(flagRedactSalt, "", "Salt of the hashes with --redact hash (default: $"+envRedactSalt+")")
+	flagSet.Bool(flagRedactAll, false, "Redact the matches of all files, not only of decrypted SOPS files")
+
+	// exclude content flags
+	flagSet.StringSlice(flagExcludeContent, []string{}, "Exclude files containing specific content")
+
+	flagSet.Duration(flagTimeout, 0, "Abort the search after this duration (e.g. 10m), the results found so far are still written to --output")
+	flagSet.String(flagStateFile, "", "Scan only the files changed since the scan which wrote this state file and mark the findings as new, unchanged or resolved (gitlab, github)")
+	flagSet.Bool(flagFailOnSkipped, true, "Exit with an error when files could not be scanned, e.g. because their content could not be fetched")
+	flagSet.String(flagBaseline, "", "Do not report the accepted findings of this baseline file, only new findings are printed and fail the search with --fail-on-findings")
+	flagSet.String(flagWriteBaseline, "", "Write the findings of the search (and of --baseline) as accepted findings to this baseline file")
+	flagSet.Bool(flagFailOnFindings, false, "Exit with an error when files with findings are found, which are not in the --baseline")
+
+	flagSet.String(flagFormat, scanner.FormatText, "Console output format (text, ndjson), with ndjson every file found is printed as one JSON object per line and logs are written to stderr")
+
+	flagSet.Bool(flagLogLate, false, "This flag will log the results after the search is complete. This is useful for large searches, when you want to be as fast as possible.")
+}
+
+// addFileFilterFlags adds the flags filtering by file name and path
+func addFileFilterFlags(flagSet *pflag.FlagSet) {
+	// filename flags
+	flagSet.StringSliceP(flagName, "n", []string{}, "Search for files with specific names (exact match)")
+	flagSet.StringSlice(flagNameContains, []string{}, "Search for files with names containing this string")
+	flagSet.StringSlice(flagNameRegex, []string{}, "Search for files with names matching this regex")
+
+	// file path flags
+	flagSet.StringSliceP(flagPath, "p", []string{}, "Search in specific directories (exact match)")
+	flagSet.StringSlice(flagPathContains, []string{}, "Search in directories containing this string")
+	flagSet.StringSlice(flagPathRegex, []string{}, "Search in directories matching this regex")
 
 	// exclude filename flags
 	flagSet.StringSlice(flagExcludeName, []string{}, "Exclude files with specific names (exact match)")
@@ -144,7 +285,4 @@ func addSearchFlags(flagSet *pflag.FlagSet) {
 	// exclude file path flags
 	flagSet.StringSlice(flagExcludePath, []string{}, "Exclude specific directories (exact match)")
 	flagSet.StringSlice(flagExcludePathContains, []string{}, "Exclude directories containing this string")
-	flagSet.StringSlice(flagExcludeContent, []string{}, "Exclude files containing specific content")
-
-	flagSet.Bool(flagLogLate, false, "This flag will log the results after the search is complete. This is useful for large searches, when you want to be as fast as possible.")
 }
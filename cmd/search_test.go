@@ -3,11 +3,17 @@
 package cmd
 
 import (
+	"context"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/alican-uelger/deep-scan/internal/scanner"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewSearchCmd(t *testing.T) {
@@ -25,8 +31,8 @@ func TestAddSearchFlags(t *testing.T) {
 		flagName, flagNameContains, flagNameRegex,
 		flagPath, flagPathContains, flagPathRegex,
 		flagContent, flagContentRegex,
-		flagSops, flagSopsContentBeforeDecryption,
-		flagNoSnippets,
+		flagSops, flagSopsContentBeforeDecryption, flagSopsKey, flagSopsKeyPath, flagSopsValue, flagSopsValueRegex, flagSopsFormat, flagSopsPolicy, flagSopsConcurrency,
+		flagNoSnippets, flagFormat, flagRedact, flagRedactReveal, flagRedactSalt, flagRedactAll,
 		flagExcludeName, flagExcludeNameContains,
 		flagExcludePath, flagExcludePathContains, flagExcludeContent,
 	}
@@ -57,3 +63,40 @@ func TestSearch_BothOrgAndProject(t *testing.T) {
 
 	assert.ErrorContains(t, err, "mutually exclusive")
 }
+
+func TestSearch_CanceledWritesPartialOutput(t *testing.T) {
+	t.Cleanup(viper.Reset)
+
+	name := filepath.Join(t.TempDir(), "output.json")
+	files := []scanner.FileMatch{{File: scanner.File{Name: "file.txt", Path: "dir", Type: scanner.FILE}}}
+	scannerMock := NewScannerMock(t)
+	scannerMock.
+		On("Search", mock.Anything, "myorg", mock.Anything).
+		Return(files, context.Canceled)
+
+	cmd := NewSearchCmd(flagGitOrg, scannerMock)
+	cmd.SetArgs([]string{"--org", "myorg", "--output", "json", "--output-name", name})
+	err := cmd.Execute()
+
+	require.ErrorIs(t, err, context.Canceled)
+	content, err := os.ReadFile(name)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "file.txt")
+}
+
+func TestSearch_MultipleProjects(t *testing.T) {
+	t.Cleanup(viper.Reset)
+
+	scannerMock := NewScannerMock(t)
+	scannerMock.
+		On("Search", mock.Anything, "", mock.MatchedBy(func(options scanner.SearchOptions) bool {
+			return assert.ObjectsAreEqual([]string{"team/api", "group/sub/project", "42"}, options.Projects)
+		})).
+		Return([]scanner.FileMatch{}, nil)
+
+	cmd := NewSearchCmd(flagGitOrg, scannerMock)
+	cmd.SetArgs([]string{"--project", "team/api", "-r", "group/sub/project,42"})
+	err := cmd.Execute()
+
+	assert.NoError(t, err)
+}
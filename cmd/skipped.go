@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"sync"
+
+	"github.com/alican-uelger/deep-scan/internal/scanner"
+	"github.com/spf13/viper"
+)
+
+// skippedFiles collects the files a search could not scan, they are summarized after the search
+type skippedFiles struct {
+	mu    sync.Mutex
+	files []scanner.SkippedFile
+}
+
+func (s *skippedFiles) add(file scanner.SkippedFile) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files = append(s.files, file)
+}
+
+// summarize logs the skipped files, with --fail-on-skipped an error is returned when files were skipped
+func (s *skippedFiles) summarize() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.files) == 0 {
+		return nil
+	}
+	slog.Warn(fmt.Sprintf("%d files could not be scanned:", len(s.files)))
+	for _, file := range s.files {
+		location := filepath.Join(file.Path, file.Name)
+		if file.Ref != "" {
+			location += "@" + file.Ref
+		}
+		slog.Warn(fmt.Sprintf("\t%s: %s", location, file.Reason))
+	}
+	if !viper.GetBool(flagFailOnSkipped) {
+		return nil
+	}
+	return fmt.Errorf("%d files could not be scanned, the results are incomplete (disable with --%s=false)", len(s.files), flagFailOnSkipped)
+}
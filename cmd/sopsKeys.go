@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/alican-uelger/deep-scan/internal/scanner"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// NewSopsKeysCmd creates a command listing which SOPS keys are used by which files and repositories
+func NewSopsKeysCmd(flagStartingPoint string, scanner Scanner) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sops-keys",
+		Short: "Inventory of the SOPS keys and the files encrypted with them",
+		RunE:  sopsKeys(flagStartingPoint, scanner),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			bindFlags(cmd)
+		},
+	}
+	addGitScannerFlags(cmd.PersistentFlags())
+	addSopsKeysFlags(cmd.PersistentFlags())
+	addOutputFLags(cmd.PersistentFlags())
+	bindFlags(cmd)
+	return cmd
+}
+
+func sopsKeys(flagStartingPoint string, s Scanner) RunE {
+	return func(cmd *cobra.Command, _ []string) error {
+		options, err := searchOptions()
+		if err != nil {
+			return err
+		}
+		options.SopsMetadata = true
+		options.Silent = true
+		org, err := startingPoint(flagStartingPoint)
+		if err != nil {
+			return err
+		}
+
+		skipped := &skippedFiles{}
+		options.OnSkip = skipped.add
+		c, err := openCache()
+		if err != nil {
+			return err
+		}
+		if c != nil {
+			options.Cache = c
+		}
+
+		ctx, cancel := searchContext(cmd)
+		defer cancel()
+
+		slog.Debug(fmt.Sprintf("running sops key inventory with %s=%s, %s=%v", flagStartingPoint, org, flagGitProject, options.Projects))
+		files, err := s.Search(ctx, org, options)
+		closeCache(c)
+		canceled := isCanceled(err)
+		if err != nil && !canceled {
+			slog.Error(fmt.Sprintf("Error searching for sops files: %v", err))
+			return err
+		}
+		if canceled {
+			slog.Warn(fmt.Sprintf("search canceled: %v - the inventory only contains the %d files found so far", err, len(files)))
+		}
+
+		inventory := scanner.SopsKeyInventory(files, options.SopsKey)
+		fmt.Print(buildSopsKeyInventoryOutput(inventory))
+		o := viper.GetString(flagOutput)
+		if o != "" {
+			outputErr := output(o, viper.GetString(flagOutputName), inventory)
+			if outputErr != nil {
+				slog.Error(fmt.Sprintf("Error outputting sops key inventory: %v", outputErr))
+				return outputErr
+			}
+		}
+		if skippedErr := skipped.summarize(); err == nil {
+			err = skippedErr
+		}
+		return err
+	}
+}
+
+func buildSopsKeyInventoryOutput(inventory []scanner.SopsKeyUsage) string {
+	result := ""
+	for _, usage := range inventory {
+		result += "+----------------------------------------+\n"
+		result += fmt.Sprintf("Key:\t%s:%s\n", usage.Type, usage.ID)
+		if len(usage.Repositories) > 0 {
+			result += fmt.Sprintf("\tRepositories: %s\n", strings.Join(usage.Repositories, ", "))
+		}
+		result += fmt.Sprintf("\tFiles (%d):\n", len(usage.Files))
+		for _, file := range usage.Files {
+			result += fmt.Sprintf("\t\t%s (lastmodified: %s, sops: %s)\n", file.Path, file.LastModified.Format("2006-01-02T15:04:05Z07:00"), file.Version)
+		}
+		result += "+----------------------------------------+\n\n"
+	}
+	return result
+}
+
+func addSopsKeysFlags(flagSet *pflag.FlagSet) {
+	addFileFilterFlags(flagSet)
+	flagSet.StringSlice(flagSopsKey, []string{}, "Only list these keys (age recipient, PGP fingerprint, AWS/GCP KMS key, Azure Key Vault URL or Vault transit path)")
+	flagSet.StringSlice(flagSopsFormat, []string{}, "Map file names to a SOPS format as <glob>=<format> (yaml, json, dotenv, ini, binary), e.g. '*.enc=json'")
+	flagSet.Duration(flagTimeout, 0, "Abort the inventory after this duration (e.g. 10m)")
+	flagSet.Bool(flagFailOnSkipped, true, "Exit with an error when files could not be scanned, e.g. because their content could not be fetched")
+}
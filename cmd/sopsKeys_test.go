@@ -0,0 +1,36 @@
+//go:build unit
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/alican-uelger/deep-scan/internal/scanner"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSopsKeysCmd(t *testing.T) {
+	cmd := NewSopsKeysCmd("test", NewScannerMock(t))
+	assert.NotNil(t, cmd)
+	assert.Equal(t, "sops-keys", cmd.Use)
+	assert.NotNil(t, cmd.PersistentFlags().Lookup(flagSopsKey))
+}
+
+func TestSopsKeys_SearchesSopsMetadata(t *testing.T) {
+	t.Cleanup(viper.Reset)
+
+	scannerMock := NewScannerMock(t)
+	scannerMock.
+		On("Search", mock.Anything, "myorg", mock.MatchedBy(func(options scanner.SearchOptions) bool {
+			return options.SopsMetadata && options.Silent
+		})).
+		Return([]scanner.FileMatch{}, nil)
+
+	cmd := NewSopsKeysCmd(flagGitOrg, scannerMock)
+	cmd.SetArgs([]string{"--org", "myorg"})
+	err := cmd.Execute()
+	require.NoError(t, err)
+}
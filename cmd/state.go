@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/alican-uelger/deep-scan/internal/scanner"
+)
+
+const flagStateFile = "state-file"
+
+// loadState reads the state of the previous scan, a missing state file starts a full scan
+func loadState(name string) (*scanner.State, error) {
+	data, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		slog.Info(fmt.Sprintf("state file %s does not exist, scanning all files", name))
+		return scanner.NewState(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	state, err := scanner.ReadState(data)
+	if err != nil {
+		return nil, fmt.Errorf("reading state file %s failed: %w", name, err)
+	}
+	return state, nil
+}
+
+// saveState replaces the state file atomically, an interrupted write keeps the previous state
+func saveState(name string, state *scanner.State) error {
+	data, err := json.MarshalIndent(state, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(name, data); err != nil {
+		return err
+	}
+	slog.Info(fmt.Sprintf("State written to %s", name))
+	return nil
+}
+
+// writeFileAtomic writes a temporary file next to the file and renames it
+func writeFileAtomic(name string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
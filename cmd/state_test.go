@@ -0,0 +1,38 @@
+//go:build unit
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alican-uelger/deep-scan/internal/scanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "state.json")
+
+	// without a state file all files are scanned
+	state, err := loadState(name)
+	require.NoError(t, err)
+	assert.Equal(t, scanner.NewState(), state)
+
+	state.Refs["org/repo@main"] = scanner.RefState{
+		Repository: "org/repo",
+		Ref:        "main",
+		Commit:     "abc123",
+		Findings:   []scanner.FileMatch{{File: scanner.File{Name: "secrets.yaml", Path: "org/repo", Type: scanner.SOPS_SECRET}}},
+	}
+	require.NoError(t, saveState(name, state))
+
+	loaded, err := loadState(name)
+	require.NoError(t, err)
+	assert.Equal(t, state, loaded)
+
+	require.NoError(t, os.WriteFile(name, []byte("{"), 0o600))
+	_, err = loadState(name)
+	assert.ErrorContains(t, err, "reading state file")
+}
@@ -0,0 +1,268 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/alican-uelger/deep-scan/internal/git"
+)
+
+const (
+	blobsDir = "blobs"
+	treesDir = "trees"
+)
+
+type Options struct {
+	// MaxSize of all cached files in bytes, Prune removes the oldest files first, 0 is unlimited
+	MaxSize int64
+	// TTL of a cached file, older files are not used anymore and removed by Prune, 0 keeps them forever
+	TTL time.Duration
+}
+
+/*
+Cache is a content-addressed on-disk cache of git hosts: file contents are stored by their blob SHA and the
+trees of commits by the commit SHA. Both never change for the same SHA, so a cached file does not have to be
+invalidated, it is only removed to limit the size of the cache.
+The contents are stored exactly as returned by the git host, SOPS encrypted files are only stored as ciphertext.
+*/
+type Cache struct {
+	dir     string
+	options Options
+	now     func() time.Time
+	hits    atomic.Int64
+	misses  atomic.Int64
+}
+
+// New opens the cache in the directory, the directory is created when it does not exist
+func New(dir string, options Options) (*Cache, error) {
+	for _, sub := range []string{blobsDir, treesDir} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o700); err != nil {
+			return nil, fmt.Errorf("creating cache directory %s failed: %w", dir, err)
+		}
+	}
+	return &Cache{dir: dir, options: options, now: time.Now}, nil
+}
+
+// Blob returns the cached content of a blob, the content is verified against its SHA
+func (c *Cache) Blob(sha string) ([]byte, bool) {
+	content, ok := c.read(c.blobPath(sha))
+	if ok && !git.IsBlobSHA(sha, content) {
+		slog.Debug(fmt.Sprintf("cached blob %s is corrupted, removing it", sha))
+		_ = os.Remove(c.blobPath(sha))
+		ok = false
+	}
+	c.count(ok)
+	return content, ok
+}
+
+// HasBlob reports if a blob is cached without reading it
+func (c *Cache) HasBlob(sha string) bool {
+	path := c.blobPath(sha)
+	if path == "" {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && !c.expired(info)
+}
+
+// PutBlob stores the content of a blob, contents not matching the SHA are rejected
+func (c *Cache) PutBlob(sha string, content []byte) error {
+	path := c.blobPath(sha)
+	if path == "" {
+		return fmt.Errorf("invalid blob SHA %q", sha)
+	}
+	if !git.IsBlobSHA(sha, content) {
+		return fmt.Errorf("content does not match blob SHA %s", sha)
+	}
+	return c.write(path, content)
+}
+
+// Tree returns the cached tree of a commit
+func (c *Cache) Tree(commit string) ([]git.TreeNode, bool) {
+	content, ok := c.read(c.treePath(commit))
+	var tree []git.TreeNode
+	if ok {
+		if err := json.Unmarshal(content, &tree); err != nil {
+			slog.Debug(fmt.Sprintf("cached tree of %s is corrupted, removing it: %s", commit, err))
+			_ = os.Remove(c.treePath(commit))
+			ok = false
+		}
+	}
+	c.count(ok)
+	return tree, ok
+}
+
+// PutTree stores the tree of a commit
+func (c *Cache) PutTree(commit string, tree []git.TreeNode) error {
+	path := c.treePath(commit)
+	if path == "" {
+		return fmt.Errorf("invalid commit SHA %q", commit)
+	}
+	content, err := json.Marshal(tree)
+	if err != nil {
+		return err
+	}
+	return c.write(path, content)
+}
+
+// Stats returns the number of cache hits and misses
+func (c *Cache) Stats() (int64, int64) {
+	return c.hits.Load(), c.misses.Load()
+}
+
+type PruneStats struct {
+	Files   int
+	Size    int64
+	Removed int
+	Freed   int64
+}
+
+func (s PruneStats) String() string {
+	return fmt.Sprintf("removed %d files (%d bytes), %d files (%d bytes) left", s.Removed, s.Freed, s.Files, s.Size)
+}
+
+type entry struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// Prune removes the files older than the TTL, then the oldest files until the cache fits into the max size
+func (c *Cache) Prune() (PruneStats, error) {
+	var stats PruneStats
+	var entries []entry
+	for _, sub := range []string{blobsDir, treesDir} {
+		err := filepath.WalkDir(filepath.Join(c.dir, sub), func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			info, err := d.Info()
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if c.expired(info) {
+				return c.remove(entry{path: path, size: info.Size()}, &stats)
+			}
+			entries = append(entries, entry{path: path, size: info.Size(), modTime: info.ModTime()})
+			stats.Files++
+			stats.Size += info.Size()
+			return nil
+		})
+		if err != nil {
+			return stats, err
+		}
+	}
+	if c.options.MaxSize <= 0 || stats.Size <= c.options.MaxSize {
+		return stats, nil
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].modTime.Before(entries[j].modTime)
+	})
+	for _, e := range entries {
+		if stats.Size <= c.options.MaxSize {
+			break
+		}
+		if err := c.remove(e, &stats); err != nil {
+			return stats, err
+		}
+		stats.Files--
+		stats.Size -= e.size
+	}
+	return stats, nil
+}
+
+func (c *Cache) remove(e entry, stats *PruneStats) error {
+	if err := os.Remove(e.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	stats.Removed++
+	stats.Freed += e.size
+	return nil
+}
+
+func (c *Cache) read(path string) ([]byte, bool) {
+	if path == "" {
+		return nil, false
+	}
+	info, err := os.Stat(path)
+	if err != nil || c.expired(info) {
+		return nil, false
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	return content, true
+}
+
+// write replaces the file atomically, concurrent readers never see a partial file
+func (c *Cache) write(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (c *Cache) expired(info fs.FileInfo) bool {
+	return c.options.TTL > 0 && c.now().Sub(info.ModTime()) > c.options.TTL
+}
+
+func (c *Cache) count(hit bool) {
+	if hit {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+}
+
+func (c *Cache) blobPath(sha string) string {
+	return c.objectPath(blobsDir, sha, "")
+}
+
+func (c *Cache) treePath(commit string) string {
+	return c.objectPath(treesDir, commit, ".json")
+}
+
+// objectPath spreads the files over subdirectories by the first two characters of the SHA, like git does
+func (c *Cache) objectPath(sub, sha, ext string) string {
+	if !isHex(sha) {
+		return ""
+	}
+	return filepath.Join(c.dir, sub, sha[:2], sha[2:]+ext)
+}
+
+// isHex rejects keys which are no SHA, they could escape the cache directory
+func isHex(sha string) bool {
+	if len(sha) < 4 {
+		return false
+	}
+	for _, r := range sha {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,110 @@
+//go:build unit
+
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alican-uelger/deep-scan/internal/git"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheBlob(t *testing.T) {
+	c, err := New(t.TempDir(), Options{})
+	require.NoError(t, err)
+	content := []byte("password: ENC[AES256_GCM,data:abc]\n")
+	sha := git.BlobSHA(content)
+
+	_, ok := c.Blob(sha)
+	assert.False(t, ok)
+	assert.False(t, c.HasBlob(sha))
+
+	require.NoError(t, c.PutBlob(sha, content))
+	cached, ok := c.Blob(sha)
+	assert.True(t, ok)
+	assert.Equal(t, content, cached)
+	assert.True(t, c.HasBlob(sha))
+
+	hits, misses := c.Stats()
+	assert.Equal(t, int64(1), hits)
+	assert.Equal(t, int64(1), misses)
+}
+
+func TestCachePutBlobRejectsInvalidContent(t *testing.T) {
+	c, err := New(t.TempDir(), Options{})
+	require.NoError(t, err)
+
+	assert.EqualError(t, c.PutBlob(git.BlobSHA([]byte("a")), []byte("b")), "content does not match blob SHA "+git.BlobSHA([]byte("a")))
+	assert.EqualError(t, c.PutBlob("../../etc/passwd", []byte("b")), `invalid blob SHA "../../etc/passwd"`)
+}
+
+func TestCacheBlobCorrupted(t *testing.T) {
+	dir := t.TempDir()
+	c, err := New(dir, Options{})
+	require.NoError(t, err)
+	content := []byte("hello\n")
+	sha := git.BlobSHA(content)
+	require.NoError(t, c.PutBlob(sha, content))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, blobsDir, sha[:2], sha[2:]), []byte("changed"), 0o600))
+
+	_, ok := c.Blob(sha)
+	assert.False(t, ok)
+	assert.False(t, c.HasBlob(sha))
+}
+
+func TestCacheTree(t *testing.T) {
+	c, err := New(t.TempDir(), Options{})
+	require.NoError(t, err)
+	tree := []git.TreeNode{{Path: "secrets.yaml", Type: "blob", SHA: "ce013625030ba8dba906f756967f9e9ca394464a"}}
+
+	_, ok := c.Tree("abc123")
+	assert.False(t, ok)
+	require.NoError(t, c.PutTree("abc123", tree))
+	cached, ok := c.Tree("abc123")
+	assert.True(t, ok)
+	assert.Equal(t, tree, cached)
+}
+
+func TestCacheTTL(t *testing.T) {
+	c, err := New(t.TempDir(), Options{TTL: time.Hour})
+	require.NoError(t, err)
+	content := []byte("hello\n")
+	sha := git.BlobSHA(content)
+	require.NoError(t, c.PutBlob(sha, content))
+
+	c.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	_, ok := c.Blob(sha)
+	assert.False(t, ok)
+
+	stats, err := c.Prune()
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Removed)
+	assert.Equal(t, 0, stats.Files)
+}
+
+func TestCachePruneMaxSize(t *testing.T) {
+	dir := t.TempDir()
+	c, err := New(dir, Options{MaxSize: 10})
+	require.NoError(t, err)
+	now := time.Now()
+	var shas []string
+	for i, content := range []string{"first\n", "second\n", "third\n"} {
+		sha := git.BlobSHA([]byte(content))
+		require.NoError(t, c.PutBlob(sha, []byte(content)))
+		modTime := now.Add(time.Duration(i-3) * time.Minute)
+		require.NoError(t, os.Chtimes(filepath.Join(dir, blobsDir, sha[:2], sha[2:]), modTime, modTime))
+		shas = append(shas, sha)
+	}
+
+	stats, err := c.Prune()
+	require.NoError(t, err)
+	// the oldest files are removed until the cache fits
+	assert.Equal(t, PruneStats{Files: 1, Size: 6, Removed: 2, Freed: 13}, stats)
+	assert.False(t, c.HasBlob(shas[0]))
+	assert.False(t, c.HasBlob(shas[1]))
+	assert.True(t, c.HasBlob(shas[2]))
+}
@@ -0,0 +1,42 @@
+package git
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+/*
+ReadArchive calls fn for every regular file of the gzipped tarball of a repository.
+The archives of GitHub and GitLab put all files below a single root directory, the paths passed to fn are relative to it.
+*/
+func ReadArchive(archive io.Reader, fn func(path string, content io.Reader) error) error {
+	gz, err := gzip.NewReader(archive)
+	if err != nil {
+		return fmt.Errorf("could not read archive: %w", err)
+	}
+	defer gz.Close()
+	reader := tar.NewReader(gz)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("could not read archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		_, path, ok := strings.Cut(header.Name, "/")
+		if !ok || path == "" {
+			continue
+		}
+		if err := fn(path, reader); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,56 @@
+//go:build unit
+
+package git
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testArchive creates a gzipped tarball with the files below a root directory, like the archives of GitHub and GitLab
+func testArchive(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	writer := tar.NewWriter(gz)
+	require.NoError(t, writer.WriteHeader(&tar.Header{Typeflag: tar.TypeXGlobalHeader, Name: "pax_global_header", PAXRecords: map[string]string{"comment": "abc123"}}))
+	require.NoError(t, writer.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: "org-repo-abc123/", Mode: 0755}))
+	for name, content := range files {
+		require.NoError(t, writer.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: "org-repo-abc123/" + name, Mode: 0644, Size: int64(len(content))}))
+		_, err := writer.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func TestReadArchive(t *testing.T) {
+	archive := testArchive(t, map[string]string{
+		"README.md":         "# repo",
+		"deploy/values.yml": "token: secret",
+	})
+
+	files := map[string]string{}
+	err := ReadArchive(bytes.NewReader(archive), func(path string, content io.Reader) error {
+		data, err := io.ReadAll(content)
+		files[path] = string(data)
+		return err
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"README.md": "# repo", "deploy/values.yml": "token: secret"}, files)
+}
+
+func TestReadArchiveInvalid(t *testing.T) {
+	err := ReadArchive(strings.NewReader("not an archive"), func(string, io.Reader) error {
+		return nil
+	})
+	assert.ErrorContains(t, err, "could not read archive")
+}
@@ -0,0 +1,28 @@
+package git
+
+import (
+	"crypto/sha1" // nolint:gosec // git object IDs are SHA-1 hashes
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+)
+
+// BlobSHA returns the git object ID of a file content, the SHA-1 of "blob <size>\0<content>"
+func BlobSHA(content []byte) string {
+	return blobHash(sha1.New(), content)
+}
+
+// IsBlobSHA verifies that the SHA is the object ID of the content, SHA-256 repositories have 64 character IDs
+func IsBlobSHA(sha string, content []byte) bool {
+	if len(sha) == sha256.Size*2 {
+		return blobHash(sha256.New(), content) == sha
+	}
+	return BlobSHA(content) == sha
+}
+
+func blobHash(h hash.Hash, content []byte) string {
+	_, _ = fmt.Fprintf(h, "blob %d\x00", len(content))
+	_, _ = h.Write(content)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,18 @@
+//go:build unit
+
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlobSHA(t *testing.T) {
+	// git hash-object of a file containing "hello\n"
+	assert.Equal(t, "ce013625030ba8dba906f756967f9e9ca394464a", BlobSHA([]byte("hello\n")))
+	assert.True(t, IsBlobSHA("ce013625030ba8dba906f756967f9e9ca394464a", []byte("hello\n")))
+	assert.False(t, IsBlobSHA("ce013625030ba8dba906f756967f9e9ca394464a", []byte("hello")))
+	// git hash-object --object-format=sha256
+	assert.True(t, IsBlobSHA("2cf8d83d9ee29543b34a87727421fdecb7e3f3a183d337639025de576db9ebb4", []byte("hello\n")))
+}
@@ -0,0 +1,65 @@
+package git
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	// candidateSearchSize is the number of search results ranked as candidates of a project which does not exist
+	candidateSearchSize = 20
+	// maxCandidates is the number of candidates listed in the error
+	maxCandidates = 5
+)
+
+// projectNotFound returns the error of a project which does not exist, listing the candidates closest to the name
+func projectNotFound(name string, candidates []string) error {
+	candidates = closestCandidates(name, candidates)
+	if len(candidates) == 0 {
+		return fmt.Errorf("project not found: %s", name)
+	}
+	return fmt.Errorf("project not found: %s (close candidates: %s)", name, strings.Join(candidates, ", "))
+}
+
+// closestCandidates sorts the candidates by their edit distance to the name and returns the closest ones
+func closestCandidates(name string, candidates []string) []string {
+	name = strings.ToLower(name)
+	distances := map[string]int{}
+	var unique []string
+	for _, candidate := range candidates {
+		if _, ok := distances[candidate]; ok || candidate == "" {
+			continue
+		}
+		distances[candidate] = editDistance(name, strings.ToLower(candidate))
+		unique = append(unique, candidate)
+	}
+	slices.SortStableFunc(unique, func(a, b string) int {
+		return distances[a] - distances[b]
+	})
+	if len(unique) > maxCandidates {
+		unique = unique[:maxCandidates]
+	}
+	return unique
+}
+
+// editDistance is the Levenshtein distance of the two strings
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
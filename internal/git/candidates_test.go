@@ -0,0 +1,24 @@
+//go:build unit
+
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("team/api", "team/api"))
+	assert.Equal(t, 1, editDistance("team/api", "team/apis"))
+	assert.Equal(t, 3, editDistance("kitten", "sitting"))
+	assert.Equal(t, 4, editDistance("", "repo"))
+}
+
+func TestClosestCandidates(t *testing.T) {
+	candidates := []string{"other-team/api-legacy", "Team/API", "team/apis", "team/apis", "team/web", "team/api-gateway", "team/api-v2"}
+	result := closestCandidates("team/api", candidates)
+	// duplicates are listed once, ties keep the order of the search results
+	assert.Equal(t, []string{"Team/API", "team/apis", "team/web", "team/api-v2", "team/api-gateway"}, result)
+	assert.Empty(t, closestCandidates("team/api", nil))
+}
@@ -0,0 +1,157 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Inclusion selects how projects with a property (e.g. archived) are handled
+type Inclusion string
+
+const (
+	Include Inclusion = "include"
+	Exclude Inclusion = "exclude"
+	Only    Inclusion = "only"
+)
+
+// the names of the filters, used to report the skipped projects
+const (
+	FilterName        = "name"
+	FilterExcludeName = "exclude-name"
+	FilterArchived    = "archived"
+	FilterForks       = "forks"
+	FilterVisibility  = "visibility"
+	FilterTopic       = "topic"
+	FilterPushedAfter = "pushed-after"
+	FilterLanguage    = "language"
+)
+
+// ProjectFilter selects the projects of a group scan. The zero value keeps every project.
+type ProjectFilter struct {
+	Archived Inclusion
+	Forks    Inclusion
+	// Visibility keeps projects with one of the visibilities (public, private, internal)
+	Visibility []string
+	// Topics keeps projects with at least one of the topics
+	Topics []string
+	// Languages keeps projects with one of the primary languages
+	Languages   []string
+	PushedAfter time.Time
+	// Name and ExcludeName are matched against the full path of the project (owner/repo or group/subgroup/project)
+	Name        *regexp.Regexp
+	ExcludeName *regexp.Regexp
+}
+
+func ParseInclusion(value string) (Inclusion, error) {
+	switch i := Inclusion(strings.ToLower(value)); i {
+	case "":
+		return Include, nil
+	case Include, Exclude, Only:
+		return i, nil
+	}
+	return "", fmt.Errorf("unsupported value %s (supported: %s, %s, %s)", value, Include, Exclude, Only)
+}
+
+// FilterStats counts the skipped projects by the filter which skipped them
+type FilterStats map[string]int
+
+func (s FilterStats) String() string {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s=%d", name, s[name]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+/*
+Apply returns the projects passing all filters. Each skipped project is counted by the first filter skipping it.
+The primary language is only looked up (which can be an API call per project) for projects passing all other filters.
+*/
+func (f ProjectFilter) Apply(ctx context.Context, projects []Project, language func(context.Context, Project) (string, error)) ([]Project, FilterStats, error) {
+	var kept []Project
+	stats := FilterStats{}
+	for _, project := range projects {
+		skippedBy := f.skippedBy(project)
+		if skippedBy == "" && len(f.Languages) > 0 {
+			if project.Language == "" && language != nil {
+				l, err := language(ctx, project)
+				if err != nil {
+					return nil, stats, fmt.Errorf("could not get language of %s: %w", project.PathWithNamespace, err)
+				}
+				project.Language = l
+			}
+			if !containsFold(f.Languages, project.Language) {
+				skippedBy = FilterLanguage
+			}
+		}
+		if skippedBy != "" {
+			slog.Debug(fmt.Sprintf("skipping project %s by filter %s", project.PathWithNamespace, skippedBy))
+			stats[skippedBy]++
+			continue
+		}
+		kept = append(kept, project)
+	}
+	return kept, stats, nil
+}
+
+func (f ProjectFilter) skippedBy(project Project) string {
+	if f.Name != nil && !f.Name.MatchString(project.PathWithNamespace) {
+		return FilterName
+	}
+	if f.ExcludeName != nil && f.ExcludeName.MatchString(project.PathWithNamespace) {
+		return FilterExcludeName
+	}
+	if !f.Archived.keeps(project.Archived) {
+		return FilterArchived
+	}
+	if !f.Forks.keeps(project.Fork) {
+		return FilterForks
+	}
+	if len(f.Visibility) > 0 && !containsFold(f.Visibility, project.Visibility) {
+		return FilterVisibility
+	}
+	if len(f.Topics) > 0 && !slices.ContainsFunc(project.Topics, func(topic string) bool { return containsFold(f.Topics, topic) }) {
+		return FilterTopic
+	}
+	if !f.PushedAfter.IsZero() && project.PushedAt.Before(f.PushedAfter) {
+		return FilterPushedAfter
+	}
+	return ""
+}
+
+func (i Inclusion) keeps(value bool) bool {
+	switch i {
+	case Exclude:
+		return !value
+	case Only:
+		return value
+	}
+	return true
+}
+
+func containsFold(values []string, value string) bool {
+	return slices.ContainsFunc(values, func(v string) bool { return strings.EqualFold(v, value) })
+}
+
+// logFilterStats reports how many projects of a group were skipped by each filter
+func logFilterStats(group string, total int, stats FilterStats) {
+	skipped := 0
+	for _, count := range stats {
+		skipped += count
+	}
+	if skipped == 0 {
+		slog.Info(fmt.Sprintf("scanning %d projects of %s", total, group))
+		return
+	}
+	slog.Info(fmt.Sprintf("scanning %d of %d projects of %s, skipped: %s", total-skipped, total, group, stats))
+}
@@ -0,0 +1,147 @@
+//go:build unit
+
+package git
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseInclusion(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected Inclusion
+		wantErr  bool
+	}{
+		{value: "", expected: Include},
+		{value: "include", expected: Include},
+		{value: "Exclude", expected: Exclude},
+		{value: "only", expected: Only},
+		{value: "never", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			inclusion, err := ParseInclusion(tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, inclusion)
+		})
+	}
+}
+
+func TestProjectFilterApply(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	projects := []Project{
+		{PathWithNamespace: "org/api", Visibility: "private", Topics: []string{"backend"}, Language: "Go", PushedAt: now},
+		{PathWithNamespace: "org/web", Visibility: "public", Topics: []string{"Frontend"}, Language: "TypeScript", PushedAt: now},
+		{PathWithNamespace: "org/legacy", Archived: true, Visibility: "private", PushedAt: now.AddDate(-2, 0, 0)},
+		{PathWithNamespace: "org/api-fork", Fork: true, Visibility: "public", Language: "Go", PushedAt: now},
+		{PathWithNamespace: "org/sandbox-test", Visibility: "internal", PushedAt: now},
+	}
+
+	tests := []struct {
+		name          string
+		filter        ProjectFilter
+		expected      []string
+		expectedStats FilterStats
+	}{
+		{
+			name:          "Zero value keeps every project",
+			filter:        ProjectFilter{},
+			expected:      []string{"org/api", "org/web", "org/legacy", "org/api-fork", "org/sandbox-test"},
+			expectedStats: FilterStats{},
+		},
+		{
+			name:          "Exclude archived and forks",
+			filter:        ProjectFilter{Archived: Exclude, Forks: Exclude},
+			expected:      []string{"org/api", "org/web", "org/sandbox-test"},
+			expectedStats: FilterStats{FilterArchived: 1, FilterForks: 1},
+		},
+		{
+			name:          "Only archived",
+			filter:        ProjectFilter{Archived: Only},
+			expected:      []string{"org/legacy"},
+			expectedStats: FilterStats{FilterArchived: 4},
+		},
+		{
+			name:          "Visibility",
+			filter:        ProjectFilter{Visibility: []string{"Public"}},
+			expected:      []string{"org/web", "org/api-fork"},
+			expectedStats: FilterStats{FilterVisibility: 3},
+		},
+		{
+			name:          "Topics",
+			filter:        ProjectFilter{Topics: []string{"frontend", "backend"}},
+			expected:      []string{"org/api", "org/web"},
+			expectedStats: FilterStats{FilterTopic: 3},
+		},
+		{
+			name:          "Language",
+			filter:        ProjectFilter{Languages: []string{"go"}},
+			expected:      []string{"org/api", "org/api-fork"},
+			expectedStats: FilterStats{FilterLanguage: 3},
+		},
+		{
+			name:          "Pushed after",
+			filter:        ProjectFilter{PushedAfter: now.AddDate(0, -1, 0)},
+			expected:      []string{"org/api", "org/web", "org/api-fork", "org/sandbox-test"},
+			expectedStats: FilterStats{FilterPushedAfter: 1},
+		},
+		{
+			name:          "Name regex",
+			filter:        ProjectFilter{Name: regexp.MustCompile(`^org/api`), ExcludeName: regexp.MustCompile(`fork$`)},
+			expected:      []string{"org/api"},
+			expectedStats: FilterStats{FilterName: 3, FilterExcludeName: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, stats, err := tt.filter.Apply(context.Background(), projects, nil)
+			assert.NoError(t, err)
+			var names []string
+			for _, project := range result {
+				names = append(names, project.PathWithNamespace)
+			}
+			assert.Equal(t, tt.expected, names)
+			assert.Equal(t, tt.expectedStats, stats)
+		})
+	}
+}
+
+func TestProjectFilterApplyLanguageLookup(t *testing.T) {
+	projects := []Project{
+		{PathWithNamespace: "org/api"},
+		{PathWithNamespace: "org/legacy", Archived: true},
+	}
+	var looked []string
+	language := func(_ context.Context, project Project) (string, error) {
+		looked = append(looked, project.PathWithNamespace)
+		return "Go", nil
+	}
+
+	result, stats, err := ProjectFilter{Archived: Exclude, Languages: []string{"Go"}}.Apply(context.Background(), projects, language)
+	assert.NoError(t, err)
+	assert.Len(t, result, 1)
+	assert.Equal(t, "Go", result[0].Language)
+	assert.Equal(t, FilterStats{FilterArchived: 1}, stats)
+	assert.Equal(t, []string{"org/api"}, looked)
+
+	failing := func(context.Context, Project) (string, error) {
+		return "", errors.New("API error")
+	}
+	_, _, err = ProjectFilter{Languages: []string{"Go"}}.Apply(context.Background(), projects, failing)
+	assert.Error(t, err)
+}
+
+func TestFilterStatsString(t *testing.T) {
+	stats := FilterStats{FilterTopic: 2, FilterArchived: 3}
+	assert.Equal(t, "archived=3, topic=2", stats.String())
+}
@@ -5,27 +5,46 @@ import (
 	"fmt"
 	"github.com/google/go-github/v50/github"
 	"golang.org/x/oauth2"
+	"io"
 	"log/slog"
+	"net/http"
+	"path"
 	"path/filepath"
+	"strconv"
+	"strings"
 )
 
 type GitHubAPI interface {
-	SearchProjects(name string, opts *github.SearchOptions) (*github.RepositoriesSearchResult, *github.Response, error)
-	GetRawFile(owner, repo, path string, opts *github.RepositoryContentGetOptions) ([]byte, *github.Response, error)
-	ListRepositoryTree(owner, repo string) ([]*github.TreeEntry, *github.Response, error)
-	ListGroupProjects(org string, opts *github.RepositoryListByOrgOptions) ([]*github.Repository, *github.Response, error)
+	SearchProjects(ctx context.Context, name string, opts *github.SearchOptions) (*github.RepositoriesSearchResult, *github.Response, error)
+	GetRawFile(ctx context.Context, owner, repo, path string, opts *github.RepositoryContentGetOptions) ([]byte, *github.Response, error)
+	ListRepositoryTree(ctx context.Context, owner, repo, ref string) ([]*github.TreeEntry, *github.Response, error)
+	ListGroupProjects(ctx context.Context, org string, opts *github.RepositoryListByOrgOptions) ([]*github.Repository, *github.Response, error)
+	GetRepository(ctx context.Context, owner, repo string) (*github.Repository, *github.Response, error)
+	GetRepositoryByID(ctx context.Context, id int64) (*github.Repository, *github.Response, error)
+	ListBranches(ctx context.Context, owner, repo string, opts *github.BranchListOptions) ([]*github.Branch, *github.Response, error)
+	GetCommitSHA(ctx context.Context, owner, repo, ref string) (string, *github.Response, error)
+	GetArchive(ctx context.Context, owner, repo, ref string) (io.ReadCloser, *github.Response, error)
+	CompareCommits(ctx context.Context, owner, repo, base, head string) (*github.CommitsComparison, *github.Response, error)
 }
 
+// the compare API lists at most 300 changed files
+const githubCompareFileLimit = 300
+
 type GitHub struct {
 	client GitHubAPI
 }
 
-func NewGitHub(token, hostname string) (*GitHub, error) {
+/*
+NewGitHub creates a client sending its requests with the transport (http.DefaultTransport when nil).
+The rate limit check of the client is disabled, the transport handles the rate limits.
+*/
+func NewGitHub(token, hostname string, transport http.RoundTripper) (*GitHub, error) {
 	if hostname == "" {
 		hostname = "github.com"
 		slog.Debug(fmt.Sprintf("using default GitHub hostname: %s", hostname))
 	}
-	ctx := context.Background()
+	// the oauth2 client sends the authorized requests with the client of the context
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: withoutRateLimitCheck{transport}})
 	ts := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: token},
 	)
@@ -36,29 +55,73 @@ func NewGitHub(token, hostname string) (*GitHub, error) {
 	}, nil
 }
 
-func (g *GitHub) GetProjectByName(name string) (Project, error) {
-	project := Project{}
+/*
+withoutRateLimitCheck removes the reset of a used up rate limit from the responses. The GitHub client rejects the
+requests on its own until the reset it has seen last, those requests would never reach the transport, which waits
+for the reset and retries them.
+*/
+type withoutRateLimitCheck struct {
+	base http.RoundTripper
+}
+
+func (t withoutRateLimitCheck) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if resp != nil && resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		resp.Header.Del("X-RateLimit-Reset")
+	}
+	return resp, err
+}
+
+/*
+GetProjectByName resolves a repository exactly by its full name (owner/repo) or numeric ID.
+When no repository matches, the error lists the closest repositories found by the search API.
+*/
+func (g *GitHub) GetProjectByName(ctx context.Context, name string) (Project, error) {
+	slog.Debug(fmt.Sprintf("getting project: %s", name))
+	var repo *github.Repository
+	var resp *github.Response
+	var err error
+	if id, parseErr := strconv.ParseInt(name, 10, 64); parseErr == nil {
+		repo, resp, err = g.client.GetRepositoryByID(ctx, id)
+	} else if owner, repoName, ok := strings.Cut(name, "/"); ok && owner != "" && repoName != "" && !strings.Contains(repoName, "/") {
+		repo, resp, err = g.client.GetRepository(ctx, owner, repoName)
+	} else {
+		return Project{}, g.notFound(ctx, name)
+	}
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return Project{}, g.notFound(ctx, name)
+	}
+	if err != nil {
+		return Project{}, err
+	}
+	return newGitHubProject(repo), nil
+}
+
+// notFound returns the error of a repository which does not exist, with the closest candidates of the search API
+func (g *GitHub) notFound(ctx context.Context, name string) error {
 	sOpts := &github.SearchOptions{
 		ListOptions: github.ListOptions{
 			Page:    1,
-			PerPage: 1,
+			PerPage: candidateSearchSize,
 		},
 	}
-	slog.Debug(fmt.Sprintf("searching project: %s", name))
-	r, _, err := g.client.SearchProjects(name, sOpts)
-	if err != nil {
-		return project, err
+	r, _, err := g.client.SearchProjects(ctx, path.Base(name), sOpts)
+	if err != nil || r == nil {
+		slog.Debug(fmt.Sprintf("could not search candidates of %s: %v", name, err))
+		return projectNotFound(name, nil)
 	}
-
-	if len(r.Repositories) < 1 {
-		return project, fmt.Errorf("project not found: %s", name)
+	var candidates []string
+	for _, repo := range r.Repositories {
+		candidates = append(candidates, repo.GetFullName())
 	}
-	project.ID = int(r.Repositories[0].GetID())
-	project.PathWithNamespace = r.Repositories[0].GetFullName()
-	return project, nil
+	return projectNotFound(name, candidates)
 }
 
-func (g *GitHub) ListGroupProjects(group string) ([]Project, error) {
+func (g *GitHub) ListGroupProjects(ctx context.Context, group string, filter ProjectFilter) ([]Project, error) {
 	var gitProjects []Project
 	opts := &github.RepositoryListByOrgOptions{
 		ListOptions: github.ListOptions{
@@ -66,31 +129,65 @@ func (g *GitHub) ListGroupProjects(group string) ([]Project, error) {
 			Page:    1,
 		},
 	}
-	slog.Debug(fmt.Sprintf("fetching projects for group: %s", group))
-	repos, _, err := g.client.ListGroupProjects(group, opts)
+	for {
+		slog.Debug(fmt.Sprintf("fetching projects for group: %s, page: %d", group, opts.Page))
+		repos, resp, err := g.client.ListGroupProjects(ctx, group, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, repo := range repos {
+			gitProjects = append(gitProjects, newGitHubProject(repo))
+		}
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	// the primary language is part of the listed repositories
+	projects, stats, err := filter.Apply(ctx, gitProjects, nil)
 	if err != nil {
 		return nil, err
 	}
-	for _, repo := range repos {
-		gitProjects = append(gitProjects, Project{
-			Name:              repo.GetName(),
-			ID:                int(repo.GetID()),
-			PathWithNamespace: repo.GetFullName(),
-		})
+	logFilterStats(group, len(gitProjects), stats)
+	return projects, nil
+}
+
+func newGitHubProject(repo *github.Repository) Project {
+	visibility := repo.GetVisibility()
+	if visibility == "" && repo.Private != nil {
+		visibility = "public"
+		if repo.GetPrivate() {
+			visibility = "private"
+		}
+	}
+	return Project{
+		Name:              repo.GetName(),
+		ID:                int(repo.GetID()),
+		PathWithNamespace: repo.GetFullName(),
+		WebURL:            repo.GetHTMLURL(),
+		DefaultBranch:     repo.GetDefaultBranch(),
+		Archived:          repo.GetArchived(),
+		Fork:              repo.GetFork(),
+		Visibility:        visibility,
+		Topics:            repo.Topics,
+		Language:          repo.GetLanguage(),
+		PushedAt:          repo.GetPushedAt().Time,
 	}
-	return gitProjects, nil
 }
 
-func (g *GitHub) GetRawFile(project Project, path string) ([]byte, error) {
-	slog.Debug(fmt.Sprintf("fetching raw file: %s", filepath.Join(project.PathWithNamespace, path)))
-	content, _, err := g.client.GetRawFile(project.Owner(), project.Name, path, &github.RepositoryContentGetOptions{})
+func (g *GitHub) GetRawFile(ctx context.Context, project Project, ref, path string) ([]byte, error) {
+	slog.Debug(fmt.Sprintf("fetching raw file: %s@%s", filepath.Join(project.PathWithNamespace, path), ref))
+	content, _, err := g.client.GetRawFile(ctx, project.Owner(), project.Name, path, &github.RepositoryContentGetOptions{Ref: ref})
 	return content, err
 }
 
-func (g *GitHub) ListRepositoryTree(project Project) ([]TreeNode, error) {
+func (g *GitHub) ListRepositoryTree(ctx context.Context, project Project, ref string) ([]TreeNode, error) {
 	var repoTreeNodes []TreeNode
-	slog.Debug(fmt.Sprintf("fetching tree for project: %v", project.Name))
-	tree, _, err := g.client.ListRepositoryTree(project.Owner(), project.Name)
+	slog.Debug(fmt.Sprintf("fetching tree for project: %v@%s", project.Name, ref))
+	if ref == "" {
+		ref = "HEAD"
+	}
+	tree, _, err := g.client.ListRepositoryTree(ctx, project.Owner(), project.Name, ref)
 	if err != nil {
 		return repoTreeNodes, err
 	}
@@ -99,7 +196,89 @@ func (g *GitHub) ListRepositoryTree(project Project) ([]TreeNode, error) {
 			IsTree: treeNode.GetType() == "tree",
 			Path:   treeNode.GetPath(),
 			Type:   treeNode.GetType(),
+			SHA:    treeNode.GetSHA(),
 		})
 	}
 	return repoTreeNodes, nil
 }
+
+// ResolveRef returns the commit SHA of a branch, tag or SHA, an empty ref is the default branch
+func (g *GitHub) ResolveRef(ctx context.Context, project Project, ref string) (Ref, error) {
+	if ref == "" {
+		ref = "HEAD"
+	}
+	slog.Debug(fmt.Sprintf("resolving ref %s of project: %s", ref, project.PathWithNamespace))
+	sha, _, err := g.client.GetCommitSHA(ctx, project.Owner(), project.Name, ref)
+	if err != nil {
+		return Ref{}, fmt.Errorf("could not resolve ref %s of %s: %w", ref, project.PathWithNamespace, err)
+	}
+	return Ref{Name: ref, SHA: sha}, nil
+}
+
+/*
+CompareCommits lists the files changed from the base to the head commit. The comparison fails when the
+base is no ancestor of the head (e.g. after a force push) or when the list of files is truncated.
+*/
+func (g *GitHub) CompareCommits(ctx context.Context, project Project, base, head string) ([]FileChange, error) {
+	slog.Debug(fmt.Sprintf("comparing %s...%s of project: %s", base, head, project.PathWithNamespace))
+	comparison, _, err := g.client.CompareCommits(ctx, project.Owner(), project.Name, base, head)
+	if err != nil {
+		return nil, fmt.Errorf("could not compare %s...%s of %s: %w", base, head, project.PathWithNamespace, err)
+	}
+	if status := comparison.GetStatus(); status != "ahead" && status != "identical" {
+		return nil, fmt.Errorf("could not compare %s...%s of %s: the head is %s", base, head, project.PathWithNamespace, status)
+	}
+	if len(comparison.Files) >= githubCompareFileLimit {
+		return nil, fmt.Errorf("could not compare %s...%s of %s: more than %d files changed", base, head, project.PathWithNamespace, githubCompareFileLimit)
+	}
+	var changes []FileChange
+	for _, file := range comparison.Files {
+		change := FileChange{Path: file.GetFilename(), SHA: file.GetSHA()}
+		switch file.GetStatus() {
+		case "added", "copied":
+			change.Status = ChangeAdded
+		case "removed":
+			change.Status = ChangeRemoved
+			change.SHA = ""
+		case "renamed":
+			change.Status = ChangeRenamed
+			change.PreviousPath = file.GetPreviousFilename()
+		default:
+			change.Status = ChangeModified
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+func (g *GitHub) ListBranches(ctx context.Context, project Project) ([]Ref, error) {
+	var refs []Ref
+	opts := &github.BranchListOptions{
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+			Page:    1,
+		},
+	}
+	for {
+		slog.Debug(fmt.Sprintf("fetching branches for project: %s, page: %d", project.PathWithNamespace, opts.Page))
+		branches, resp, err := g.client.ListBranches(ctx, project.Owner(), project.Name, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, branch := range branches {
+			refs = append(refs, Ref{Name: branch.GetName(), SHA: branch.GetCommit().GetSHA()})
+		}
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return refs, nil
+}
+
+// GetArchive downloads the gzipped tarball of the repository at the ref
+func (g *GitHub) GetArchive(ctx context.Context, project Project, ref string) (io.ReadCloser, error) {
+	slog.Debug(fmt.Sprintf("fetching archive of project: %s@%s", project.PathWithNamespace, ref))
+	archive, _, err := g.client.GetArchive(ctx, project.Owner(), project.Name, ref)
+	return archive, err
+}
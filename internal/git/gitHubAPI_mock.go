@@ -3,6 +3,9 @@
 package git
 
 import (
+	context "context"
+	io "io"
+
 	github "github.com/google/go-github/v50/github"
 	mock "github.com/stretchr/testify/mock"
 )
@@ -20,9 +23,218 @@ func (_m *GitHubAPIMock) EXPECT() *GitHubAPIMock_Expecter {
 	return &GitHubAPIMock_Expecter{mock: &_m.Mock}
 }
 
-// GetRawFile provides a mock function with given fields: owner, repo, path, opts
-func (_m *GitHubAPIMock) GetRawFile(owner string, repo string, path string, opts *github.RepositoryContentGetOptions) ([]byte, *github.Response, error) {
-	ret := _m.Called(owner, repo, path, opts)
+// CompareCommits provides a mock function with given fields: ctx, owner, repo, base, head
+func (_m *GitHubAPIMock) CompareCommits(ctx context.Context, owner string, repo string, base string, head string) (*github.CommitsComparison, *github.Response, error) {
+	ret := _m.Called(ctx, owner, repo, base, head)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CompareCommits")
+	}
+
+	var r0 *github.CommitsComparison
+	var r1 *github.Response
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string) (*github.CommitsComparison, *github.Response, error)); ok {
+		return rf(ctx, owner, repo, base, head)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string) *github.CommitsComparison); ok {
+		r0 = rf(ctx, owner, repo, base, head)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*github.CommitsComparison)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, string) *github.Response); ok {
+		r1 = rf(ctx, owner, repo, base, head)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*github.Response)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, string, string, string) error); ok {
+		r2 = rf(ctx, owner, repo, base, head)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GitHubAPIMock_CompareCommits_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CompareCommits'
+type GitHubAPIMock_CompareCommits_Call struct {
+	*mock.Call
+}
+
+// CompareCommits is a helper method to define mock.On call
+//   - ctx context.Context
+//   - owner string
+//   - repo string
+//   - base string
+//   - head string
+func (_e *GitHubAPIMock_Expecter) CompareCommits(ctx interface{}, owner interface{}, repo interface{}, base interface{}, head interface{}) *GitHubAPIMock_CompareCommits_Call {
+	return &GitHubAPIMock_CompareCommits_Call{Call: _e.mock.On("CompareCommits", ctx, owner, repo, base, head)}
+}
+
+func (_c *GitHubAPIMock_CompareCommits_Call) Run(run func(ctx context.Context, owner string, repo string, base string, head string)) *GitHubAPIMock_CompareCommits_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(string), args[4].(string))
+	})
+	return _c
+}
+
+func (_c *GitHubAPIMock_CompareCommits_Call) Return(_a0 *github.CommitsComparison, _a1 *github.Response, _a2 error) *GitHubAPIMock_CompareCommits_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *GitHubAPIMock_CompareCommits_Call) RunAndReturn(run func(context.Context, string, string, string, string) (*github.CommitsComparison, *github.Response, error)) *GitHubAPIMock_CompareCommits_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetArchive provides a mock function with given fields: ctx, owner, repo, ref
+func (_m *GitHubAPIMock) GetArchive(ctx context.Context, owner string, repo string, ref string) (io.ReadCloser, *github.Response, error) {
+	ret := _m.Called(ctx, owner, repo, ref)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetArchive")
+	}
+
+	var r0 io.ReadCloser
+	var r1 *github.Response
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (io.ReadCloser, *github.Response, error)); ok {
+		return rf(ctx, owner, repo, ref)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) io.ReadCloser); ok {
+		r0 = rf(ctx, owner, repo, ref)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(io.ReadCloser)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) *github.Response); ok {
+		r1 = rf(ctx, owner, repo, ref)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*github.Response)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, string, string) error); ok {
+		r2 = rf(ctx, owner, repo, ref)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GitHubAPIMock_GetArchive_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetArchive'
+type GitHubAPIMock_GetArchive_Call struct {
+	*mock.Call
+}
+
+// GetArchive is a helper method to define mock.On call
+//   - ctx context.Context
+//   - owner string
+//   - repo string
+//   - ref string
+func (_e *GitHubAPIMock_Expecter) GetArchive(ctx interface{}, owner interface{}, repo interface{}, ref interface{}) *GitHubAPIMock_GetArchive_Call {
+	return &GitHubAPIMock_GetArchive_Call{Call: _e.mock.On("GetArchive", ctx, owner, repo, ref)}
+}
+
+func (_c *GitHubAPIMock_GetArchive_Call) Run(run func(ctx context.Context, owner string, repo string, ref string)) *GitHubAPIMock_GetArchive_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(string))
+	})
+	return _c
+}
+
+func (_c *GitHubAPIMock_GetArchive_Call) Return(_a0 io.ReadCloser, _a1 *github.Response, _a2 error) *GitHubAPIMock_GetArchive_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *GitHubAPIMock_GetArchive_Call) RunAndReturn(run func(context.Context, string, string, string) (io.ReadCloser, *github.Response, error)) *GitHubAPIMock_GetArchive_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetCommitSHA provides a mock function with given fields: ctx, owner, repo, ref
+func (_m *GitHubAPIMock) GetCommitSHA(ctx context.Context, owner string, repo string, ref string) (string, *github.Response, error) {
+	ret := _m.Called(ctx, owner, repo, ref)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCommitSHA")
+	}
+
+	var r0 string
+	var r1 *github.Response
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (string, *github.Response, error)); ok {
+		return rf(ctx, owner, repo, ref)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) string); ok {
+		r0 = rf(ctx, owner, repo, ref)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) *github.Response); ok {
+		r1 = rf(ctx, owner, repo, ref)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*github.Response)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, string, string) error); ok {
+		r2 = rf(ctx, owner, repo, ref)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GitHubAPIMock_GetCommitSHA_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetCommitSHA'
+type GitHubAPIMock_GetCommitSHA_Call struct {
+	*mock.Call
+}
+
+// GetCommitSHA is a helper method to define mock.On call
+//   - ctx context.Context
+//   - owner string
+//   - repo string
+//   - ref string
+func (_e *GitHubAPIMock_Expecter) GetCommitSHA(ctx interface{}, owner interface{}, repo interface{}, ref interface{}) *GitHubAPIMock_GetCommitSHA_Call {
+	return &GitHubAPIMock_GetCommitSHA_Call{Call: _e.mock.On("GetCommitSHA", ctx, owner, repo, ref)}
+}
+
+func (_c *GitHubAPIMock_GetCommitSHA_Call) Run(run func(ctx context.Context, owner string, repo string, ref string)) *GitHubAPIMock_GetCommitSHA_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(string))
+	})
+	return _c
+}
+
+func (_c *GitHubAPIMock_GetCommitSHA_Call) Return(_a0 string, _a1 *github.Response, _a2 error) *GitHubAPIMock_GetCommitSHA_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *GitHubAPIMock_GetCommitSHA_Call) RunAndReturn(run func(context.Context, string, string, string) (string, *github.Response, error)) *GitHubAPIMock_GetCommitSHA_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetRawFile provides a mock function with given fields: ctx, owner, repo, path, opts
+func (_m *GitHubAPIMock) GetRawFile(ctx context.Context, owner string, repo string, path string, opts *github.RepositoryContentGetOptions) ([]byte, *github.Response, error) {
+	ret := _m.Called(ctx, owner, repo, path, opts)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetRawFile")
@@ -31,27 +243,27 @@ func (_m *GitHubAPIMock) GetRawFile(owner string, repo string, path string, opts
 	var r0 []byte
 	var r1 *github.Response
 	var r2 error
-	if rf, ok := ret.Get(0).(func(string, string, string, *github.RepositoryContentGetOptions) ([]byte, *github.Response, error)); ok {
-		return rf(owner, repo, path, opts)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, *github.RepositoryContentGetOptions) ([]byte, *github.Response, error)); ok {
+		return rf(ctx, owner, repo, path, opts)
 	}
-	if rf, ok := ret.Get(0).(func(string, string, string, *github.RepositoryContentGetOptions) []byte); ok {
-		r0 = rf(owner, repo, path, opts)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, *github.RepositoryContentGetOptions) []byte); ok {
+		r0 = rf(ctx, owner, repo, path, opts)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]byte)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string, string, *github.RepositoryContentGetOptions) *github.Response); ok {
-		r1 = rf(owner, repo, path, opts)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, *github.RepositoryContentGetOptions) *github.Response); ok {
+		r1 = rf(ctx, owner, repo, path, opts)
 	} else {
 		if ret.Get(1) != nil {
 			r1 = ret.Get(1).(*github.Response)
 		}
 	}
 
-	if rf, ok := ret.Get(2).(func(string, string, string, *github.RepositoryContentGetOptions) error); ok {
-		r2 = rf(owner, repo, path, opts)
+	if rf, ok := ret.Get(2).(func(context.Context, string, string, string, *github.RepositoryContentGetOptions) error); ok {
+		r2 = rf(ctx, owner, repo, path, opts)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -65,17 +277,18 @@ type GitHubAPIMock_GetRawFile_Call struct {
 }
 
 // GetRawFile is a helper method to define mock.On call
+//   - ctx context.Context
 //   - owner string
 //   - repo string
 //   - path string
 //   - opts *github.RepositoryContentGetOptions
-func (_e *GitHubAPIMock_Expecter) GetRawFile(owner interface{}, repo interface{}, path interface{}, opts interface{}) *GitHubAPIMock_GetRawFile_Call {
-	return &GitHubAPIMock_GetRawFile_Call{Call: _e.mock.On("GetRawFile", owner, repo, path, opts)}
+func (_e *GitHubAPIMock_Expecter) GetRawFile(ctx interface{}, owner interface{}, repo interface{}, path interface{}, opts interface{}) *GitHubAPIMock_GetRawFile_Call {
+	return &GitHubAPIMock_GetRawFile_Call{Call: _e.mock.On("GetRawFile", ctx, owner, repo, path, opts)}
 }
 
-func (_c *GitHubAPIMock_GetRawFile_Call) Run(run func(owner string, repo string, path string, opts *github.RepositoryContentGetOptions)) *GitHubAPIMock_GetRawFile_Call {
+func (_c *GitHubAPIMock_GetRawFile_Call) Run(run func(ctx context.Context, owner string, repo string, path string, opts *github.RepositoryContentGetOptions)) *GitHubAPIMock_GetRawFile_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string), args[1].(string), args[2].(string), args[3].(*github.RepositoryContentGetOptions))
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(string), args[4].(*github.RepositoryContentGetOptions))
 	})
 	return _c
 }
@@ -85,14 +298,221 @@ func (_c *GitHubAPIMock_GetRawFile_Call) Return(_a0 []byte, _a1 *github.Response
 	return _c
 }
 
-func (_c *GitHubAPIMock_GetRawFile_Call) RunAndReturn(run func(string, string, string, *github.RepositoryContentGetOptions) ([]byte, *github.Response, error)) *GitHubAPIMock_GetRawFile_Call {
+func (_c *GitHubAPIMock_GetRawFile_Call) RunAndReturn(run func(context.Context, string, string, string, *github.RepositoryContentGetOptions) ([]byte, *github.Response, error)) *GitHubAPIMock_GetRawFile_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetRepository provides a mock function with given fields: ctx, owner, repo
+func (_m *GitHubAPIMock) GetRepository(ctx context.Context, owner string, repo string) (*github.Repository, *github.Response, error) {
+	ret := _m.Called(ctx, owner, repo)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRepository")
+	}
+
+	var r0 *github.Repository
+	var r1 *github.Response
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*github.Repository, *github.Response, error)); ok {
+		return rf(ctx, owner, repo)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *github.Repository); ok {
+		r0 = rf(ctx, owner, repo)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*github.Repository)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) *github.Response); ok {
+		r1 = rf(ctx, owner, repo)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*github.Response)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, string) error); ok {
+		r2 = rf(ctx, owner, repo)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GitHubAPIMock_GetRepository_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetRepository'
+type GitHubAPIMock_GetRepository_Call struct {
+	*mock.Call
+}
+
+// GetRepository is a helper method to define mock.On call
+//   - ctx context.Context
+//   - owner string
+//   - repo string
+func (_e *GitHubAPIMock_Expecter) GetRepository(ctx interface{}, owner interface{}, repo interface{}) *GitHubAPIMock_GetRepository_Call {
+	return &GitHubAPIMock_GetRepository_Call{Call: _e.mock.On("GetRepository", ctx, owner, repo)}
+}
+
+func (_c *GitHubAPIMock_GetRepository_Call) Run(run func(ctx context.Context, owner string, repo string)) *GitHubAPIMock_GetRepository_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *GitHubAPIMock_GetRepository_Call) Return(_a0 *github.Repository, _a1 *github.Response, _a2 error) *GitHubAPIMock_GetRepository_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *GitHubAPIMock_GetRepository_Call) RunAndReturn(run func(context.Context, string, string) (*github.Repository, *github.Response, error)) *GitHubAPIMock_GetRepository_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetRepositoryByID provides a mock function with given fields: ctx, id
+func (_m *GitHubAPIMock) GetRepositoryByID(ctx context.Context, id int64) (*github.Repository, *github.Response, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRepositoryByID")
+	}
+
+	var r0 *github.Repository
+	var r1 *github.Response
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*github.Repository, *github.Response, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *github.Repository); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*github.Repository)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) *github.Response); ok {
+		r1 = rf(ctx, id)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*github.Response)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, int64) error); ok {
+		r2 = rf(ctx, id)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GitHubAPIMock_GetRepositoryByID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetRepositoryByID'
+type GitHubAPIMock_GetRepositoryByID_Call struct {
+	*mock.Call
+}
+
+// GetRepositoryByID is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id int64
+func (_e *GitHubAPIMock_Expecter) GetRepositoryByID(ctx interface{}, id interface{}) *GitHubAPIMock_GetRepositoryByID_Call {
+	return &GitHubAPIMock_GetRepositoryByID_Call{Call: _e.mock.On("GetRepositoryByID", ctx, id)}
+}
+
+func (_c *GitHubAPIMock_GetRepositoryByID_Call) Run(run func(ctx context.Context, id int64)) *GitHubAPIMock_GetRepositoryByID_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int64))
+	})
+	return _c
+}
+
+func (_c *GitHubAPIMock_GetRepositoryByID_Call) Return(_a0 *github.Repository, _a1 *github.Response, _a2 error) *GitHubAPIMock_GetRepositoryByID_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *GitHubAPIMock_GetRepositoryByID_Call) RunAndReturn(run func(context.Context, int64) (*github.Repository, *github.Response, error)) *GitHubAPIMock_GetRepositoryByID_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListBranches provides a mock function with given fields: ctx, owner, repo, opts
+func (_m *GitHubAPIMock) ListBranches(ctx context.Context, owner string, repo string, opts *github.BranchListOptions) ([]*github.Branch, *github.Response, error) {
+	ret := _m.Called(ctx, owner, repo, opts)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListBranches")
+	}
+
+	var r0 []*github.Branch
+	var r1 *github.Response
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *github.BranchListOptions) ([]*github.Branch, *github.Response, error)); ok {
+		return rf(ctx, owner, repo, opts)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *github.BranchListOptions) []*github.Branch); ok {
+		r0 = rf(ctx, owner, repo, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*github.Branch)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, *github.BranchListOptions) *github.Response); ok {
+		r1 = rf(ctx, owner, repo, opts)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*github.Response)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, string, *github.BranchListOptions) error); ok {
+		r2 = rf(ctx, owner, repo, opts)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GitHubAPIMock_ListBranches_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListBranches'
+type GitHubAPIMock_ListBranches_Call struct {
+	*mock.Call
+}
+
+// ListBranches is a helper method to define mock.On call
+//   - ctx context.Context
+//   - owner string
+//   - repo string
+//   - opts *github.BranchListOptions
+func (_e *GitHubAPIMock_Expecter) ListBranches(ctx interface{}, owner interface{}, repo interface{}, opts interface{}) *GitHubAPIMock_ListBranches_Call {
+	return &GitHubAPIMock_ListBranches_Call{Call: _e.mock.On("ListBranches", ctx, owner, repo, opts)}
+}
+
+func (_c *GitHubAPIMock_ListBranches_Call) Run(run func(ctx context.Context, owner string, repo string, opts *github.BranchListOptions)) *GitHubAPIMock_ListBranches_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(*github.BranchListOptions))
+	})
+	return _c
+}
+
+func (_c *GitHubAPIMock_ListBranches_Call) Return(_a0 []*github.Branch, _a1 *github.Response, _a2 error) *GitHubAPIMock_ListBranches_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *GitHubAPIMock_ListBranches_Call) RunAndReturn(run func(context.Context, string, string, *github.BranchListOptions) ([]*github.Branch, *github.Response, error)) *GitHubAPIMock_ListBranches_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// ListGroupProjects provides a mock function with given fields: org, opts
-func (_m *GitHubAPIMock) ListGroupProjects(org string, opts *github.RepositoryListByOrgOptions) ([]*github.Repository, *github.Response, error) {
-	ret := _m.Called(org, opts)
+// ListGroupProjects provides a mock function with given fields: ctx, org, opts
+func (_m *GitHubAPIMock) ListGroupProjects(ctx context.Context, org string, opts *github.RepositoryListByOrgOptions) ([]*github.Repository, *github.Response, error) {
+	ret := _m.Called(ctx, org, opts)
 
 	if len(ret) == 0 {
 		panic("no return value specified for ListGroupProjects")
@@ -101,27 +521,27 @@ func (_m *GitHubAPIMock) ListGroupProjects(org string, opts *github.RepositoryLi
 	var r0 []*github.Repository
 	var r1 *github.Response
 	var r2 error
-	if rf, ok := ret.Get(0).(func(string, *github.RepositoryListByOrgOptions) ([]*github.Repository, *github.Response, error)); ok {
-		return rf(org, opts)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *github.RepositoryListByOrgOptions) ([]*github.Repository, *github.Response, error)); ok {
+		return rf(ctx, org, opts)
 	}
-	if rf, ok := ret.Get(0).(func(string, *github.RepositoryListByOrgOptions) []*github.Repository); ok {
-		r0 = rf(org, opts)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *github.RepositoryListByOrgOptions) []*github.Repository); ok {
+		r0 = rf(ctx, org, opts)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*github.Repository)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string, *github.RepositoryListByOrgOptions) *github.Response); ok {
-		r1 = rf(org, opts)
+	if rf, ok := ret.Get(1).(func(context.Context, string, *github.RepositoryListByOrgOptions) *github.Response); ok {
+		r1 = rf(ctx, org, opts)
 	} else {
 		if ret.Get(1) != nil {
 			r1 = ret.Get(1).(*github.Response)
 		}
 	}
 
-	if rf, ok := ret.Get(2).(func(string, *github.RepositoryListByOrgOptions) error); ok {
-		r2 = rf(org, opts)
+	if rf, ok := ret.Get(2).(func(context.Context, string, *github.RepositoryListByOrgOptions) error); ok {
+		r2 = rf(ctx, org, opts)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -135,15 +555,16 @@ type GitHubAPIMock_ListGroupProjects_Call struct {
 }
 
 // ListGroupProjects is a helper method to define mock.On call
+//   - ctx context.Context
 //   - org string
 //   - opts *github.RepositoryListByOrgOptions
-func (_e *GitHubAPIMock_Expecter) ListGroupProjects(org interface{}, opts interface{}) *GitHubAPIMock_ListGroupProjects_Call {
-	return &GitHubAPIMock_ListGroupProjects_Call{Call: _e.mock.On("ListGroupProjects", org, opts)}
+func (_e *GitHubAPIMock_Expecter) ListGroupProjects(ctx interface{}, org interface{}, opts interface{}) *GitHubAPIMock_ListGroupProjects_Call {
+	return &GitHubAPIMock_ListGroupProjects_Call{Call: _e.mock.On("ListGroupProjects", ctx, org, opts)}
 }
 
-func (_c *GitHubAPIMock_ListGroupProjects_Call) Run(run func(org string, opts *github.RepositoryListByOrgOptions)) *GitHubAPIMock_ListGroupProjects_Call {
+func (_c *GitHubAPIMock_ListGroupProjects_Call) Run(run func(ctx context.Context, org string, opts *github.RepositoryListByOrgOptions)) *GitHubAPIMock_ListGroupProjects_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string), args[1].(*github.RepositoryListByOrgOptions))
+		run(args[0].(context.Context), args[1].(string), args[2].(*github.RepositoryListByOrgOptions))
 	})
 	return _c
 }
@@ -153,14 +574,14 @@ func (_c *GitHubAPIMock_ListGroupProjects_Call) Return(_a0 []*github.Repository,
 	return _c
 }
 
-func (_c *GitHubAPIMock_ListGroupProjects_Call) RunAndReturn(run func(string, *github.RepositoryListByOrgOptions) ([]*github.Repository, *github.Response, error)) *GitHubAPIMock_ListGroupProjects_Call {
+func (_c *GitHubAPIMock_ListGroupProjects_Call) RunAndReturn(run func(context.Context, string, *github.RepositoryListByOrgOptions) ([]*github.Repository, *github.Response, error)) *GitHubAPIMock_ListGroupProjects_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// ListRepositoryTree provides a mock function with given fields: owner, repo
-func (_m *GitHubAPIMock) ListRepositoryTree(owner string, repo string) ([]*github.TreeEntry, *github.Response, error) {
-	ret := _m.Called(owner, repo)
+// ListRepositoryTree provides a mock function with given fields: ctx, owner, repo, ref
+func (_m *GitHubAPIMock) ListRepositoryTree(ctx context.Context, owner string, repo string, ref string) ([]*github.TreeEntry, *github.Response, error) {
+	ret := _m.Called(ctx, owner, repo, ref)
 
 	if len(ret) == 0 {
 		panic("no return value specified for ListRepositoryTree")
@@ -169,27 +590,27 @@ func (_m *GitHubAPIMock) ListRepositoryTree(owner string, repo string) ([]*githu
 	var r0 []*github.TreeEntry
 	var r1 *github.Response
 	var r2 error
-	if rf, ok := ret.Get(0).(func(string, string) ([]*github.TreeEntry, *github.Response, error)); ok {
-		return rf(owner, repo)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) ([]*github.TreeEntry, *github.Response, error)); ok {
+		return rf(ctx, owner, repo, ref)
 	}
-	if rf, ok := ret.Get(0).(func(string, string) []*github.TreeEntry); ok {
-		r0 = rf(owner, repo)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) []*github.TreeEntry); ok {
+		r0 = rf(ctx, owner, repo, ref)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*github.TreeEntry)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string) *github.Response); ok {
-		r1 = rf(owner, repo)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) *github.Response); ok {
+		r1 = rf(ctx, owner, repo, ref)
 	} else {
 		if ret.Get(1) != nil {
 			r1 = ret.Get(1).(*github.Response)
 		}
 	}
 
-	if rf, ok := ret.Get(2).(func(string, string) error); ok {
-		r2 = rf(owner, repo)
+	if rf, ok := ret.Get(2).(func(context.Context, string, string, string) error); ok {
+		r2 = rf(ctx, owner, repo, ref)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -203,15 +624,17 @@ type GitHubAPIMock_ListRepositoryTree_Call struct {
 }
 
 // ListRepositoryTree is a helper method to define mock.On call
+//   - ctx context.Context
 //   - owner string
 //   - repo string
-func (_e *GitHubAPIMock_Expecter) ListRepositoryTree(owner interface{}, repo interface{}) *GitHubAPIMock_ListRepositoryTree_Call {
-	return &GitHubAPIMock_ListRepositoryTree_Call{Call: _e.mock.On("ListRepositoryTree", owner, repo)}
+//   - ref string
+func (_e *GitHubAPIMock_Expecter) ListRepositoryTree(ctx interface{}, owner interface{}, repo interface{}, ref interface{}) *GitHubAPIMock_ListRepositoryTree_Call {
+	return &GitHubAPIMock_ListRepositoryTree_Call{Call: _e.mock.On("ListRepositoryTree", ctx, owner, repo, ref)}
 }
 
-func (_c *GitHubAPIMock_ListRepositoryTree_Call) Run(run func(owner string, repo string)) *GitHubAPIMock_ListRepositoryTree_Call {
+func (_c *GitHubAPIMock_ListRepositoryTree_Call) Run(run func(ctx context.Context, owner string, repo string, ref string)) *GitHubAPIMock_ListRepositoryTree_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string), args[1].(string))
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(string))
 	})
 	return _c
 }
@@ -221,14 +644,14 @@ func (_c *GitHubAPIMock_ListRepositoryTree_Call) Return(_a0 []*github.TreeEntry,
 	return _c
 }
 
-func (_c *GitHubAPIMock_ListRepositoryTree_Call) RunAndReturn(run func(string, string) ([]*github.TreeEntry, *github.Response, error)) *GitHubAPIMock_ListRepositoryTree_Call {
+func (_c *GitHubAPIMock_ListRepositoryTree_Call) RunAndReturn(run func(context.Context, string, string, string) ([]*github.TreeEntry, *github.Response, error)) *GitHubAPIMock_ListRepositoryTree_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// SearchProjects provides a mock function with given fields: name, opts
-func (_m *GitHubAPIMock) SearchProjects(name string, opts *github.SearchOptions) (*github.RepositoriesSearchResult, *github.Response, error) {
-	ret := _m.Called(name, opts)
+// SearchProjects provides a mock function with given fields: ctx, name, opts
+func (_m *GitHubAPIMock) SearchProjects(ctx context.Context, name string, opts *github.SearchOptions) (*github.RepositoriesSearchResult, *github.Response, error) {
+	ret := _m.Called(ctx, name, opts)
 
 	if len(ret) == 0 {
 		panic("no return value specified for SearchProjects")
@@ -237,27 +660,27 @@ func (_m *GitHubAPIMock) SearchProjects(name string, opts *github.SearchOptions)
 	var r0 *github.RepositoriesSearchResult
 	var r1 *github.Response
 	var r2 error
-	if rf, ok := ret.Get(0).(func(string, *github.SearchOptions) (*github.RepositoriesSearchResult, *github.Response, error)); ok {
-		return rf(name, opts)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *github.SearchOptions) (*github.RepositoriesSearchResult, *github.Response, error)); ok {
+		return rf(ctx, name, opts)
 	}
-	if rf, ok := ret.Get(0).(func(string, *github.SearchOptions) *github.RepositoriesSearchResult); ok {
-		r0 = rf(name, opts)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *github.SearchOptions) *github.RepositoriesSearchResult); ok {
+		r0 = rf(ctx, name, opts)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*github.RepositoriesSearchResult)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string, *github.SearchOptions) *github.Response); ok {
-		r1 = rf(name, opts)
+	if rf, ok := ret.Get(1).(func(context.Context, string, *github.SearchOptions) *github.Response); ok {
+		r1 = rf(ctx, name, opts)
 	} else {
 		if ret.Get(1) != nil {
 			r1 = ret.Get(1).(*github.Response)
 		}
 	}
 
-	if rf, ok := ret.Get(2).(func(string, *github.SearchOptions) error); ok {
-		r2 = rf(name, opts)
+	if rf, ok := ret.Get(2).(func(context.Context, string, *github.SearchOptions) error); ok {
+		r2 = rf(ctx, name, opts)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -271,15 +694,16 @@ type GitHubAPIMock_SearchProjects_Call struct {
 }
 
 // SearchProjects is a helper method to define mock.On call
+//   - ctx context.Context
 //   - name string
 //   - opts *github.SearchOptions
-func (_e *GitHubAPIMock_Expecter) SearchProjects(name interface{}, opts interface{}) *GitHubAPIMock_SearchProjects_Call {
-	return &GitHubAPIMock_SearchProjects_Call{Call: _e.mock.On("SearchProjects", name, opts)}
+func (_e *GitHubAPIMock_Expecter) SearchProjects(ctx interface{}, name interface{}, opts interface{}) *GitHubAPIMock_SearchProjects_Call {
+	return &GitHubAPIMock_SearchProjects_Call{Call: _e.mock.On("SearchProjects", ctx, name, opts)}
 }
 
-func (_c *GitHubAPIMock_SearchProjects_Call) Run(run func(name string, opts *github.SearchOptions)) *GitHubAPIMock_SearchProjects_Call {
+func (_c *GitHubAPIMock_SearchProjects_Call) Run(run func(ctx context.Context, name string, opts *github.SearchOptions)) *GitHubAPIMock_SearchProjects_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string), args[1].(*github.SearchOptions))
+		run(args[0].(context.Context), args[1].(string), args[2].(*github.SearchOptions))
 	})
 	return _c
 }
@@ -289,7 +713,7 @@ func (_c *GitHubAPIMock_SearchProjects_Call) Return(_a0 *github.RepositoriesSear
 	return _c
 }
 
-func (_c *GitHubAPIMock_SearchProjects_Call) RunAndReturn(run func(string, *github.SearchOptions) (*github.RepositoriesSearchResult, *github.Response, error)) *GitHubAPIMock_SearchProjects_Call {
+func (_c *GitHubAPIMock_SearchProjects_Call) RunAndReturn(run func(context.Context, string, *github.SearchOptions) (*github.RepositoriesSearchResult, *github.Response, error)) *GitHubAPIMock_SearchProjects_Call {
 	_c.Call.Return(run)
 	return _c
 }
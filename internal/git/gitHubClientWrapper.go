@@ -2,34 +2,78 @@ package git
 
 import (
 	"context"
+	"fmt"
 	"github.com/google/go-github/v50/github"
+	"io"
+	"net/http"
 )
 
 type githubClientWrapper struct {
 	client *github.Client
 }
 
-func (w *githubClientWrapper) ListGroupProjects(org string, opts *github.RepositoryListByOrgOptions) ([]*github.Repository, *github.Response, error) {
-	return w.client.Repositories.ListByOrg(context.Background(), org, opts)
+func (w *githubClientWrapper) ListGroupProjects(ctx context.Context, org string, opts *github.RepositoryListByOrgOptions) ([]*github.Repository, *github.Response, error) {
+	return w.client.Repositories.ListByOrg(ctx, org, opts)
 }
 
-func (w *githubClientWrapper) SearchProjects(name string, opts *github.SearchOptions) (*github.RepositoriesSearchResult, *github.Response, error) {
-	return w.client.Search.Repositories(context.Background(), name, opts)
+func (w *githubClientWrapper) SearchProjects(ctx context.Context, name string, opts *github.SearchOptions) (*github.RepositoriesSearchResult, *github.Response, error) {
+	return w.client.Search.Repositories(ctx, name, opts)
 }
 
-func (w *githubClientWrapper) GetRawFile(owner, repo, path string, opts *github.RepositoryContentGetOptions) ([]byte, *github.Response, error) {
-	fileContent, _, _, err := w.client.Repositories.GetContents(context.Background(), owner, repo, path, opts)
+func (w *githubClientWrapper) GetRepository(ctx context.Context, owner, repo string) (*github.Repository, *github.Response, error) {
+	return w.client.Repositories.Get(ctx, owner, repo)
+}
+
+func (w *githubClientWrapper) GetRepositoryByID(ctx context.Context, id int64) (*github.Repository, *github.Response, error) {
+	return w.client.Repositories.GetByID(ctx, id)
+}
+
+func (w *githubClientWrapper) GetRawFile(ctx context.Context, owner, repo, path string, opts *github.RepositoryContentGetOptions) ([]byte, *github.Response, error) {
+	fileContent, _, resp, err := w.client.Repositories.GetContents(ctx, owner, repo, path, opts)
 	if err != nil {
-		return nil, nil, err
+		return nil, resp, err
 	}
 	content, err := fileContent.GetContent()
-	return []byte(content), nil, err
+	return []byte(content), resp, err
 }
 
-func (w *githubClientWrapper) ListRepositoryTree(owner, repo string) ([]*github.TreeEntry, *github.Response, error) {
-	tree, _, err := w.client.Git.GetTree(context.Background(), owner, repo, "HEAD", true)
+func (w *githubClientWrapper) ListRepositoryTree(ctx context.Context, owner, repo, ref string) ([]*github.TreeEntry, *github.Response, error) {
+	tree, resp, err := w.client.Git.GetTree(ctx, owner, repo, ref, true)
 	if tree == nil {
-		return nil, nil, err
+		return nil, resp, err
 	}
-	return tree.Entries, nil, err
+	return tree.Entries, resp, err
+}
+
+func (w *githubClientWrapper) ListBranches(ctx context.Context, owner, repo string, opts *github.BranchListOptions) ([]*github.Branch, *github.Response, error) {
+	return w.client.Repositories.ListBranches(ctx, owner, repo, opts)
+}
+
+func (w *githubClientWrapper) GetCommitSHA(ctx context.Context, owner, repo, ref string) (string, *github.Response, error) {
+	return w.client.Repositories.GetCommitSHA1(ctx, owner, repo, ref, "")
+}
+
+// GetArchive follows the archive link of the tarball endpoint and returns the body of the download, the request timeout does not apply to the download
+func (w *githubClientWrapper) GetArchive(ctx context.Context, owner, repo, ref string) (io.ReadCloser, *github.Response, error) {
+	link, resp, err := w.client.Repositories.GetArchiveLink(ctx, owner, repo, github.Tarball, &github.RepositoryContentGetOptions{Ref: ref}, true)
+	if err != nil {
+		return nil, resp, err
+	}
+	req, err := http.NewRequestWithContext(withoutRequestTimeout(ctx), http.MethodGet, link.String(), nil)
+	if err != nil {
+		return nil, resp, err
+	}
+	download, err := w.client.Client().Do(req)
+	if err != nil {
+		return nil, resp, err
+	}
+	if download.StatusCode != http.StatusOK {
+		download.Body.Close()
+		return nil, resp, fmt.Errorf("archive download of %s/%s failed: %s", owner, repo, download.Status)
+	}
+	return download.Body, resp, nil
+}
+
+func (w *githubClientWrapper) CompareCommits(ctx context.Context, owner, repo, base, head string) (*github.CommitsComparison, *github.Response, error) {
+	return w.client.Repositories.CompareCommits(ctx, owner, repo, base, head, nil)
 }
@@ -3,50 +3,80 @@
 package git
 
 import (
+	"context"
 	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/google/go-github/v50/github"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGitHubGetProjectByName(t *testing.T) {
+	notFound := &github.Response{Response: &http.Response{StatusCode: http.StatusNotFound}}
 	tests := []struct {
 		name            string
 		projectName     string
-		mockResponse    *github.RepositoriesSearchResult
-		mockError       error
+		setup           func(clientMock *GitHubAPIMock)
 		expectedError   error
 		expectedProject Project
 	}{
 		{
-			name:        "Valid project",
-			projectName: "test",
-			mockResponse: &github.RepositoriesSearchResult{
-				Repositories: []*github.Repository{
-					{ID: github.Int64(1), FullName: github.String("test/project")},
-				},
+			name:        "Full name",
+			projectName: "team/api",
+			setup: func(clientMock *GitHubAPIMock) {
+				clientMock.On("GetRepository", mock.Anything, "team", "api").
+					Return(&github.Repository{ID: github.Int64(1), FullName: github.String("team/api")}, nil, nil)
 			},
-			mockError:     nil,
-			expectedError: nil,
-			expectedProject: Project{
-				ID:                1,
-				PathWithNamespace: "test/project",
+			expectedProject: Project{ID: 1, PathWithNamespace: "team/api"},
+		},
+		{
+			name:        "Numeric ID",
+			projectName: "42",
+			setup: func(clientMock *GitHubAPIMock) {
+				clientMock.On("GetRepositoryByID", mock.Anything, int64(42)).
+					Return(&github.Repository{ID: github.Int64(42), FullName: github.String("team/api")}, nil, nil)
+			},
+			expectedProject: Project{ID: 42, PathWithNamespace: "team/api"},
+		},
+		{
+			name:        "Not found lists close candidates",
+			projectName: "team/api",
+			setup: func(clientMock *GitHubAPIMock) {
+				clientMock.On("GetRepository", mock.Anything, "team", "api").
+					Return(nil, notFound, errors.New("404 Not Found"))
+				clientMock.On("SearchProjects", mock.Anything, "api", mock.Anything).
+					Return(&github.RepositoriesSearchResult{Repositories: []*github.Repository{
+						{FullName: github.String("other-team/api-legacy")},
+						{FullName: github.String("team/apis")},
+					}}, nil, nil)
 			},
+			expectedError:   errors.New("project not found: team/api (close candidates: team/apis, other-team/api-legacy)"),
+			expectedProject: Project{},
 		},
 		{
-			name:            "No projects found",
-			projectName:     "not-found-test",
-			mockResponse:    &github.RepositoriesSearchResult{Repositories: []*github.Repository{}},
-			mockError:       nil,
-			expectedError:   errors.New("project not found: not-found-test"),
+			name:        "Name without owner",
+			projectName: "api",
+			setup: func(clientMock *GitHubAPIMock) {
+				clientMock.On("SearchProjects", mock.Anything, "api", mock.Anything).
+					Return(&github.RepositoriesSearchResult{Repositories: []*github.Repository{}}, nil, nil)
+			},
+			expectedError:   errors.New("project not found: api"),
 			expectedProject: Project{},
 		},
 		{
-			name:            "Error from API",
-			projectName:     "test",
-			mockResponse:    nil,
-			mockError:       errors.New("API error"),
+			name:        "Error from API",
+			projectName: "team/api",
+			setup: func(clientMock *GitHubAPIMock) {
+				clientMock.On("GetRepository", mock.Anything, "team", "api").
+					Return(nil, nil, errors.New("API error"))
+			},
 			expectedError:   errors.New("API error"),
 			expectedProject: Project{},
 		},
@@ -55,18 +85,13 @@ func TestGitHubGetProjectByName(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			clientMock := NewGitHubAPIMock(t)
-			clientMock.On("SearchProjects", tt.projectName, &github.SearchOptions{
-				ListOptions: github.ListOptions{
-					Page:    1,
-					PerPage: 1,
-				},
-			}).Return(tt.mockResponse, nil, tt.mockError)
+			tt.setup(clientMock)
 
 			g := GitHub{
 				client: clientMock,
 			}
 
-			project, err := g.GetProjectByName(tt.projectName)
+			project, err := g.GetProjectByName(context.Background(), tt.projectName)
 			assert.Equal(t, tt.expectedError, err)
 			assert.Equal(t, tt.expectedProject, project)
 		})
@@ -112,13 +137,13 @@ func TestGitHubGetRawFile(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			clientMock := NewGitHubAPIMock(t)
-			clientMock.On("GetRawFile", "test", "project", tt.path, &github.RepositoryContentGetOptions{}).Return(tt.mockResponse, nil, tt.mockError)
+			clientMock.On("GetRawFile", mock.Anything, "test", "project", tt.path, &github.RepositoryContentGetOptions{Ref: "abc123"}).Return(tt.mockResponse, nil, tt.mockError)
 
 			g := GitHub{
 				client: clientMock,
 			}
 
-			result, err := g.GetRawFile(tt.project, tt.path)
+			result, err := g.GetRawFile(context.Background(), tt.project, "abc123", tt.path)
 			assert.Equal(t, tt.expectedError, err)
 			assert.Equal(t, tt.expectedResult, result)
 		})
@@ -142,14 +167,14 @@ func TestGitHubListRepositoryTree(t *testing.T) {
 				PathWithNamespace: "org/p-1",
 			},
 			mockResponse: []*github.TreeEntry{
-				{Path: github.String("file1"), Type: github.String("blob")},
-				{Path: github.String("dir1"), Type: github.String("tree")},
+				{SHA: github.String("a1b2c3"), Path: github.String("file1"), Type: github.String("blob")},
+				{SHA: github.String("d4e5f6"), Path: github.String("dir1"), Type: github.String("tree")},
 			},
 			mockError:     nil,
 			expectedError: nil,
 			expectedResult: []TreeNode{
-				{Path: "file1", Type: "blob", IsTree: false},
-				{Path: "dir1", Type: "tree", IsTree: true},
+				{Path: "file1", Type: "blob", IsTree: false, SHA: "a1b2c3"},
+				{Path: "dir1", Type: "tree", IsTree: true, SHA: "d4e5f6"},
 			},
 		},
 		{
@@ -169,13 +194,13 @@ func TestGitHubListRepositoryTree(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			clientMock := NewGitHubAPIMock(t)
-			clientMock.On("ListRepositoryTree", tt.mockProject.Owner(), tt.mockProject.Name).Return(tt.mockResponse, nil, tt.mockError)
+			clientMock.On("ListRepositoryTree", mock.Anything, tt.mockProject.Owner(), tt.mockProject.Name, "HEAD").Return(tt.mockResponse, nil, tt.mockError)
 
 			g := GitHub{
 				client: clientMock,
 			}
 
-			result, err := g.ListRepositoryTree(tt.mockProject)
+			result, err := g.ListRepositoryTree(context.Background(), tt.mockProject, "")
 			assert.Equal(t, tt.expectedError, err)
 			assert.Equal(t, tt.expectedResult, result)
 		})
@@ -218,7 +243,7 @@ func TestGitHubListGroupProjects(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			clientMock := NewGitHubAPIMock(t)
-			clientMock.On("ListGroupProjects", tt.group, &github.RepositoryListByOrgOptions{
+			clientMock.On("ListGroupProjects", mock.Anything, tt.group, &github.RepositoryListByOrgOptions{
 				ListOptions: github.ListOptions{
 					PerPage: 100,
 					Page:    1,
@@ -229,9 +254,146 @@ func TestGitHubListGroupProjects(t *testing.T) {
 				client: clientMock,
 			}
 
-			result, err := g.ListGroupProjects(tt.group)
+			result, err := g.ListGroupProjects(context.Background(), tt.group, ProjectFilter{})
 			assert.Equal(t, tt.expectedError, err)
 			assert.Equal(t, tt.expectedResult, result)
 		})
 	}
 }
+
+func TestGitHubListGroupProjectsPagination(t *testing.T) {
+	clientMock := NewGitHubAPIMock(t)
+	clientMock.On("ListGroupProjects", mock.Anything, "test-group", mock.MatchedBy(func(opts *github.RepositoryListByOrgOptions) bool {
+		return opts.Page == 1
+	})).Return([]*github.Repository{
+		{ID: github.Int64(1), FullName: github.String("test-group/project1")},
+		{ID: github.Int64(2), FullName: github.String("test-group/old"), Archived: github.Bool(true)},
+	}, &github.Response{NextPage: 2}, nil).Once()
+	clientMock.On("ListGroupProjects", mock.Anything, "test-group", mock.MatchedBy(func(opts *github.RepositoryListByOrgOptions) bool {
+		return opts.Page == 2
+	})).Return([]*github.Repository{
+		{ID: github.Int64(3), FullName: github.String("test-group/project3"), Language: github.String("Go")},
+	}, &github.Response{NextPage: 0}, nil).Once()
+
+	g := GitHub{
+		client: clientMock,
+	}
+
+	result, err := g.ListGroupProjects(context.Background(), "test-group", ProjectFilter{Archived: Exclude})
+	assert.NoError(t, err)
+	var names []string
+	for _, project := range result {
+		names = append(names, project.PathWithNamespace)
+	}
+	assert.Equal(t, []string{"test-group/project1", "test-group/project3"}, names)
+}
+
+func TestGitHubRateLimitHandledByTransport(t *testing.T) {
+	reset := time.Now().Add(time.Second).Unix()
+	repository := func(headers ...string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			for i := 0; i+1 < len(headers); i += 2 {
+				w.Header().Set(headers[i], headers[i+1])
+			}
+			_, _ = io.WriteString(w, `{"id": 1, "full_name": "owner/repo"}`)
+		}
+	}
+	server, requests := testServer(t,
+		repository("X-RateLimit-Limit", "60", "X-RateLimit-Remaining", "0", "X-RateLimit-Reset", strconv.FormatInt(reset, 10)),
+		repository(),
+	)
+	transport := NewRateLimitTransport(nil, DefaultTransportOptions)
+	client := github.NewClient(&http.Client{Transport: withoutRateLimitCheck{transport}})
+	baseURL, err := url.Parse(server.URL + "/")
+	require.NoError(t, err)
+	client.BaseURL = baseURL
+	wrapper := &githubClientWrapper{client: client}
+
+	// the client does not reject the second call on its own, the transport waits for the reset
+	for range 2 {
+		repo, _, err := wrapper.GetRepository(context.Background(), "owner", "repo")
+		require.NoError(t, err)
+		assert.Equal(t, "owner/repo", repo.GetFullName())
+	}
+	assert.Equal(t, int32(2), requests.Load())
+	assert.False(t, time.Now().Before(time.Unix(reset, 0)))
+}
+
+func TestGitHubResolveRef(t *testing.T) {
+	project := Project{Name: "api", PathWithNamespace: "team/api"}
+	clientMock := NewGitHubAPIMock(t)
+	clientMock.On("GetCommitSHA", mock.Anything, "team", "api", "release/1.0").Return("abc123", nil, nil)
+	clientMock.On("GetCommitSHA", mock.Anything, "team", "api", "HEAD").Return("def456", nil, nil)
+	clientMock.On("GetCommitSHA", mock.Anything, "team", "api", "missing").Return("", nil, errors.New("404 Not Found"))
+
+	g := GitHub{
+		client: clientMock,
+	}
+
+	ref, err := g.ResolveRef(context.Background(), project, "release/1.0")
+	assert.NoError(t, err)
+	assert.Equal(t, Ref{Name: "release/1.0", SHA: "abc123"}, ref)
+
+	ref, err = g.ResolveRef(context.Background(), project, "")
+	assert.NoError(t, err)
+	assert.Equal(t, Ref{Name: "HEAD", SHA: "def456"}, ref)
+
+	_, err = g.ResolveRef(context.Background(), project, "missing")
+	assert.EqualError(t, err, "could not resolve ref missing of team/api: 404 Not Found")
+}
+
+func TestGitHubListBranches(t *testing.T) {
+	project := Project{Name: "api", PathWithNamespace: "team/api"}
+	clientMock := NewGitHubAPIMock(t)
+	clientMock.On("ListBranches", mock.Anything, "team", "api", mock.MatchedBy(func(opts *github.BranchListOptions) bool {
+		return opts.Page == 1
+	})).Return([]*github.Branch{
+		{Name: github.String("main"), Commit: &github.RepositoryCommit{SHA: github.String("abc123")}},
+	}, &github.Response{NextPage: 2}, nil).Once()
+	clientMock.On("ListBranches", mock.Anything, "team", "api", mock.MatchedBy(func(opts *github.BranchListOptions) bool {
+		return opts.Page == 2
+	})).Return([]*github.Branch{
+		{Name: github.String("release/1.0"), Commit: &github.RepositoryCommit{SHA: github.String("def456")}},
+	}, &github.Response{NextPage: 0}, nil).Once()
+
+	g := GitHub{
+		client: clientMock,
+	}
+
+	refs, err := g.ListBranches(context.Background(), project)
+	assert.NoError(t, err)
+	assert.Equal(t, []Ref{{Name: "main", SHA: "abc123"}, {Name: "release/1.0", SHA: "def456"}}, refs)
+}
+
+func TestGitHubCompareCommits(t *testing.T) {
+	project := Project{Name: "api", PathWithNamespace: "team/api"}
+	clientMock := NewGitHubAPIMock(t)
+	clientMock.On("CompareCommits", mock.Anything, "team", "api", "abc123", "def456").Return(&github.CommitsComparison{
+		Status: github.String("ahead"),
+		Files: []*github.CommitFile{
+			{Filename: github.String("added.yaml"), Status: github.String("added"), SHA: github.String("a1")},
+			{Filename: github.String("changed.yaml"), Status: github.String("modified"), SHA: github.String("b2")},
+			{Filename: github.String("new.yaml"), PreviousFilename: github.String("old.yaml"), Status: github.String("renamed"), SHA: github.String("c3")},
+			{Filename: github.String("removed.yaml"), Status: github.String("removed"), SHA: github.String("d4")},
+		},
+	}, nil, nil)
+	clientMock.On("CompareCommits", mock.Anything, "team", "api", "abc123", "force-pushed").Return(&github.CommitsComparison{
+		Status: github.String("diverged"),
+	}, nil, nil)
+
+	g := GitHub{
+		client: clientMock,
+	}
+
+	changes, err := g.CompareCommits(context.Background(), project, "abc123", "def456")
+	assert.NoError(t, err)
+	assert.Equal(t, []FileChange{
+		{Path: "added.yaml", Status: ChangeAdded, SHA: "a1"},
+		{Path: "changed.yaml", Status: ChangeModified, SHA: "b2"},
+		{Path: "new.yaml", PreviousPath: "old.yaml", Status: ChangeRenamed, SHA: "c3"},
+		{Path: "removed.yaml", Status: ChangeRemoved},
+	}, changes)
+
+	_, err = g.CompareCommits(context.Background(), project, "abc123", "force-pushed")
+	assert.EqualError(t, err, "could not compare abc123...force-pushed of team/api: the head is diverged")
+}
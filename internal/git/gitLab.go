@@ -1,31 +1,54 @@
 package git
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"log/slog"
+	"net/http"
+	"path"
 	"path/filepath"
 	"strconv"
 
 	"gitlab.com/gitlab-org/api/client-go"
+	"golang.org/x/time/rate"
 )
 
 type GitLabAPI interface {
-	ListGroupProjects(group string, opts *gitlab.ListGroupProjectsOptions) ([]*gitlab.Project, *gitlab.Response, error)
-	SearchProjects(project string, opts *gitlab.SearchOptions) ([]*gitlab.Project, *gitlab.Response, error)
-	GetRawFile(project string, path string, opts *gitlab.GetRawFileOptions) ([]byte, *gitlab.Response, error)
-	ListRepositoryTree(project string, opts *gitlab.ListTreeOptions) ([]*gitlab.TreeNode, *gitlab.Response, error)
+	ListGroupProjects(ctx context.Context, group string, opts *gitlab.ListGroupProjectsOptions) ([]*gitlab.Project, *gitlab.Response, error)
+	SearchProjects(ctx context.Context, project string, opts *gitlab.SearchOptions) ([]*gitlab.Project, *gitlab.Response, error)
+	GetProject(ctx context.Context, project string) (*gitlab.Project, *gitlab.Response, error)
+	GetRawFile(ctx context.Context, project string, path string, opts *gitlab.GetRawFileOptions) ([]byte, *gitlab.Response, error)
+	ListRepositoryTree(ctx context.Context, project string, opts *gitlab.ListTreeOptions) ([]*gitlab.TreeNode, *gitlab.Response, error)
+	GetProjectLanguages(ctx context.Context, project string) (*gitlab.ProjectLanguages, *gitlab.Response, error)
+	ListBranches(ctx context.Context, project string, opts *gitlab.ListBranchesOptions) ([]*gitlab.Branch, *gitlab.Response, error)
+	GetCommit(ctx context.Context, project string, sha string) (*gitlab.Commit, *gitlab.Response, error)
+	StreamArchive(ctx context.Context, project string, w io.Writer, opts *gitlab.ArchiveOptions) (*gitlab.Response, error)
+	Compare(ctx context.Context, project string, opts *gitlab.CompareOptions) (*gitlab.Compare, *gitlab.Response, error)
 }
 
+// the compare API lists at most 1000 changed files (the default diff limit of GitLab)
+const gitlabCompareFileLimit = 1000
+
 type GitLab struct {
 	client GitLabAPI
 }
 
-func NewGitLab(token, hostname string) (*GitLab, error) {
+/*
+NewGitLab creates a client sending its requests with the transport (http.DefaultTransport when nil).
+The retries and the rate limiting of the client are disabled, the transport handles them.
+*/
+func NewGitLab(token, hostname string, transport http.RoundTripper) (*GitLab, error) {
 	if hostname == "" {
 		hostname = "gitlab.com"
 		slog.Debug(fmt.Sprintf("using default GitLab hostname: %s", hostname))
 	}
-	client, err := gitlab.NewClient(token, gitlab.WithBaseURL(hostname))
+	client, err := gitlab.NewClient(token,
+		gitlab.WithBaseURL(hostname),
+		gitlab.WithHTTPClient(&http.Client{Transport: transport}),
+		gitlab.WithoutRetries(),
+		gitlab.WithCustomLimiter(rate.NewLimiter(rate.Inf, 0)),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -34,29 +57,43 @@ func NewGitLab(token, hostname string) (*GitLab, error) {
 	}, nil
 }
 
-func (g *GitLab) GetProjectByName(projectName string) (Project, error) {
-	project := Project{}
+/*
+GetProjectByName resolves a project exactly by its full path (group/subgroup/project) or numeric ID.
+When no project matches, the error lists the closest projects found by the search API.
+*/
+func (g *GitLab) GetProjectByName(ctx context.Context, projectName string) (Project, error) {
+	slog.Debug(fmt.Sprintf("getting project: %s", projectName))
+	project, resp, err := g.client.GetProject(ctx, projectName)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return Project{}, g.notFound(ctx, projectName)
+	}
+	if err != nil {
+		return Project{}, err
+	}
+	return newGitLabProject(project), nil
+}
+
+// notFound returns the error of a project which does not exist, with the closest candidates of the search API
+func (g *GitLab) notFound(ctx context.Context, projectName string) error {
 	sOpts := gitlab.SearchOptions{
 		ListOptions: gitlab.ListOptions{
 			Page:    1,
-			PerPage: 1,
+			PerPage: candidateSearchSize,
 		},
 	}
-	slog.Debug(fmt.Sprintf("searching project: %s", projectName))
-	projects, _, err := g.client.SearchProjects(projectName, &sOpts)
+	projects, _, err := g.client.SearchProjects(ctx, path.Base(projectName), &sOpts)
 	if err != nil {
-		return project, err
+		slog.Debug(fmt.Sprintf("could not search candidates of %s: %v", projectName, err))
+		return projectNotFound(projectName, nil)
 	}
-	if len(projects) < 1 {
-		return project, fmt.Errorf("project not found: %s", projectName)
+	var candidates []string
+	for _, project := range projects {
+		candidates = append(candidates, project.PathWithNamespace)
 	}
-	project.Name = projects[0].Name
-	project.ID = projects[0].ID
-	project.PathWithNamespace = projects[0].PathWithNamespace
-	return project, nil
+	return projectNotFound(projectName, candidates)
 }
 
-func (g *GitLab) ListGroupProjects(group string) ([]Project, error) {
+func (g *GitLab) ListGroupProjects(ctx context.Context, group string, filter ProjectFilter) ([]Project, error) {
 	var gitProjects []Project
 	opts := &gitlab.ListGroupProjectsOptions{
 		IncludeSubGroups: gitlab.Bool(true),
@@ -67,32 +104,71 @@ func (g *GitLab) ListGroupProjects(group string) ([]Project, error) {
 	}
 	for {
 		slog.Debug(fmt.Sprintf("fetching projects for group: %s, page: %d", group, opts.Page))
-		projects, resp, err := g.client.ListGroupProjects(group, opts)
+		projects, resp, err := g.client.ListGroupProjects(ctx, group, opts)
 		if err != nil {
 			return nil, err
 		}
 		for _, project := range projects {
-			gitProjects = append(gitProjects, Project{
-				Name:              project.Name,
-				ID:                project.ID,
-				PathWithNamespace: project.PathWithNamespace,
-			})
+			gitProjects = append(gitProjects, newGitLabProject(project))
 		}
-		if resp.NextPage == 0 {
+		if resp == nil || resp.NextPage == 0 {
 			break
 		}
 		opts.Page = resp.NextPage
 	}
-	return gitProjects, nil
+	projects, stats, err := filter.Apply(ctx, gitProjects, g.primaryLanguage)
+	if err != nil {
+		return nil, err
+	}
+	logFilterStats(group, len(gitProjects), stats)
+	return projects, nil
+}
+
+// primaryLanguage returns the language with the highest share of the project
+func (g *GitLab) primaryLanguage(ctx context.Context, project Project) (string, error) {
+	languages, _, err := g.client.GetProjectLanguages(ctx, strconv.Itoa(project.ID))
+	if err != nil || languages == nil {
+		return "", err
+	}
+	primary, share := "", float32(0)
+	for language, s := range *languages {
+		if s > share || (s == share && language < primary) {
+			primary, share = language, s
+		}
+	}
+	return primary, nil
 }
 
-func (g *GitLab) GetRawFile(project Project, path string) ([]byte, error) {
-	slog.Debug(fmt.Sprintf("fetching raw file: %s", filepath.Join(project.PathWithNamespace, path)))
-	content, _, err := g.client.GetRawFile(strconv.Itoa(project.ID), path, &gitlab.GetRawFileOptions{})
+func newGitLabProject(project *gitlab.Project) Project {
+	p := Project{
+		Name:              project.Name,
+		ID:                project.ID,
+		PathWithNamespace: project.PathWithNamespace,
+		WebURL:            project.WebURL,
+		DefaultBranch:     project.DefaultBranch,
+		Archived:          project.Archived,
+		Fork:              project.ForkedFromProject != nil,
+		Visibility:        string(project.Visibility),
+		Topics:            project.Topics,
+	}
+	// gitlab has no push date, the last activity includes pushes
+	if project.LastActivityAt != nil {
+		p.PushedAt = *project.LastActivityAt
+	}
+	return p
+}
+
+func (g *GitLab) GetRawFile(ctx context.Context, project Project, ref, path string) ([]byte, error) {
+	slog.Debug(fmt.Sprintf("fetching raw file: %s@%s", filepath.Join(project.PathWithNamespace, path), ref))
+	opts := &gitlab.GetRawFileOptions{}
+	if ref != "" {
+		opts.Ref = gitlab.Ptr(ref)
+	}
+	content, _, err := g.client.GetRawFile(ctx, strconv.Itoa(project.ID), path, opts)
 	return content, err
 }
 
-func (g *GitLab) ListRepositoryTree(project Project) ([]TreeNode, error) {
+func (g *GitLab) ListRepositoryTree(ctx context.Context, project Project, ref string) ([]TreeNode, error) {
 	var repoTreeNodes []TreeNode
 	opts := &gitlab.ListTreeOptions{
 		Recursive: gitlab.Bool(true),
@@ -101,9 +177,12 @@ func (g *GitLab) ListRepositoryTree(project Project) ([]TreeNode, error) {
 			Page:    1,
 		},
 	}
+	if ref != "" {
+		opts.Ref = gitlab.Ptr(ref)
+	}
 	for {
-		slog.Debug(fmt.Sprintf("fetching tree for project: %s, page: %d", project.Name, opts.Page))
-		treeNodes, resp, err := g.client.ListRepositoryTree(project.PathWithNamespace, opts)
+		slog.Debug(fmt.Sprintf("fetching tree for project: %s@%s, page: %d", project.Name, ref, opts.Page))
+		treeNodes, resp, err := g.client.ListRepositoryTree(ctx, project.PathWithNamespace, opts)
 		if err != nil {
 			return repoTreeNodes, err
 		}
@@ -112,9 +191,10 @@ func (g *GitLab) ListRepositoryTree(project Project) ([]TreeNode, error) {
 				IsTree: treeNode.Type == "tree",
 				Path:   treeNode.Path,
 				Type:   treeNode.Type,
+				SHA:    treeNode.ID,
 			})
 		}
-		if resp.NextPage == 0 {
+		if resp == nil || resp.NextPage == 0 {
 			break
 		}
 		opts.Page = resp.NextPage
@@ -122,3 +202,110 @@ func (g *GitLab) ListRepositoryTree(project Project) ([]TreeNode, error) {
 
 	return repoTreeNodes, nil
 }
+
+// ResolveRef returns the commit SHA of a branch, tag or SHA, an empty ref is the default branch
+func (g *GitLab) ResolveRef(ctx context.Context, project Project, ref string) (Ref, error) {
+	if ref == "" {
+		ref = "HEAD"
+	}
+	slog.Debug(fmt.Sprintf("resolving ref %s of project: %s", ref, project.PathWithNamespace))
+	commit, _, err := g.client.GetCommit(ctx, strconv.Itoa(project.ID), ref)
+	if err != nil {
+		return Ref{}, fmt.Errorf("could not resolve ref %s of %s: %w", ref, project.PathWithNamespace, err)
+	}
+	return Ref{Name: ref, SHA: commit.ID}, nil
+}
+
+/*
+CompareCommits lists the files changed from the base to the head commit, the commits are compared directly,
+so a base which is no ancestor of the head (e.g. after a force push) is compared correctly.
+The comparison fails when GitLab timed out or the list of files is truncated.
+*/
+func (g *GitLab) CompareCommits(ctx context.Context, project Project, base, head string) ([]FileChange, error) {
+	slog.Debug(fmt.Sprintf("comparing %s..%s of project: %s", base, head, project.PathWithNamespace))
+	comparison, _, err := g.client.Compare(ctx, strconv.Itoa(project.ID), &gitlab.CompareOptions{
+		From:     gitlab.Ptr(base),
+		To:       gitlab.Ptr(head),
+		Straight: gitlab.Ptr(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not compare %s..%s of %s: %w", base, head, project.PathWithNamespace, err)
+	}
+	if comparison.CompareTimeout {
+		return nil, fmt.Errorf("could not compare %s..%s of %s: the comparison timed out", base, head, project.PathWithNamespace)
+	}
+	if len(comparison.Diffs) >= gitlabCompareFileLimit {
+		return nil, fmt.Errorf("could not compare %s..%s of %s: more than %d files changed", base, head, project.PathWithNamespace, gitlabCompareFileLimit)
+	}
+	var changes []FileChange
+	for _, diff := range comparison.Diffs {
+		change := FileChange{Path: diff.NewPath, Status: ChangeModified}
+		switch {
+		case diff.NewFile:
+			change.Status = ChangeAdded
+		case diff.DeletedFile:
+			change.Status = ChangeRemoved
+		case diff.RenamedFile:
+			change.Status = ChangeRenamed
+			change.PreviousPath = diff.OldPath
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+func (g *GitLab) ListBranches(ctx context.Context, project Project) ([]Ref, error) {
+	var refs []Ref
+	opts := &gitlab.ListBranchesOptions{
+		ListOptions: gitlab.ListOptions{
+			PerPage: 100,
+			Page:    1,
+		},
+	}
+	for {
+		slog.Debug(fmt.Sprintf("fetching branches for project: %s, page: %d", project.PathWithNamespace, opts.Page))
+		branches, resp, err := g.client.ListBranches(ctx, strconv.Itoa(project.ID), opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, branch := range branches {
+			ref := Ref{Name: branch.Name}
+			if branch.Commit != nil {
+				ref.SHA = branch.Commit.ID
+			}
+			refs = append(refs, ref)
+		}
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return refs, nil
+}
+
+/*
+GetArchive streams the gzipped tarball of the project at the ref, the download runs until the archive is read or closed.
+The request timeout does not apply to the download.
+*/
+func (g *GitLab) GetArchive(ctx context.Context, project Project, ref string) (io.ReadCloser, error) {
+	slog.Debug(fmt.Sprintf("fetching archive of project: %s@%s", project.PathWithNamespace, ref))
+	opts := &gitlab.ArchiveOptions{Format: gitlab.Ptr("tar.gz")}
+	if ref != "" {
+		opts.SHA = gitlab.Ptr(ref)
+	}
+	reader, writer := io.Pipe()
+	go func() {
+		_, err := g.client.StreamArchive(withoutRequestTimeout(ctx), strconv.Itoa(project.ID), archiveWriter{pipe: writer}, opts)
+		writer.CloseWithError(err)
+	}()
+	return reader, nil
+}
+
+// archiveWriter passes only the writing side of the pipe to the client, the pipe itself is closed concurrently
+type archiveWriter struct {
+	pipe *io.PipeWriter
+}
+
+func (w archiveWriter) Write(p []byte) (int, error) {
+	return w.pipe.Write(p)
+}
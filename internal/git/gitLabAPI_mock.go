@@ -3,6 +3,9 @@
 package git
 
 import (
+	context "context"
+	io "io"
+
 	mock "github.com/stretchr/testify/mock"
 	gitlab "gitlab.com/gitlab-org/api/client-go"
 )
@@ -20,9 +23,283 @@ func (_m *GitLabAPIMock) EXPECT() *GitLabAPIMock_Expecter {
 	return &GitLabAPIMock_Expecter{mock: &_m.Mock}
 }
 
-// GetRawFile provides a mock function with given fields: project, path, opts
-func (_m *GitLabAPIMock) GetRawFile(project string, path string, opts *gitlab.GetRawFileOptions) ([]byte, *gitlab.Response, error) {
-	ret := _m.Called(project, path, opts)
+// Compare provides a mock function with given fields: ctx, project, opts
+func (_m *GitLabAPIMock) Compare(ctx context.Context, project string, opts *gitlab.CompareOptions) (*gitlab.Compare, *gitlab.Response, error) {
+	ret := _m.Called(ctx, project, opts)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Compare")
+	}
+
+	var r0 *gitlab.Compare
+	var r1 *gitlab.Response
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *gitlab.CompareOptions) (*gitlab.Compare, *gitlab.Response, error)); ok {
+		return rf(ctx, project, opts)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, *gitlab.CompareOptions) *gitlab.Compare); ok {
+		r0 = rf(ctx, project, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gitlab.Compare)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, *gitlab.CompareOptions) *gitlab.Response); ok {
+		r1 = rf(ctx, project, opts)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*gitlab.Response)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, *gitlab.CompareOptions) error); ok {
+		r2 = rf(ctx, project, opts)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GitLabAPIMock_Compare_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Compare'
+type GitLabAPIMock_Compare_Call struct {
+	*mock.Call
+}
+
+// Compare is a helper method to define mock.On call
+//   - ctx context.Context
+//   - project string
+//   - opts *gitlab.CompareOptions
+func (_e *GitLabAPIMock_Expecter) Compare(ctx interface{}, project interface{}, opts interface{}) *GitLabAPIMock_Compare_Call {
+	return &GitLabAPIMock_Compare_Call{Call: _e.mock.On("Compare", ctx, project, opts)}
+}
+
+func (_c *GitLabAPIMock_Compare_Call) Run(run func(ctx context.Context, project string, opts *gitlab.CompareOptions)) *GitLabAPIMock_Compare_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(*gitlab.CompareOptions))
+	})
+	return _c
+}
+
+func (_c *GitLabAPIMock_Compare_Call) Return(_a0 *gitlab.Compare, _a1 *gitlab.Response, _a2 error) *GitLabAPIMock_Compare_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *GitLabAPIMock_Compare_Call) RunAndReturn(run func(context.Context, string, *gitlab.CompareOptions) (*gitlab.Compare, *gitlab.Response, error)) *GitLabAPIMock_Compare_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetCommit provides a mock function with given fields: ctx, project, sha
+func (_m *GitLabAPIMock) GetCommit(ctx context.Context, project string, sha string) (*gitlab.Commit, *gitlab.Response, error) {
+	ret := _m.Called(ctx, project, sha)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCommit")
+	}
+
+	var r0 *gitlab.Commit
+	var r1 *gitlab.Response
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*gitlab.Commit, *gitlab.Response, error)); ok {
+		return rf(ctx, project, sha)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *gitlab.Commit); ok {
+		r0 = rf(ctx, project, sha)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gitlab.Commit)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) *gitlab.Response); ok {
+		r1 = rf(ctx, project, sha)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*gitlab.Response)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, string) error); ok {
+		r2 = rf(ctx, project, sha)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GitLabAPIMock_GetCommit_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetCommit'
+type GitLabAPIMock_GetCommit_Call struct {
+	*mock.Call
+}
+
+// GetCommit is a helper method to define mock.On call
+//   - ctx context.Context
+//   - project string
+//   - sha string
+func (_e *GitLabAPIMock_Expecter) GetCommit(ctx interface{}, project interface{}, sha interface{}) *GitLabAPIMock_GetCommit_Call {
+	return &GitLabAPIMock_GetCommit_Call{Call: _e.mock.On("GetCommit", ctx, project, sha)}
+}
+
+func (_c *GitLabAPIMock_GetCommit_Call) Run(run func(ctx context.Context, project string, sha string)) *GitLabAPIMock_GetCommit_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *GitLabAPIMock_GetCommit_Call) Return(_a0 *gitlab.Commit, _a1 *gitlab.Response, _a2 error) *GitLabAPIMock_GetCommit_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *GitLabAPIMock_GetCommit_Call) RunAndReturn(run func(context.Context, string, string) (*gitlab.Commit, *gitlab.Response, error)) *GitLabAPIMock_GetCommit_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetProject provides a mock function with given fields: ctx, project
+func (_m *GitLabAPIMock) GetProject(ctx context.Context, project string) (*gitlab.Project, *gitlab.Response, error) {
+	ret := _m.Called(ctx, project)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetProject")
+	}
+
+	var r0 *gitlab.Project
+	var r1 *gitlab.Response
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*gitlab.Project, *gitlab.Response, error)); ok {
+		return rf(ctx, project)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *gitlab.Project); ok {
+		r0 = rf(ctx, project)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gitlab.Project)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) *gitlab.Response); ok {
+		r1 = rf(ctx, project)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*gitlab.Response)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string) error); ok {
+		r2 = rf(ctx, project)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GitLabAPIMock_GetProject_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetProject'
+type GitLabAPIMock_GetProject_Call struct {
+	*mock.Call
+}
+
+// GetProject is a helper method to define mock.On call
+//   - ctx context.Context
+//   - project string
+func (_e *GitLabAPIMock_Expecter) GetProject(ctx interface{}, project interface{}) *GitLabAPIMock_GetProject_Call {
+	return &GitLabAPIMock_GetProject_Call{Call: _e.mock.On("GetProject", ctx, project)}
+}
+
+func (_c *GitLabAPIMock_GetProject_Call) Run(run func(ctx context.Context, project string)) *GitLabAPIMock_GetProject_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *GitLabAPIMock_GetProject_Call) Return(_a0 *gitlab.Project, _a1 *gitlab.Response, _a2 error) *GitLabAPIMock_GetProject_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *GitLabAPIMock_GetProject_Call) RunAndReturn(run func(context.Context, string) (*gitlab.Project, *gitlab.Response, error)) *GitLabAPIMock_GetProject_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetProjectLanguages provides a mock function with given fields: ctx, project
+func (_m *GitLabAPIMock) GetProjectLanguages(ctx context.Context, project string) (*gitlab.ProjectLanguages, *gitlab.Response, error) {
+	ret := _m.Called(ctx, project)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetProjectLanguages")
+	}
+
+	var r0 *gitlab.ProjectLanguages
+	var r1 *gitlab.Response
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*gitlab.ProjectLanguages, *gitlab.Response, error)); ok {
+		return rf(ctx, project)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *gitlab.ProjectLanguages); ok {
+		r0 = rf(ctx, project)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gitlab.ProjectLanguages)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) *gitlab.Response); ok {
+		r1 = rf(ctx, project)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*gitlab.Response)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string) error); ok {
+		r2 = rf(ctx, project)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GitLabAPIMock_GetProjectLanguages_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetProjectLanguages'
+type GitLabAPIMock_GetProjectLanguages_Call struct {
+	*mock.Call
+}
+
+// GetProjectLanguages is a helper method to define mock.On call
+//   - ctx context.Context
+//   - project string
+func (_e *GitLabAPIMock_Expecter) GetProjectLanguages(ctx interface{}, project interface{}) *GitLabAPIMock_GetProjectLanguages_Call {
+	return &GitLabAPIMock_GetProjectLanguages_Call{Call: _e.mock.On("GetProjectLanguages", ctx, project)}
+}
+
+func (_c *GitLabAPIMock_GetProjectLanguages_Call) Run(run func(ctx context.Context, project string)) *GitLabAPIMock_GetProjectLanguages_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *GitLabAPIMock_GetProjectLanguages_Call) Return(_a0 *gitlab.ProjectLanguages, _a1 *gitlab.Response, _a2 error) *GitLabAPIMock_GetProjectLanguages_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *GitLabAPIMock_GetProjectLanguages_Call) RunAndReturn(run func(context.Context, string) (*gitlab.ProjectLanguages, *gitlab.Response, error)) *GitLabAPIMock_GetProjectLanguages_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetRawFile provides a mock function with given fields: ctx, project, path, opts
+func (_m *GitLabAPIMock) GetRawFile(ctx context.Context, project string, path string, opts *gitlab.GetRawFileOptions) ([]byte, *gitlab.Response, error) {
+	ret := _m.Called(ctx, project, path, opts)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetRawFile")
@@ -31,27 +308,27 @@ func (_m *GitLabAPIMock) GetRawFile(project string, path string, opts *gitlab.Ge
 	var r0 []byte
 	var r1 *gitlab.Response
 	var r2 error
-	if rf, ok := ret.Get(0).(func(string, string, *gitlab.GetRawFileOptions) ([]byte, *gitlab.Response, error)); ok {
-		return rf(project, path, opts)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *gitlab.GetRawFileOptions) ([]byte, *gitlab.Response, error)); ok {
+		return rf(ctx, project, path, opts)
 	}
-	if rf, ok := ret.Get(0).(func(string, string, *gitlab.GetRawFileOptions) []byte); ok {
-		r0 = rf(project, path, opts)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *gitlab.GetRawFileOptions) []byte); ok {
+		r0 = rf(ctx, project, path, opts)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]byte)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string, *gitlab.GetRawFileOptions) *gitlab.Response); ok {
-		r1 = rf(project, path, opts)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, *gitlab.GetRawFileOptions) *gitlab.Response); ok {
+		r1 = rf(ctx, project, path, opts)
 	} else {
 		if ret.Get(1) != nil {
 			r1 = ret.Get(1).(*gitlab.Response)
 		}
 	}
 
-	if rf, ok := ret.Get(2).(func(string, string, *gitlab.GetRawFileOptions) error); ok {
-		r2 = rf(project, path, opts)
+	if rf, ok := ret.Get(2).(func(context.Context, string, string, *gitlab.GetRawFileOptions) error); ok {
+		r2 = rf(ctx, project, path, opts)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -65,16 +342,17 @@ type GitLabAPIMock_GetRawFile_Call struct {
 }
 
 // GetRawFile is a helper method to define mock.On call
+//   - ctx context.Context
 //   - project string
 //   - path string
 //   - opts *gitlab.GetRawFileOptions
-func (_e *GitLabAPIMock_Expecter) GetRawFile(project interface{}, path interface{}, opts interface{}) *GitLabAPIMock_GetRawFile_Call {
-	return &GitLabAPIMock_GetRawFile_Call{Call: _e.mock.On("GetRawFile", project, path, opts)}
+func (_e *GitLabAPIMock_Expecter) GetRawFile(ctx interface{}, project interface{}, path interface{}, opts interface{}) *GitLabAPIMock_GetRawFile_Call {
+	return &GitLabAPIMock_GetRawFile_Call{Call: _e.mock.On("GetRawFile", ctx, project, path, opts)}
 }
 
-func (_c *GitLabAPIMock_GetRawFile_Call) Run(run func(project string, path string, opts *gitlab.GetRawFileOptions)) *GitLabAPIMock_GetRawFile_Call {
+func (_c *GitLabAPIMock_GetRawFile_Call) Run(run func(ctx context.Context, project string, path string, opts *gitlab.GetRawFileOptions)) *GitLabAPIMock_GetRawFile_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string), args[1].(string), args[2].(*gitlab.GetRawFileOptions))
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(*gitlab.GetRawFileOptions))
 	})
 	return _c
 }
@@ -84,14 +362,83 @@ func (_c *GitLabAPIMock_GetRawFile_Call) Return(_a0 []byte, _a1 *gitlab.Response
 	return _c
 }
 
-func (_c *GitLabAPIMock_GetRawFile_Call) RunAndReturn(run func(string, string, *gitlab.GetRawFileOptions) ([]byte, *gitlab.Response, error)) *GitLabAPIMock_GetRawFile_Call {
+func (_c *GitLabAPIMock_GetRawFile_Call) RunAndReturn(run func(context.Context, string, string, *gitlab.GetRawFileOptions) ([]byte, *gitlab.Response, error)) *GitLabAPIMock_GetRawFile_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// ListGroupProjects provides a mock function with given fields: group, opts
-func (_m *GitLabAPIMock) ListGroupProjects(group string, opts *gitlab.ListGroupProjectsOptions) ([]*gitlab.Project, *gitlab.Response, error) {
-	ret := _m.Called(group, opts)
+// ListBranches provides a mock function with given fields: ctx, project, opts
+func (_m *GitLabAPIMock) ListBranches(ctx context.Context, project string, opts *gitlab.ListBranchesOptions) ([]*gitlab.Branch, *gitlab.Response, error) {
+	ret := _m.Called(ctx, project, opts)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListBranches")
+	}
+
+	var r0 []*gitlab.Branch
+	var r1 *gitlab.Response
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *gitlab.ListBranchesOptions) ([]*gitlab.Branch, *gitlab.Response, error)); ok {
+		return rf(ctx, project, opts)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, *gitlab.ListBranchesOptions) []*gitlab.Branch); ok {
+		r0 = rf(ctx, project, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*gitlab.Branch)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, *gitlab.ListBranchesOptions) *gitlab.Response); ok {
+		r1 = rf(ctx, project, opts)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*gitlab.Response)
+		}
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, *gitlab.ListBranchesOptions) error); ok {
+		r2 = rf(ctx, project, opts)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GitLabAPIMock_ListBranches_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListBranches'
+type GitLabAPIMock_ListBranches_Call struct {
+	*mock.Call
+}
+
+// ListBranches is a helper method to define mock.On call
+//   - ctx context.Context
+//   - project string
+//   - opts *gitlab.ListBranchesOptions
+func (_e *GitLabAPIMock_Expecter) ListBranches(ctx interface{}, project interface{}, opts interface{}) *GitLabAPIMock_ListBranches_Call {
+	return &GitLabAPIMock_ListBranches_Call{Call: _e.mock.On("ListBranches", ctx, project, opts)}
+}
+
+func (_c *GitLabAPIMock_ListBranches_Call) Run(run func(ctx context.Context, project string, opts *gitlab.ListBranchesOptions)) *GitLabAPIMock_ListBranches_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(*gitlab.ListBranchesOptions))
+	})
+	return _c
+}
+
+func (_c *GitLabAPIMock_ListBranches_Call) Return(_a0 []*gitlab.Branch, _a1 *gitlab.Response, _a2 error) *GitLabAPIMock_ListBranches_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *GitLabAPIMock_ListBranches_Call) RunAndReturn(run func(context.Context, string, *gitlab.ListBranchesOptions) ([]*gitlab.Branch, *gitlab.Response, error)) *GitLabAPIMock_ListBranches_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListGroupProjects provides a mock function with given fields: ctx, group, opts
+func (_m *GitLabAPIMock) ListGroupProjects(ctx context.Context, group string, opts *gitlab.ListGroupProjectsOptions) ([]*gitlab.Project, *gitlab.Response, error) {
+	ret := _m.Called(ctx, group, opts)
 
 	if len(ret) == 0 {
 		panic("no return value specified for ListGroupProjects")
@@ -100,27 +447,27 @@ func (_m *GitLabAPIMock) ListGroupProjects(group string, opts *gitlab.ListGroupP
 	var r0 []*gitlab.Project
 	var r1 *gitlab.Response
 	var r2 error
-	if rf, ok := ret.Get(0).(func(string, *gitlab.ListGroupProjectsOptions) ([]*gitlab.Project, *gitlab.Response, error)); ok {
-		return rf(group, opts)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *gitlab.ListGroupProjectsOptions) ([]*gitlab.Project, *gitlab.Response, error)); ok {
+		return rf(ctx, group, opts)
 	}
-	if rf, ok := ret.Get(0).(func(string, *gitlab.ListGroupProjectsOptions) []*gitlab.Project); ok {
-		r0 = rf(group, opts)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *gitlab.ListGroupProjectsOptions) []*gitlab.Project); ok {
+		r0 = rf(ctx, group, opts)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*gitlab.Project)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string, *gitlab.ListGroupProjectsOptions) *gitlab.Response); ok {
-		r1 = rf(group, opts)
+	if rf, ok := ret.Get(1).(func(context.Context, string, *gitlab.ListGroupProjectsOptions) *gitlab.Response); ok {
+		r1 = rf(ctx, group, opts)
 	} else {
 		if ret.Get(1) != nil {
 			r1 = ret.Get(1).(*gitlab.Response)
 		}
 	}
 
-	if rf, ok := ret.Get(2).(func(string, *gitlab.ListGroupProjectsOptions) error); ok {
-		r2 = rf(group, opts)
+	if rf, ok := ret.Get(2).(func(context.Context, string, *gitlab.ListGroupProjectsOptions) error); ok {
+		r2 = rf(ctx, group, opts)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -134,15 +481,16 @@ type GitLabAPIMock_ListGroupProjects_Call struct {
 }
 
 // ListGroupProjects is a helper method to define mock.On call
+//   - ctx context.Context
 //   - group string
 //   - opts *gitlab.ListGroupProjectsOptions
-func (_e *GitLabAPIMock_Expecter) ListGroupProjects(group interface{}, opts interface{}) *GitLabAPIMock_ListGroupProjects_Call {
-	return &GitLabAPIMock_ListGroupProjects_Call{Call: _e.mock.On("ListGroupProjects", group, opts)}
+func (_e *GitLabAPIMock_Expecter) ListGroupProjects(ctx interface{}, group interface{}, opts interface{}) *GitLabAPIMock_ListGroupProjects_Call {
+	return &GitLabAPIMock_ListGroupProjects_Call{Call: _e.mock.On("ListGroupProjects", ctx, group, opts)}
 }
 
-func (_c *GitLabAPIMock_ListGroupProjects_Call) Run(run func(group string, opts *gitlab.ListGroupProjectsOptions)) *GitLabAPIMock_ListGroupProjects_Call {
+func (_c *GitLabAPIMock_ListGroupProjects_Call) Run(run func(ctx context.Context, group string, opts *gitlab.ListGroupProjectsOptions)) *GitLabAPIMock_ListGroupProjects_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string), args[1].(*gitlab.ListGroupProjectsOptions))
+		run(args[0].(context.Context), args[1].(string), args[2].(*gitlab.ListGroupProjectsOptions))
 	})
 	return _c
 }
@@ -152,14 +500,14 @@ func (_c *GitLabAPIMock_ListGroupProjects_Call) Return(_a0 []*gitlab.Project, _a
 	return _c
 }
 
-func (_c *GitLabAPIMock_ListGroupProjects_Call) RunAndReturn(run func(string, *gitlab.ListGroupProjectsOptions) ([]*gitlab.Project, *gitlab.Response, error)) *GitLabAPIMock_ListGroupProjects_Call {
+func (_c *GitLabAPIMock_ListGroupProjects_Call) RunAndReturn(run func(context.Context, string, *gitlab.ListGroupProjectsOptions) ([]*gitlab.Project, *gitlab.Response, error)) *GitLabAPIMock_ListGroupProjects_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// ListRepositoryTree provides a mock function with given fields: project, opts
-func (_m *GitLabAPIMock) ListRepositoryTree(project string, opts *gitlab.ListTreeOptions) ([]*gitlab.TreeNode, *gitlab.Response, error) {
-	ret := _m.Called(project, opts)
+// ListRepositoryTree provides a mock function with given fields: ctx, project, opts
+func (_m *GitLabAPIMock) ListRepositoryTree(ctx context.Context, project string, opts *gitlab.ListTreeOptions) ([]*gitlab.TreeNode, *gitlab.Response, error) {
+	ret := _m.Called(ctx, project, opts)
 
 	if len(ret) == 0 {
 		panic("no return value specified for ListRepositoryTree")
@@ -168,27 +516,27 @@ func (_m *GitLabAPIMock) ListRepositoryTree(project string, opts *gitlab.ListTre
 	var r0 []*gitlab.TreeNode
 	var r1 *gitlab.Response
 	var r2 error
-	if rf, ok := ret.Get(0).(func(string, *gitlab.ListTreeOptions) ([]*gitlab.TreeNode, *gitlab.Response, error)); ok {
-		return rf(project, opts)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *gitlab.ListTreeOptions) ([]*gitlab.TreeNode, *gitlab.Response, error)); ok {
+		return rf(ctx, project, opts)
 	}
-	if rf, ok := ret.Get(0).(func(string, *gitlab.ListTreeOptions) []*gitlab.TreeNode); ok {
-		r0 = rf(project, opts)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *gitlab.ListTreeOptions) []*gitlab.TreeNode); ok {
+		r0 = rf(ctx, project, opts)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*gitlab.TreeNode)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string, *gitlab.ListTreeOptions) *gitlab.Response); ok {
-		r1 = rf(project, opts)
+	if rf, ok := ret.Get(1).(func(context.Context, string, *gitlab.ListTreeOptions) *gitlab.Response); ok {
+		r1 = rf(ctx, project, opts)
 	} else {
 		if ret.Get(1) != nil {
 			r1 = ret.Get(1).(*gitlab.Response)
 		}
 	}
 
-	if rf, ok := ret.Get(2).(func(string, *gitlab.ListTreeOptions) error); ok {
-		r2 = rf(project, opts)
+	if rf, ok := ret.Get(2).(func(context.Context, string, *gitlab.ListTreeOptions) error); ok {
+		r2 = rf(ctx, project, opts)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -202,15 +550,16 @@ type GitLabAPIMock_ListRepositoryTree_Call struct {
 }
 
 // ListRepositoryTree is a helper method to define mock.On call
+//   - ctx context.Context
 //   - project string
 //   - opts *gitlab.ListTreeOptions
-func (_e *GitLabAPIMock_Expecter) ListRepositoryTree(project interface{}, opts interface{}) *GitLabAPIMock_ListRepositoryTree_Call {
-	return &GitLabAPIMock_ListRepositoryTree_Call{Call: _e.mock.On("ListRepositoryTree", project, opts)}
+func (_e *GitLabAPIMock_Expecter) ListRepositoryTree(ctx interface{}, project interface{}, opts interface{}) *GitLabAPIMock_ListRepositoryTree_Call {
+	return &GitLabAPIMock_ListRepositoryTree_Call{Call: _e.mock.On("ListRepositoryTree", ctx, project, opts)}
 }
 
-func (_c *GitLabAPIMock_ListRepositoryTree_Call) Run(run func(project string, opts *gitlab.ListTreeOptions)) *GitLabAPIMock_ListRepositoryTree_Call {
+func (_c *GitLabAPIMock_ListRepositoryTree_Call) Run(run func(ctx context.Context, project string, opts *gitlab.ListTreeOptions)) *GitLabAPIMock_ListRepositoryTree_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string), args[1].(*gitlab.ListTreeOptions))
+		run(args[0].(context.Context), args[1].(string), args[2].(*gitlab.ListTreeOptions))
 	})
 	return _c
 }
@@ -220,14 +569,14 @@ func (_c *GitLabAPIMock_ListRepositoryTree_Call) Return(_a0 []*gitlab.TreeNode,
 	return _c
 }
 
-func (_c *GitLabAPIMock_ListRepositoryTree_Call) RunAndReturn(run func(string, *gitlab.ListTreeOptions) ([]*gitlab.TreeNode, *gitlab.Response, error)) *GitLabAPIMock_ListRepositoryTree_Call {
+func (_c *GitLabAPIMock_ListRepositoryTree_Call) RunAndReturn(run func(context.Context, string, *gitlab.ListTreeOptions) ([]*gitlab.TreeNode, *gitlab.Response, error)) *GitLabAPIMock_ListRepositoryTree_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// SearchProjects provides a mock function with given fields: project, opts
-func (_m *GitLabAPIMock) SearchProjects(project string, opts *gitlab.SearchOptions) ([]*gitlab.Project, *gitlab.Response, error) {
-	ret := _m.Called(project, opts)
+// SearchProjects provides a mock function with given fields: ctx, project, opts
+func (_m *GitLabAPIMock) SearchProjects(ctx context.Context, project string, opts *gitlab.SearchOptions) ([]*gitlab.Project, *gitlab.Response, error) {
+	ret := _m.Called(ctx, project, opts)
 
 	if len(ret) == 0 {
 		panic("no return value specified for SearchProjects")
@@ -236,27 +585,27 @@ func (_m *GitLabAPIMock) SearchProjects(project string, opts *gitlab.SearchOptio
 	var r0 []*gitlab.Project
 	var r1 *gitlab.Response
 	var r2 error
-	if rf, ok := ret.Get(0).(func(string, *gitlab.SearchOptions) ([]*gitlab.Project, *gitlab.Response, error)); ok {
-		return rf(project, opts)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *gitlab.SearchOptions) ([]*gitlab.Project, *gitlab.Response, error)); ok {
+		return rf(ctx, project, opts)
 	}
-	if rf, ok := ret.Get(0).(func(string, *gitlab.SearchOptions) []*gitlab.Project); ok {
-		r0 = rf(project, opts)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *gitlab.SearchOptions) []*gitlab.Project); ok {
+		r0 = rf(ctx, project, opts)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*gitlab.Project)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string, *gitlab.SearchOptions) *gitlab.Response); ok {
-		r1 = rf(project, opts)
+	if rf, ok := ret.Get(1).(func(context.Context, string, *gitlab.SearchOptions) *gitlab.Response); ok {
+		r1 = rf(ctx, project, opts)
 	} else {
 		if ret.Get(1) != nil {
 			r1 = ret.Get(1).(*gitlab.Response)
 		}
 	}
 
-	if rf, ok := ret.Get(2).(func(string, *gitlab.SearchOptions) error); ok {
-		r2 = rf(project, opts)
+	if rf, ok := ret.Get(2).(func(context.Context, string, *gitlab.SearchOptions) error); ok {
+		r2 = rf(ctx, project, opts)
 	} else {
 		r2 = ret.Error(2)
 	}
@@ -270,15 +619,16 @@ type GitLabAPIMock_SearchProjects_Call struct {
 }
 
 // SearchProjects is a helper method to define mock.On call
+//   - ctx context.Context
 //   - project string
 //   - opts *gitlab.SearchOptions
-func (_e *GitLabAPIMock_Expecter) SearchProjects(project interface{}, opts interface{}) *GitLabAPIMock_SearchProjects_Call {
-	return &GitLabAPIMock_SearchProjects_Call{Call: _e.mock.On("SearchProjects", project, opts)}
+func (_e *GitLabAPIMock_Expecter) SearchProjects(ctx interface{}, project interface{}, opts interface{}) *GitLabAPIMock_SearchProjects_Call {
+	return &GitLabAPIMock_SearchProjects_Call{Call: _e.mock.On("SearchProjects", ctx, project, opts)}
 }
 
-func (_c *GitLabAPIMock_SearchProjects_Call) Run(run func(project string, opts *gitlab.SearchOptions)) *GitLabAPIMock_SearchProjects_Call {
+func (_c *GitLabAPIMock_SearchProjects_Call) Run(run func(ctx context.Context, project string, opts *gitlab.SearchOptions)) *GitLabAPIMock_SearchProjects_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string), args[1].(*gitlab.SearchOptions))
+		run(args[0].(context.Context), args[1].(string), args[2].(*gitlab.SearchOptions))
 	})
 	return _c
 }
@@ -288,7 +638,68 @@ func (_c *GitLabAPIMock_SearchProjects_Call) Return(_a0 []*gitlab.Project, _a1 *
 	return _c
 }
 
-func (_c *GitLabAPIMock_SearchProjects_Call) RunAndReturn(run func(string, *gitlab.SearchOptions) ([]*gitlab.Project, *gitlab.Response, error)) *GitLabAPIMock_SearchProjects_Call {
+func (_c *GitLabAPIMock_SearchProjects_Call) RunAndReturn(run func(context.Context, string, *gitlab.SearchOptions) ([]*gitlab.Project, *gitlab.Response, error)) *GitLabAPIMock_SearchProjects_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// StreamArchive provides a mock function with given fields: ctx, project, w, opts
+func (_m *GitLabAPIMock) StreamArchive(ctx context.Context, project string, w io.Writer, opts *gitlab.ArchiveOptions) (*gitlab.Response, error) {
+	ret := _m.Called(ctx, project, w, opts)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StreamArchive")
+	}
+
+	var r0 *gitlab.Response
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, io.Writer, *gitlab.ArchiveOptions) (*gitlab.Response, error)); ok {
+		return rf(ctx, project, w, opts)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, io.Writer, *gitlab.ArchiveOptions) *gitlab.Response); ok {
+		r0 = rf(ctx, project, w, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gitlab.Response)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, io.Writer, *gitlab.ArchiveOptions) error); ok {
+		r1 = rf(ctx, project, w, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GitLabAPIMock_StreamArchive_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StreamArchive'
+type GitLabAPIMock_StreamArchive_Call struct {
+	*mock.Call
+}
+
+// StreamArchive is a helper method to define mock.On call
+//   - ctx context.Context
+//   - project string
+//   - w io.Writer
+//   - opts *gitlab.ArchiveOptions
+func (_e *GitLabAPIMock_Expecter) StreamArchive(ctx interface{}, project interface{}, w interface{}, opts interface{}) *GitLabAPIMock_StreamArchive_Call {
+	return &GitLabAPIMock_StreamArchive_Call{Call: _e.mock.On("StreamArchive", ctx, project, w, opts)}
+}
+
+func (_c *GitLabAPIMock_StreamArchive_Call) Run(run func(ctx context.Context, project string, w io.Writer, opts *gitlab.ArchiveOptions)) *GitLabAPIMock_StreamArchive_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(io.Writer), args[3].(*gitlab.ArchiveOptions))
+	})
+	return _c
+}
+
+func (_c *GitLabAPIMock_StreamArchive_Call) Return(_a0 *gitlab.Response, _a1 error) *GitLabAPIMock_StreamArchive_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GitLabAPIMock_StreamArchive_Call) RunAndReturn(run func(context.Context, string, io.Writer, *gitlab.ArchiveOptions) (*gitlab.Response, error)) *GitLabAPIMock_StreamArchive_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -1,6 +1,11 @@
 package git
 
-import gitlab "gitlab.com/gitlab-org/api/client-go"
+import (
+	"context"
+	"io"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
 
 /*
 gitlabClientWrapper is a wrapper around gitlab.Client to make it easier to mock the gitlab.Client in tests
@@ -10,22 +15,54 @@ type gitlabClientWrapper struct {
 	client *gitlab.Client
 }
 
-func (w *gitlabClientWrapper) ListGroupProjects(group string, opts *gitlab.ListGroupProjectsOptions) ([]*gitlab.Project, *gitlab.Response, error) {
-	return w.client.Groups.ListGroupProjects(group, opts)
+func (w *gitlabClientWrapper) ListGroupProjects(ctx context.Context, group string, opts *gitlab.ListGroupProjectsOptions) ([]*gitlab.Project, *gitlab.Response, error) {
+	return w.client.Groups.ListGroupProjects(group, opts, gitlab.WithContext(ctx))
 }
 
 /*
 project string: The name or ID of the project
 */
 
-func (w *gitlabClientWrapper) SearchProjects(project string, opts *gitlab.SearchOptions) ([]*gitlab.Project, *gitlab.Response, error) {
-	return w.client.Search.Projects(project, opts)
+func (w *gitlabClientWrapper) SearchProjects(ctx context.Context, project string, opts *gitlab.SearchOptions) ([]*gitlab.Project, *gitlab.Response, error) {
+	return w.client.Search.Projects(project, opts, gitlab.WithContext(ctx))
+}
+
+/*
+project string: The full path or ID of the project
+*/
+
+func (w *gitlabClientWrapper) GetProject(ctx context.Context, project string) (*gitlab.Project, *gitlab.Response, error) {
+	return w.client.Projects.GetProject(project, nil, gitlab.WithContext(ctx))
+}
+
+func (w *gitlabClientWrapper) GetRawFile(ctx context.Context, project string, path string, opts *gitlab.GetRawFileOptions) ([]byte, *gitlab.Response, error) {
+	return w.client.RepositoryFiles.GetRawFile(project, path, opts, gitlab.WithContext(ctx))
+}
+
+func (w *gitlabClientWrapper) ListRepositoryTree(ctx context.Context, project string, opts *gitlab.ListTreeOptions) ([]*gitlab.TreeNode, *gitlab.Response, error) {
+	return w.client.Repositories.ListTree(project, opts, gitlab.WithContext(ctx))
+}
+
+func (w *gitlabClientWrapper) GetProjectLanguages(ctx context.Context, project string) (*gitlab.ProjectLanguages, *gitlab.Response, error) {
+	return w.client.Projects.GetProjectLanguages(project, gitlab.WithContext(ctx))
+}
+
+func (w *gitlabClientWrapper) ListBranches(ctx context.Context, project string, opts *gitlab.ListBranchesOptions) ([]*gitlab.Branch, *gitlab.Response, error) {
+	return w.client.Branches.ListBranches(project, opts, gitlab.WithContext(ctx))
+}
+
+/*
+sha string: The branch, tag or commit SHA
+*/
+
+func (w *gitlabClientWrapper) GetCommit(ctx context.Context, project string, sha string) (*gitlab.Commit, *gitlab.Response, error) {
+	return w.client.Commits.GetCommit(project, sha, nil, gitlab.WithContext(ctx))
 }
 
-func (w *gitlabClientWrapper) GetRawFile(project string, path string, opts *gitlab.GetRawFileOptions) ([]byte, *gitlab.Response, error) {
-	return w.client.RepositoryFiles.GetRawFile(project, path, opts)
+func (w *gitlabClientWrapper) StreamArchive(ctx context.Context, project string, writer io.Writer, opts *gitlab.ArchiveOptions) (*gitlab.Response, error) {
+	return w.client.Repositories.StreamArchive(project, writer, opts, gitlab.WithContext(ctx))
 }
 
-func (w *gitlabClientWrapper) ListRepositoryTree(project string, opts *gitlab.ListTreeOptions) ([]*gitlab.TreeNode, *gitlab.Response, error) {
-	return w.client.Repositories.ListTree(project, opts)
+func (w *gitlabClientWrapper) Compare(ctx context.Context, project string, opts *gitlab.CompareOptions) (*gitlab.Compare, *gitlab.Response, error) {
+	return w.client.Repositories.Compare(project, opts, gitlab.WithContext(ctx))
 }
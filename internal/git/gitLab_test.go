@@ -3,58 +3,88 @@
 package git
 
 import (
+	"context"
 	"errors"
 	"github.com/stretchr/testify/mock"
+	"io"
+	"net/http"
 	"strconv"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"gitlab.com/gitlab-org/api/client-go"
 )
 
 func TestNewGitlab(t *testing.T) {
 	token := "test-token"
 	host := "http://example.com"
-	g, err := NewGitLab(token, host)
+	g, err := NewGitLab(token, host, nil)
 	assert.NoError(t, err)
 	assert.NotNil(t, g)
 }
 
 func TestGetProjectByName(t *testing.T) {
+	notFound := &gitlab.Response{Response: &http.Response{StatusCode: http.StatusNotFound}}
 	tests := []struct {
 		name            string
-		projctName      string
-		mockResponse    []*gitlab.Project
-		mockError       error
+		projectName     string
+		setup           func(clientMock *GitLabAPIMock)
 		expectedError   error
 		expectedProject Project
 	}{
 		{
-			name:       "Valid project",
-			projctName: "test",
-			mockResponse: []*gitlab.Project{
-				{ID: 1, PathWithNamespace: "test/project"},
+			name:        "Full path",
+			projectName: "group/sub/project",
+			setup: func(clientMock *GitLabAPIMock) {
+				clientMock.On("GetProject", mock.Anything, "group/sub/project").
+					Return(&gitlab.Project{ID: 1, PathWithNamespace: "group/sub/project"}, nil, nil)
 			},
-			mockError:     nil,
-			expectedError: nil,
-			expectedProject: Project{
-				ID:                1,
-				PathWithNamespace: "test/project",
+			expectedProject: Project{ID: 1, PathWithNamespace: "group/sub/project"},
+		},
+		{
+			name:        "Numeric ID",
+			projectName: "1",
+			setup: func(clientMock *GitLabAPIMock) {
+				clientMock.On("GetProject", mock.Anything, "1").
+					Return(&gitlab.Project{ID: 1, PathWithNamespace: "group/sub/project"}, nil, nil)
+			},
+			expectedProject: Project{ID: 1, PathWithNamespace: "group/sub/project"},
+		},
+		{
+			name:        "Not found lists close candidates",
+			projectName: "group/project",
+			setup: func(clientMock *GitLabAPIMock) {
+				clientMock.On("GetProject", mock.Anything, "group/project").
+					Return(nil, notFound, errors.New("404 Not Found"))
+				clientMock.On("SearchProjects", mock.Anything, "project", mock.Anything).
+					Return([]*gitlab.Project{
+						{PathWithNamespace: "other/project-legacy"},
+						{PathWithNamespace: "group/sub/project"},
+					}, nil, nil)
 			},
+			expectedError:   errors.New("project not found: group/project (close candidates: group/sub/project, other/project-legacy)"),
+			expectedProject: Project{},
 		},
 		{
-			name:            "No projects found",
-			projctName:      "not-found-test",
-			mockResponse:    []*gitlab.Project{},
-			mockError:       nil,
+			name:        "Not found without candidates",
+			projectName: "not-found-test",
+			setup: func(clientMock *GitLabAPIMock) {
+				clientMock.On("GetProject", mock.Anything, "not-found-test").
+					Return(nil, notFound, errors.New("404 Not Found"))
+				clientMock.On("SearchProjects", mock.Anything, "not-found-test", mock.Anything).
+					Return([]*gitlab.Project{}, nil, nil)
+			},
 			expectedError:   errors.New("project not found: not-found-test"),
 			expectedProject: Project{},
 		},
 		{
-			name:            "Error from API",
-			projctName:      "test",
-			mockResponse:    nil,
-			mockError:       errors.New("API error"),
+			name:        "Error from API",
+			projectName: "test",
+			setup: func(clientMock *GitLabAPIMock) {
+				clientMock.On("GetProject", mock.Anything, "test").
+					Return(nil, nil, errors.New("API error"))
+			},
 			expectedError:   errors.New("API error"),
 			expectedProject: Project{},
 		},
@@ -63,18 +93,13 @@ func TestGetProjectByName(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			clientMock := NewGitLabAPIMock(t)
-			clientMock.On("SearchProjects", tt.projctName, &gitlab.SearchOptions{
-				ListOptions: gitlab.ListOptions{
-					Page:    1,
-					PerPage: 1,
-				},
-			}).Return(tt.mockResponse, nil, tt.mockError)
+			tt.setup(clientMock)
 
 			g := GitLab{
 				client: clientMock,
 			}
 
-			project, err := g.GetProjectByName(tt.projctName)
+			project, err := g.GetProjectByName(context.Background(), tt.projectName)
 			assert.Equal(t, tt.expectedError, err)
 			assert.Equal(t, tt.expectedProject, project)
 		})
@@ -122,13 +147,13 @@ func TestGetRawFile(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			clientMock := NewGitLabAPIMock(t)
-			clientMock.On("GetRawFile", strconv.Itoa(tt.project.ID), tt.path, mock.Anything).Return(tt.mockResponse, nil, tt.mockError)
+			clientMock.On("GetRawFile", mock.Anything, strconv.Itoa(tt.project.ID), tt.path, &gitlab.GetRawFileOptions{Ref: gitlab.Ptr("abc123")}).Return(tt.mockResponse, nil, tt.mockError)
 
 			g := GitLab{
 				client: clientMock,
 			}
 
-			result, err := g.GetRawFile(tt.project, tt.path)
+			result, err := g.GetRawFile(context.Background(), tt.project, "abc123", tt.path)
 			assert.Equal(t, tt.expectedError, err)
 			assert.Equal(t, tt.expectedResult, result)
 		})
@@ -140,6 +165,7 @@ func TestListRepositoryTree(t *testing.T) {
 		name           string
 		mockProject    Project
 		mockResponse   []*gitlab.TreeNode
+		noResponse     bool
 		mockError      error
 		expectedError  error
 		expectedResult []TreeNode
@@ -152,14 +178,14 @@ func TestListRepositoryTree(t *testing.T) {
 				PathWithNamespace: "org/p-1",
 			},
 			mockResponse: []*gitlab.TreeNode{
-				{Path: "file1", Type: "blob"},
-				{Path: "dir1", Type: "tree"},
+				{ID: "a1b2c3", Path: "file1", Type: "blob"},
+				{ID: "d4e5f6", Path: "dir1", Type: "tree"},
 			},
 			mockError:     nil,
 			expectedError: nil,
 			expectedResult: []TreeNode{
-				{Path: "file1", Type: "blob", IsTree: false},
-				{Path: "dir1", Type: "tree", IsTree: true},
+				{Path: "file1", Type: "blob", IsTree: false, SHA: "a1b2c3"},
+				{Path: "dir1", Type: "tree", IsTree: true, SHA: "d4e5f6"},
 			},
 		},
 		{
@@ -174,24 +200,44 @@ func TestListRepositoryTree(t *testing.T) {
 			expectedError:  errors.New("API error"),
 			expectedResult: nil,
 		},
+		{
+			name: "Tree without a response",
+			mockProject: Project{
+				Name:              "p-3",
+				ID:                3,
+				PathWithNamespace: "org/p-3",
+			},
+			mockResponse: []*gitlab.TreeNode{
+				{ID: "a1b2c3", Path: "file1", Type: "blob"},
+			},
+			noResponse: true,
+			expectedResult: []TreeNode{
+				{Path: "file1", Type: "blob", IsTree: false, SHA: "a1b2c3"},
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			resp := &gitlab.Response{NextPage: 0}
+			if tt.noResponse {
+				resp = nil
+			}
 			clientMock := NewGitLabAPIMock(t)
-			clientMock.On("ListRepositoryTree", tt.mockProject.PathWithNamespace, &gitlab.ListTreeOptions{
+			clientMock.On("ListRepositoryTree", mock.Anything, tt.mockProject.PathWithNamespace, &gitlab.ListTreeOptions{
 				Recursive: gitlab.Bool(true),
+				Ref:       gitlab.Ptr("abc123"),
 				ListOptions: gitlab.ListOptions{
 					PerPage: 100,
 					Page:    1,
 				},
-			}).Return(tt.mockResponse, &gitlab.Response{NextPage: 0}, tt.mockError)
+			}).Return(tt.mockResponse, resp, tt.mockError)
 
 			g := GitLab{
 				client: clientMock,
 			}
 
-			result, err := g.ListRepositoryTree(tt.mockProject)
+			result, err := g.ListRepositoryTree(context.Background(), tt.mockProject, "abc123")
 			assert.Equal(t, tt.expectedError, err)
 			assert.Equal(t, tt.expectedResult, result)
 		})
@@ -203,6 +249,7 @@ func TestListGroupProjects(t *testing.T) {
 		name           string
 		group          string
 		mockResponse   []*gitlab.Project
+		noResponse     bool
 		mockError      error
 		expectedError  error
 		expectedResult []Project
@@ -229,26 +276,168 @@ func TestListGroupProjects(t *testing.T) {
 			expectedError:  errors.New("API error"),
 			expectedResult: nil,
 		},
+		{
+			name:  "Projects without a response",
+			group: "test-group",
+			mockResponse: []*gitlab.Project{
+				{ID: 1, PathWithNamespace: "test-group/project1"},
+			},
+			noResponse: true,
+			expectedResult: []Project{
+				{ID: 1, PathWithNamespace: "test-group/project1"},
+			},
+		},
+		{
+			name:           "Error without a response",
+			group:          "test-group",
+			noResponse:     true,
+			mockError:      errors.New("connection refused"),
+			expectedError:  errors.New("connection refused"),
+			expectedResult: nil,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			resp := &gitlab.Response{NextPage: 0}
+			if tt.noResponse {
+				resp = nil
+			}
 			clientMock := NewGitLabAPIMock(t)
-			clientMock.On("ListGroupProjects", tt.group, &gitlab.ListGroupProjectsOptions{
+			clientMock.On("ListGroupProjects", mock.Anything, tt.group, &gitlab.ListGroupProjectsOptions{
 				IncludeSubGroups: gitlab.Bool(true),
 				ListOptions: gitlab.ListOptions{
 					PerPage: 100,
 					Page:    1,
 				},
-			}).Return(tt.mockResponse, &gitlab.Response{NextPage: 0}, tt.mockError)
+			}).Return(tt.mockResponse, resp, tt.mockError)
 
 			g := GitLab{
 				client: clientMock,
 			}
 
-			result, err := g.ListGroupProjects(tt.group)
+			result, err := g.ListGroupProjects(context.Background(), tt.group, ProjectFilter{})
 			assert.Equal(t, tt.expectedError, err)
 			assert.Equal(t, tt.expectedResult, result)
 		})
 	}
 }
+
+func TestListGroupProjectsLanguage(t *testing.T) {
+	clientMock := NewGitLabAPIMock(t)
+	clientMock.On("ListGroupProjects", mock.Anything, "test-group", mock.Anything).Return([]*gitlab.Project{
+		{ID: 1, PathWithNamespace: "test-group/go"},
+		{ID: 2, PathWithNamespace: "test-group/python"},
+		{ID: 3, PathWithNamespace: "test-group/archived", Archived: true},
+	}, &gitlab.Response{NextPage: 0}, nil)
+	clientMock.On("GetProjectLanguages", mock.Anything, "1").Return(&gitlab.ProjectLanguages{"Go": 80, "Shell": 20}, nil, nil)
+	clientMock.On("GetProjectLanguages", mock.Anything, "2").Return(&gitlab.ProjectLanguages{"Python": 100}, nil, nil)
+
+	g := GitLab{
+		client: clientMock,
+	}
+
+	result, err := g.ListGroupProjects(context.Background(), "test-group", ProjectFilter{Archived: Exclude, Languages: []string{"go"}})
+	assert.NoError(t, err)
+	assert.Len(t, result, 1)
+	assert.Equal(t, "test-group/go", result[0].PathWithNamespace)
+	assert.Equal(t, "Go", result[0].Language)
+	// the language of archived projects is never looked up
+	clientMock.AssertNumberOfCalls(t, "GetProjectLanguages", 2)
+}
+
+func TestResolveRef(t *testing.T) {
+	project := Project{ID: 1, PathWithNamespace: "group/project"}
+	clientMock := NewGitLabAPIMock(t)
+	clientMock.On("GetCommit", mock.Anything, "1", "v1.0.0").Return(&gitlab.Commit{ID: "abc123"}, nil, nil)
+	clientMock.On("GetCommit", mock.Anything, "1", "missing").Return(nil, nil, errors.New("404 Not Found"))
+
+	g := GitLab{
+		client: clientMock,
+	}
+
+	ref, err := g.ResolveRef(context.Background(), project, "v1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, Ref{Name: "v1.0.0", SHA: "abc123"}, ref)
+
+	_, err = g.ResolveRef(context.Background(), project, "missing")
+	assert.EqualError(t, err, "could not resolve ref missing of group/project: 404 Not Found")
+}
+
+func TestListBranches(t *testing.T) {
+	project := Project{ID: 1, PathWithNamespace: "group/project"}
+	clientMock := NewGitLabAPIMock(t)
+	clientMock.On("ListBranches", mock.Anything, "1", mock.Anything).Return([]*gitlab.Branch{
+		{Name: "main", Commit: &gitlab.Commit{ID: "abc123"}},
+		{Name: "env/prod", Commit: &gitlab.Commit{ID: "def456"}},
+	}, &gitlab.Response{NextPage: 0}, nil)
+
+	g := GitLab{
+		client: clientMock,
+	}
+
+	refs, err := g.ListBranches(context.Background(), project)
+	assert.NoError(t, err)
+	assert.Equal(t, []Ref{{Name: "main", SHA: "abc123"}, {Name: "env/prod", SHA: "def456"}}, refs)
+}
+
+func TestGetArchive(t *testing.T) {
+	project := Project{ID: 1, PathWithNamespace: "group/project"}
+	clientMock := NewGitLabAPIMock(t)
+	clientMock.On("StreamArchive", mock.Anything, "1", mock.Anything, &gitlab.ArchiveOptions{Format: gitlab.Ptr("tar.gz"), SHA: gitlab.Ptr("abc123")}).
+		Run(func(args mock.Arguments) {
+			_, _ = args.Get(2).(io.Writer).Write([]byte("archive"))
+		}).
+		Return(nil, nil)
+	clientMock.On("StreamArchive", mock.Anything, "1", mock.Anything, &gitlab.ArchiveOptions{Format: gitlab.Ptr("tar.gz"), SHA: gitlab.Ptr("missing")}).
+		Return(nil, errors.New("404 Not Found"))
+
+	g := GitLab{
+		client: clientMock,
+	}
+
+	archive, err := g.GetArchive(context.Background(), project, "abc123")
+	require.NoError(t, err)
+	content, err := io.ReadAll(archive)
+	assert.NoError(t, err)
+	assert.Equal(t, "archive", string(content))
+
+	archive, err = g.GetArchive(context.Background(), project, "missing")
+	require.NoError(t, err)
+	_, err = io.ReadAll(archive)
+	assert.EqualError(t, err, "404 Not Found")
+}
+
+func TestCompareCommits(t *testing.T) {
+	project := Project{ID: 1, PathWithNamespace: "group/project"}
+	clientMock := NewGitLabAPIMock(t)
+	clientMock.On("Compare", mock.Anything, "1", &gitlab.CompareOptions{
+		From:     gitlab.Ptr("abc123"),
+		To:       gitlab.Ptr("def456"),
+		Straight: gitlab.Ptr(true),
+	}).Return(&gitlab.Compare{Diffs: []*gitlab.Diff{
+		{NewPath: "added.yaml", OldPath: "added.yaml", NewFile: true},
+		{NewPath: "changed.yaml", OldPath: "changed.yaml"},
+		{NewPath: "new.yaml", OldPath: "old.yaml", RenamedFile: true},
+		{NewPath: "removed.yaml", OldPath: "removed.yaml", DeletedFile: true},
+	}}, nil, nil)
+	clientMock.On("Compare", mock.Anything, "1", mock.MatchedBy(func(opts *gitlab.CompareOptions) bool {
+		return *opts.To == "huge"
+	})).Return(&gitlab.Compare{CompareTimeout: true}, nil, nil)
+
+	g := GitLab{
+		client: clientMock,
+	}
+
+	changes, err := g.CompareCommits(context.Background(), project, "abc123", "def456")
+	assert.NoError(t, err)
+	assert.Equal(t, []FileChange{
+		{Path: "added.yaml", Status: ChangeAdded},
+		{Path: "changed.yaml", Status: ChangeModified},
+		{Path: "new.yaml", PreviousPath: "old.yaml", Status: ChangeRenamed},
+		{Path: "removed.yaml", Status: ChangeRemoved},
+	}, changes)
+
+	_, err = g.CompareCommits(context.Background(), project, "abc123", "huge")
+	assert.EqualError(t, err, "could not compare abc123..huge of group/project: the comparison timed out")
+}
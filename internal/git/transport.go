@@ -0,0 +1,333 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// secondaryRateLimitDelay is the wait after a GitHub secondary rate limit without a Retry-After header
+const secondaryRateLimitDelay = time.Minute
+
+// the rate limit headers of GitHub (X-RateLimit-*) and GitLab (RateLimit-*)
+var (
+	remainingHeaders = []string{"X-RateLimit-Remaining", "RateLimit-Remaining"}
+	resetHeaders     = []string{"X-RateLimit-Reset", "RateLimit-Reset"}
+)
+
+type TransportOptions struct {
+	// RequestsPerSecond is the rate of the token bucket, 0 disables the throttling
+	RequestsPerSecond float64
+	// Burst is the number of requests sent at once before the rate applies
+	Burst int
+	// MaxRetries of a request failing with a rate limit, a 5xx status or a network error
+	MaxRetries int
+	// BaseDelay is the backoff of the first retry, it doubles with every retry up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// RequestTimeout limits each attempt of a request including the read of its body, 0 disables it.
+	// The backoff and the rate limit waits between the attempts do not count towards it.
+	RequestTimeout time.Duration
+}
+
+func DefaultTransportOptions() TransportOptions {
+	return TransportOptions{
+		RequestsPerSecond: 10,
+		Burst:             10,
+		MaxRetries:        5,
+		BaseDelay:         time.Second,
+		MaxDelay:          time.Minute,
+	}
+}
+
+/*
+RateLimitTransport throttles the requests of an API client with a token bucket and retries
+rate limited (429, GitHub secondary rate limits), unavailable (5xx) and failed requests with an
+exponential backoff with jitter. Retry-After and the rate limit reset headers are honored, a
+reached rate limit pauses all requests of the transport until the limit is reset.
+Each attempt is limited to the request timeout, the waits between the attempts only end with the context of the request.
+*/
+type RateLimitTransport struct {
+	base    http.RoundTripper
+	load    func() TransportOptions
+	once    sync.Once
+	options TransportOptions
+	limiter *rate.Limiter
+
+	mu sync.Mutex
+	// pausedUntil delays all requests after a rate limit was reached
+	pausedUntil time.Time
+
+	now    func() time.Time
+	jitter func(time.Duration) time.Duration
+}
+
+/*
+NewRateLimitTransport wraps the base transport (http.DefaultTransport when nil).
+The options are loaded on the first request, because the clients are created before the flags are parsed.
+*/
+func NewRateLimitTransport(base http.RoundTripper, options func() TransportOptions) *RateLimitTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &RateLimitTransport{
+		base:   base,
+		load:   options,
+		now:    time.Now,
+		jitter: equalJitter,
+	}
+}
+
+func (t *RateLimitTransport) init() {
+	t.once.Do(func() {
+		t.options = t.load()
+		limit := rate.Inf
+		if t.options.RequestsPerSecond > 0 {
+			limit = rate.Limit(t.options.RequestsPerSecond)
+		}
+		t.limiter = rate.NewLimiter(limit, max(t.options.Burst, 1))
+	})
+}
+
+func (t *RateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.init()
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		if err := t.waitForReset(ctx); err != nil {
+			return nil, err
+		}
+		if err := t.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+		attemptReq, err := rewind(req, attempt)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := t.roundTripAttempt(attemptReq)
+		delay, reason := t.retryDelay(ctx, resp, err, attempt)
+		if reason == "" || attempt >= t.options.MaxRetries || !isRewindable(req) {
+			return resp, err
+		}
+		if resp != nil {
+			// the connection is only reused when the body was read to the end
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		slog.Warn(fmt.Sprintf("%s %s failed with %s - retrying in %s (%d/%d)", req.Method, req.URL.Redacted(), reason, delay.Round(time.Millisecond), attempt+1, t.options.MaxRetries))
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// roundTripAttempt sends a single attempt of a request, the request timeout ends when the body of the response is closed
+func (t *RateLimitTransport) roundTripAttempt(req *http.Request) (*http.Response, error) {
+	if t.options.RequestTimeout <= 0 || req.Context().Value(noRequestTimeoutKey{}) != nil {
+		return t.base.RoundTrip(req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), t.options.RequestTimeout)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelBody cancels the context of an attempt when the body of its response is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+type noRequestTimeoutKey struct{}
+
+// withoutRequestTimeout exempts the requests of the context from the request timeout, e.g. the download of an archive
+func withoutRequestTimeout(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noRequestTimeoutKey{}, true)
+}
+
+// retryDelay returns the delay before the next attempt and the reason of the retry, the reason is empty when the request is done
+func (t *RateLimitTransport) retryDelay(ctx context.Context, resp *http.Response, err error, attempt int) (time.Duration, string) {
+	if err != nil {
+		if ctx.Err() != nil {
+			return 0, ""
+		}
+		return t.backoff(attempt), err.Error()
+	}
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests || isSecondaryRateLimit(resp):
+		delay := t.rateLimitWait(resp)
+		if delay <= 0 {
+			delay = t.backoff(attempt)
+			if resp.StatusCode == http.StatusForbidden && resp.Header.Get("Retry-After") == "" {
+				delay = max(delay, secondaryRateLimitDelay)
+			}
+		}
+		t.pause(delay)
+		return delay, resp.Status
+	case resp.StatusCode == http.StatusInternalServerError, resp.StatusCode == http.StatusBadGateway,
+		resp.StatusCode == http.StatusServiceUnavailable, resp.StatusCode == http.StatusGatewayTimeout:
+		if delay := retryAfter(resp, t.now()); delay > 0 {
+			return delay, resp.Status
+		}
+		return t.backoff(attempt), resp.Status
+	}
+	// the last request of the rate limit succeeded, the next one would fail
+	if delay := t.rateLimitWait(resp); delay > 0 {
+		t.pause(delay)
+	}
+	return 0, ""
+}
+
+// backoff doubles the base delay with every attempt, the jitter spreads the retries of concurrent requests
+func (t *RateLimitTransport) backoff(attempt int) time.Duration {
+	delay := t.options.BaseDelay
+	for i := 0; i < attempt && delay < t.options.MaxDelay; i++ {
+		delay *= 2
+	}
+	if t.options.MaxDelay > 0 {
+		delay = min(delay, t.options.MaxDelay)
+	}
+	return t.jitter(delay)
+}
+
+// rateLimitWait returns the wait until the rate limit of a response is reset, 0 when requests are left
+func (t *RateLimitTransport) rateLimitWait(resp *http.Response) time.Duration {
+	if delay := retryAfter(resp, t.now()); delay > 0 {
+		return delay
+	}
+	if headerValue(resp.Header, remainingHeaders) != "0" {
+		return 0
+	}
+	reset, err := strconv.ParseInt(headerValue(resp.Header, resetHeaders), 10, 64)
+	if err != nil {
+		return 0
+	}
+	// the reset is given in seconds, one more second avoids hitting the limit right before it is reset
+	return time.Unix(reset, 0).Sub(t.now()) + time.Second
+}
+
+// pause delays all requests of the transport
+func (t *RateLimitTransport) pause(delay time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	until := t.now().Add(delay)
+	if until.After(t.pausedUntil) {
+		t.pausedUntil = until
+		slog.Warn(fmt.Sprintf("rate limit reached, pausing requests until %s", until.Format(time.RFC3339)))
+	}
+}
+
+func (t *RateLimitTransport) waitForReset(ctx context.Context) error {
+	t.mu.Lock()
+	delay := t.pausedUntil.Sub(t.now())
+	t.mu.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+	return sleep(ctx, delay)
+}
+
+/*
+isSecondaryRateLimit detects the secondary rate limits of GitHub, which are reported as 403.
+The body is read to tell them apart from a missing permission and is restored afterward.
+*/
+func isSecondaryRateLimit(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusForbidden {
+		return false
+	}
+	if resp.Header.Get("Retry-After") != "" || headerValue(resp.Header, remainingHeaders) == "0" {
+		return true
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+	return err == nil && strings.Contains(strings.ToLower(string(body)), "rate limit")
+}
+
+// retryAfter parses the Retry-After header, which is given in seconds or as HTTP date
+func retryAfter(resp *http.Response, now time.Time) time.Duration {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return date.Sub(now)
+	}
+	return 0
+}
+
+func headerValue(header http.Header, names []string) string {
+	for _, name := range names {
+		if value := header.Get(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// isRewindable reports if the body of a request can be sent again
+func isRewindable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// rewind returns the request of an attempt, retries get a copy with a fresh body
+func rewind(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 0 || req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+	if req.GetBody == nil {
+		return nil, errors.New("request body can not be sent again")
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	clone := req.Clone(req.Context())
+	clone.Body = body
+	return clone, nil
+}
+
+// equalJitter keeps half of the delay and randomizes the other half
+func equalJitter(delay time.Duration) time.Duration {
+	if delay <= 1 {
+		return delay
+	}
+	half := delay / 2
+	return half + rand.N(delay-half)
+}
+
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
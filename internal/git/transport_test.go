@@ -0,0 +1,293 @@
+//go:build unit
+
+package git
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testTransport(maxRetries int) *RateLimitTransport {
+	transport := NewRateLimitTransport(nil, func() TransportOptions {
+		return TransportOptions{MaxRetries: maxRetries, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+	})
+	transport.jitter = func(delay time.Duration) time.Duration { return delay }
+	return transport
+}
+
+// testServer answers the requests with the handlers in order, the last handler answers all further requests
+func testServer(t *testing.T, handlers ...http.HandlerFunc) (*httptest.Server, *atomic.Int32) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i := int(requests.Add(1)) - 1
+		handlers[min(i, len(handlers)-1)](w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func status(code int, headers ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i+1 < len(headers); i += 2 {
+			w.Header().Set(headers[i], headers[i+1])
+		}
+		w.WriteHeader(code)
+		_, _ = io.WriteString(w, http.StatusText(code))
+	}
+}
+
+func TestRateLimitTransportRetries(t *testing.T) {
+	tests := []struct {
+		name             string
+		handlers         []http.HandlerFunc
+		maxRetries       int
+		expectedStatus   int
+		expectedRequests int32
+	}{
+		{
+			name:             "Success",
+			handlers:         []http.HandlerFunc{status(http.StatusOK)},
+			maxRetries:       3,
+			expectedStatus:   http.StatusOK,
+			expectedRequests: 1,
+		},
+		{
+			name:             "Retry 5xx",
+			handlers:         []http.HandlerFunc{status(http.StatusBadGateway), status(http.StatusServiceUnavailable), status(http.StatusOK)},
+			maxRetries:       3,
+			expectedStatus:   http.StatusOK,
+			expectedRequests: 3,
+		},
+		{
+			name:             "Retry 429 with Retry-After",
+			handlers:         []http.HandlerFunc{status(http.StatusTooManyRequests, "Retry-After", "0"), status(http.StatusOK)},
+			maxRetries:       3,
+			expectedStatus:   http.StatusOK,
+			expectedRequests: 2,
+		},
+		{
+			name: "Retry secondary rate limit",
+			handlers: []http.HandlerFunc{
+				status(http.StatusForbidden, "Retry-After", "0"),
+				status(http.StatusOK),
+			},
+			maxRetries:       3,
+			expectedStatus:   http.StatusOK,
+			expectedRequests: 2,
+		},
+		{
+			name:             "No retry of a missing permission",
+			handlers:         []http.HandlerFunc{status(http.StatusForbidden)},
+			maxRetries:       3,
+			expectedStatus:   http.StatusForbidden,
+			expectedRequests: 1,
+		},
+		{
+			name:             "No retry of a client error",
+			handlers:         []http.HandlerFunc{status(http.StatusNotFound)},
+			maxRetries:       3,
+			expectedStatus:   http.StatusNotFound,
+			expectedRequests: 1,
+		},
+		{
+			name:             "Retries exhausted",
+			handlers:         []http.HandlerFunc{status(http.StatusServiceUnavailable)},
+			maxRetries:       2,
+			expectedStatus:   http.StatusServiceUnavailable,
+			expectedRequests: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := testServer(t, tt.handlers...)
+			client := &http.Client{Transport: testTransport(tt.maxRetries)}
+
+			resp, err := client.Get(server.URL)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			assert.Equal(t, http.StatusText(tt.expectedStatus), string(body))
+			assert.Equal(t, tt.expectedRequests, requests.Load())
+		})
+	}
+}
+
+func TestRateLimitTransportRetriesBody(t *testing.T) {
+	var bodies []string
+	server, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		status(http.StatusServiceUnavailable)(w, r)
+	}, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		status(http.StatusOK)(w, r)
+	})
+	client := &http.Client{Transport: testTransport(3)}
+
+	resp, err := client.Post(server.URL, "text/plain", strings.NewReader("payload"))
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{"payload", "payload"}, bodies)
+}
+
+func TestRateLimitTransportSecondaryRateLimitBody(t *testing.T) {
+	secondary := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = io.WriteString(w, `{"message": "You have exceeded a secondary rate limit."}`)
+	}
+	server, requests := testServer(t, secondary)
+	transport := testTransport(0)
+	client := &http.Client{Transport: transport}
+
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	// without retries the response is returned, its body is still readable
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Contains(t, string(body), "secondary rate limit")
+	assert.Equal(t, int32(1), requests.Load())
+	// without a Retry-After header the requests are paused for a minute
+	assert.WithinDuration(t, time.Now().Add(secondaryRateLimitDelay), transport.pausedUntil, 5*time.Second)
+}
+
+func TestRateLimitTransportWaitsForReset(t *testing.T) {
+	reset := time.Now().Add(time.Hour)
+	server, _ := testServer(t, status(http.StatusOK, "X-RateLimit-Remaining", "0", "X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10)))
+	transport := testTransport(3)
+	client := &http.Client{Transport: transport}
+
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// the rate limit is used up, the next request waits until the reset
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	_, err = client.Do(req)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestRateLimitTransportRequestTimeout(t *testing.T) {
+	slow := func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+		status(http.StatusOK)(w, r)
+	}
+	tests := []struct {
+		name             string
+		handlers         []http.HandlerFunc
+		noTimeout        bool
+		expectedRequests int32
+	}{
+		{
+			name:             "Timed out attempt is retried",
+			handlers:         []http.HandlerFunc{slow, status(http.StatusOK)},
+			expectedRequests: 2,
+		},
+		{
+			name:             "Wait for Retry-After does not count towards the timeout",
+			handlers:         []http.HandlerFunc{status(http.StatusTooManyRequests, "Retry-After", "1"), status(http.StatusOK)},
+			expectedRequests: 2,
+		},
+		{
+			name:             "Request without timeout",
+			handlers:         []http.HandlerFunc{slow},
+			noTimeout:        true,
+			expectedRequests: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := testServer(t, tt.handlers...)
+			transport := testTransport(3)
+			transport.init()
+			transport.options.RequestTimeout = 200 * time.Millisecond
+			client := &http.Client{Transport: transport}
+
+			ctx := context.Background()
+			if tt.noTimeout {
+				ctx = withoutRequestTimeout(ctx)
+			}
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+			require.NoError(t, err)
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, http.StatusText(http.StatusOK), string(body))
+			assert.Equal(t, tt.expectedRequests, requests.Load())
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		value    string
+		expected time.Duration
+	}{
+		{name: "Seconds", value: "30", expected: 30 * time.Second},
+		{name: "HTTP date", value: "Wed, 31 Jan 2024 12:01:00 GMT", expected: time.Minute},
+		{name: "Missing", value: "", expected: 0},
+		{name: "Invalid", value: "soon", expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}}
+			if tt.value != "" {
+				resp.Header.Set("Retry-After", tt.value)
+			}
+			assert.Equal(t, tt.expected, retryAfter(resp, now))
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	transport := testTransport(10)
+	transport.init()
+	transport.options.BaseDelay = time.Second
+	transport.options.MaxDelay = 10 * time.Second
+
+	var delays []time.Duration
+	for attempt := 0; attempt < 6; attempt++ {
+		delays = append(delays, transport.backoff(attempt))
+	}
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}, delays)
+
+	for i := 0; i < 100; i++ {
+		delay := equalJitter(time.Second)
+		assert.GreaterOrEqual(t, delay, 500*time.Millisecond)
+		assert.Less(t, delay, time.Second)
+	}
+}
@@ -2,18 +2,54 @@ package git
 
 import (
 	"strings"
+	"time"
 )
 
 type TreeNode struct {
 	IsTree bool // is folder
 	Path   string
 	Type   string
+	SHA    string // git object ID, the blob SHA of a file changes with its content only
+}
+
+type ChangeStatus string
+
+const (
+	ChangeAdded    ChangeStatus = "added"
+	ChangeModified ChangeStatus = "modified"
+	ChangeRenamed  ChangeStatus = "renamed"
+	ChangeRemoved  ChangeStatus = "removed"
+)
+
+// FileChange is a file changed between two commits
+type FileChange struct {
+	Path string
+	// PreviousPath is only set for renamed files
+	PreviousPath string
+	Status       ChangeStatus
+	// SHA is the blob SHA of the new content, it is only known for GitHub
+	SHA string
+}
+
+// Ref is a branch, tag or commit resolved to the SHA of its commit
+type Ref struct {
+	Name string
+	SHA  string
 }
 
 type Project struct {
 	Name              string
 	ID                int
-	PathWithNamespace string // Github html_url
+	PathWithNamespace string // Github full name (owner/repo)
+	WebURL            string
+	DefaultBranch     string
+	Archived          bool
+	Fork              bool
+	Visibility        string
+	Topics            []string
+	// Language is the primary language, it is only known for GitHub projects without an extra API call
+	Language string
+	PushedAt time.Time
 }
 
 func (p *Project) Owner() string {
@@ -0,0 +1,257 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the separator of the commit header fields, it can not be part of names or emails
+const fieldSeparator = "\x00"
+
+// commitFormat prints a commit header line: marker, SHA, author name, author email and author date
+const commitFormat = "--format=%x00commit%x00%H%x00%an%x00%ae%x00%aI"
+
+type Commit struct {
+	SHA    string    `json:"sha" yaml:"sha"`
+	Author string    `json:"author" yaml:"author"`
+	Email  string    `json:"email" yaml:"email"`
+	Date   time.Time `json:"date" yaml:"date"`
+}
+
+// Line is an added line of a diff with its line number in the new version of the file
+type Line struct {
+	Number int
+	Text   string
+}
+
+// Change holds the lines a commit added to a file
+type Change struct {
+	Commit Commit
+	Path   string
+	Lines  []Line
+}
+
+// Content returns the added lines at their line numbers, all other lines are empty
+func (c Change) Content() string {
+	if len(c.Lines) == 0 {
+		return ""
+	}
+	lines := make([]string, c.Lines[len(c.Lines)-1].Number)
+	for _, line := range c.Lines {
+		lines[line.Number-1] = line.Text
+	}
+	return strings.Join(lines, "\n")
+}
+
+type LogOptions struct {
+	// All walks the commits of all refs instead of HEAD
+	All bool
+	// Since limits the commits to a date, passed to git log --since (e.g. 2024-01-31 or "2 weeks ago")
+	Since    string
+	MaxCount int
+}
+
+// Repository reads the history of a local clone with the git binary, it never accesses the network
+type Repository struct {
+	Dir string
+}
+
+// Open returns the repository containing the directory
+func Open(ctx context.Context, dir string) (*Repository, error) {
+	out, err := run(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a git repository: %w", dir, err)
+	}
+	return &Repository{Dir: strings.TrimSpace(string(out))}, nil
+}
+
+/*
+Walk calls fn with the added lines of every file changed by the commits of the log, newest commit first.
+Binary files, deleted files and merge commits are skipped, renames are reported with their added lines only.
+*/
+func (r *Repository) Walk(ctx context.Context, options LogOptions, fn func(Change) error) error {
+	// the prefixes are set explicitly, diff.noprefix or diff.mnemonicPrefix would change the paths of the +++ headers
+	args := []string{"-c", "core.quotePath=false", "log", "-p", "-U0", "--no-color", "--no-ext-diff", "--src-prefix=a/", "--dst-prefix=b/", "--diff-filter=d", commitFormat}
+	if options.All {
+		args = append(args, "--all")
+	}
+	if options.Since != "" {
+		args = append(args, "--since="+options.Since)
+	}
+	if options.MaxCount > 0 {
+		args = append(args, fmt.Sprintf("--max-count=%d", options.MaxCount))
+	}
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", r.Dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	parseErr := parseLog(stdout, fn)
+	if parseErr != nil {
+		// stop git, its output is not read anymore
+		_ = cmd.Process.Kill()
+	}
+	waitErr := cmd.Wait()
+	if parseErr != nil {
+		return parseErr
+	}
+	if waitErr != nil {
+		return fmt.Errorf("git log failed: %w: %s", waitErr, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// Show returns the content of a file at a revision, os.ErrNotExist when the file does not exist at the revision
+func (r *Repository) Show(ctx context.Context, rev, path string) ([]byte, error) {
+	out, err := run(ctx, r.Dir, "cat-file", "blob", rev+":"+path)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return nil, fmt.Errorf("%s:%s: %w", rev, path, os.ErrNotExist)
+	}
+	return out, err
+}
+
+func run(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		slog.Debug(fmt.Sprintf("git %s failed: %s", strings.Join(args, " "), strings.TrimSpace(stderr.String())))
+		return nil, err
+	}
+	return out, nil
+}
+
+// parseLog parses the output of git log -p -U0 with the commit format
+func parseLog(reader io.Reader, fn func(Change) error) error {
+	buffered := bufio.NewReader(reader)
+	var commit Commit
+	var change *Change
+	// the lines left in the current hunk, the content of a hunk can look like a diff header
+	added, removed := 0, 0
+	nextLine := 0
+	flush := func() error {
+		if change == nil || len(change.Lines) == 0 {
+			change = nil
+			return nil
+		}
+		err := fn(*change)
+		change = nil
+		return err
+	}
+
+	for {
+		line, readErr := buffered.ReadString('\n')
+		if readErr != nil && readErr != io.EOF {
+			return readErr
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case added > 0 && strings.HasPrefix(line, "+"):
+			change.Lines = append(change.Lines, Line{Number: nextLine, Text: line[1:]})
+			nextLine++
+			added--
+		case removed > 0 && strings.HasPrefix(line, "-"):
+			removed--
+		case strings.HasPrefix(line, fieldSeparator+"commit"+fieldSeparator):
+			if err := flush(); err != nil {
+				return err
+			}
+			var err error
+			commit, err = parseCommit(line)
+			if err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "diff --git "):
+			if err := flush(); err != nil {
+				return err
+			}
+			added, removed = 0, 0
+		case strings.HasPrefix(line, "+++ "):
+			// paths with spaces end with a tab
+			if path := strings.TrimSuffix(strings.TrimPrefix(line, "+++ "), "\t"); path != "/dev/null" {
+				change = &Change{Commit: commit, Path: strings.TrimPrefix(unquote(path), "b/")}
+			}
+		case strings.HasPrefix(line, "@@ ") && change != nil:
+			start, newCount, oldCount, err := parseHunkHeader(line)
+			if err != nil {
+				return err
+			}
+			nextLine = start
+			added, removed = newCount, oldCount
+		}
+		if readErr == io.EOF {
+			return flush()
+		}
+	}
+}
+
+func parseCommit(line string) (Commit, error) {
+	fields := strings.Split(strings.TrimPrefix(line, fieldSeparator), fieldSeparator)
+	if len(fields) != 5 {
+		return Commit{}, fmt.Errorf("unexpected commit header: %q", line)
+	}
+	date, err := time.Parse(time.RFC3339, fields[4])
+	if err != nil {
+		return Commit{}, fmt.Errorf("unexpected commit date of %s: %w", fields[1], err)
+	}
+	return Commit{SHA: fields[1], Author: fields[2], Email: fields[3], Date: date}, nil
+}
+
+// parseHunkHeader parses "@@ -old[,count] +new[,count] @@" into the first new line and the line counts
+func parseHunkHeader(line string) (int, int, int, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return 0, 0, 0, fmt.Errorf("unexpected hunk header: %q", line)
+	}
+	_, oldCount, err := parseRange(strings.TrimPrefix(fields[1], "-"))
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("unexpected hunk header %q: %w", line, err)
+	}
+	start, newCount, err := parseRange(strings.TrimPrefix(fields[2], "+"))
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("unexpected hunk header %q: %w", line, err)
+	}
+	return start, newCount, oldCount, nil
+}
+
+func parseRange(value string) (int, int, error) {
+	startValue, countValue, hasCount := strings.Cut(value, ",")
+	start, err := strconv.Atoi(startValue)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !hasCount {
+		return start, 1, nil
+	}
+	count, err := strconv.Atoi(countValue)
+	return start, count, err
+}
+
+// unquote removes the quotes git adds to paths with special characters
+func unquote(path string) string {
+	if !strings.HasPrefix(path, `"`) {
+		return path
+	}
+	unquoted, err := strconv.Unquote(path)
+	if err != nil {
+		return path
+	}
+	return unquoted
+}
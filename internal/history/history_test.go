@@ -0,0 +1,160 @@
+//go:build unit
+
+package history
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleLog = "\x00commit\x00abc123\x00Dev One\x00dev@example.com\x002024-01-31T10:00:00+01:00\n" +
+	"\n" +
+	"diff --git a/config.yaml b/config.yaml\n" +
+	"index 1111111..2222222 100644\n" +
+	"--- a/config.yaml\n" +
+	"+++ b/config.yaml\n" +
+	"@@ -2 +2 @@ name: app\n" +
+	"-token: old\n" +
+	"+token: ghp_new\n" +
+	"@@ -10,0 +11,2 @@ name: app\n" +
+	"+++ not a header\n" +
+	"+--- neither\n" +
+	"diff --git a/logo.png b/logo.png\n" +
+	"new file mode 100644\n" +
+	"index 0000000..3333333\n" +
+	"Binary files /dev/null and b/logo.png differ\n" +
+	"\x00commit\x00def456\x00Dev Two\x00two@example.com\x002024-01-30T09:00:00Z\n" +
+	"\n" +
+	"diff --git a/sp ace.env b/sp ace.env\n" +
+	"new file mode 100644\n" +
+	"--- /dev/null\n" +
+	"+++ b/sp ace.env\t\n" +
+	"@@ -0,0 +1 @@\n" +
+	"+KEY=value\n" +
+	"\\ No newline at end of file\n" +
+	"diff --git a/removed.txt b/removed.txt\n" +
+	"--- a/removed.txt\n" +
+	"+++ b/removed.txt\n" +
+	"@@ -1 +0,0 @@\n" +
+	"-gone\n"
+
+func TestParseLog(t *testing.T) {
+	var changes []Change
+	err := parseLog(strings.NewReader(sampleLog), func(change Change) error {
+		changes = append(changes, change)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+
+	assert.Equal(t, "config.yaml", changes[0].Path)
+	assert.Equal(t, "abc123", changes[0].Commit.SHA)
+	assert.Equal(t, "Dev One", changes[0].Commit.Author)
+	assert.Equal(t, "dev@example.com", changes[0].Commit.Email)
+	assert.True(t, time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC).Equal(changes[0].Commit.Date))
+	assert.Equal(t, []Line{{Number: 2, Text: "token: ghp_new"}, {Number: 11, Text: "++ not a header"}, {Number: 12, Text: "--- neither"}}, changes[0].Lines)
+
+	assert.Equal(t, "sp ace.env", changes[1].Path)
+	assert.Equal(t, "def456", changes[1].Commit.SHA)
+	assert.Equal(t, []Line{{Number: 1, Text: "KEY=value"}}, changes[1].Lines)
+}
+
+func TestParseLogCallbackError(t *testing.T) {
+	err := parseLog(strings.NewReader(sampleLog), func(Change) error {
+		return errors.New("stop")
+	})
+	assert.EqualError(t, err, "stop")
+}
+
+func TestChangeContent(t *testing.T) {
+	change := Change{Lines: []Line{{Number: 2, Text: "b"}, {Number: 4, Text: "d"}}}
+	assert.Equal(t, "\nb\n\nd", change.Content())
+	assert.Equal(t, "", Change{}.Content())
+}
+
+// gitCommand runs git in a directory without the global and system config of the user
+func gitCommand(t *testing.T, dir string) func(args ...string) {
+	return func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1")
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+}
+
+func TestRepository(t *testing.T) {
+	dir := t.TempDir()
+	git := gitCommand(t, dir)
+	git("init", "-q")
+	git("config", "user.name", "Dev One")
+	git("config", "user.email", "dev@example.com")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("name: app\ntoken: secret\n"), 0644))
+	git("add", ".")
+	git("commit", "-qm", "add config")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("name: app\n"), 0644))
+	git("commit", "-qam", "remove token")
+
+	repository, err := Open(context.Background(), filepath.Join(dir, "."))
+	require.NoError(t, err)
+
+	var changes []Change
+	err = repository.Walk(context.Background(), LogOptions{}, func(change Change) error {
+		changes = append(changes, change)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, "config.yaml", changes[0].Path)
+	assert.Equal(t, "name: app\ntoken: secret", changes[0].Content())
+
+	content, err := repository.Show(context.Background(), "HEAD", "config.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "name: app\n", string(content))
+
+	_, err = repository.Show(context.Background(), "HEAD", "missing.yaml")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	_, err = Open(context.Background(), t.TempDir())
+	assert.Error(t, err)
+}
+
+func TestRepositoryDiffPrefixConfig(t *testing.T) {
+	dir := t.TempDir()
+	git := gitCommand(t, dir)
+	git("init", "-q")
+	git("config", "user.name", "Dev One")
+	git("config", "user.email", "dev@example.com")
+	// a directory named like a prefix must not be trimmed
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "b"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b", "config.yaml"), []byte("token: secret\n"), 0644))
+	git("add", ".")
+	git("commit", "-qm", "add config")
+
+	for _, config := range []string{"diff.noprefix", "diff.mnemonicPrefix"} {
+		t.Run(config, func(t *testing.T) {
+			// the same as git -c <config>=true, for the git processes started by Walk
+			t.Setenv("GIT_CONFIG_COUNT", "1")
+			t.Setenv("GIT_CONFIG_KEY_0", config)
+			t.Setenv("GIT_CONFIG_VALUE_0", "true")
+			repository, err := Open(context.Background(), dir)
+			require.NoError(t, err)
+
+			var paths []string
+			err = repository.Walk(context.Background(), LogOptions{}, func(change Change) error {
+				paths = append(paths, change.Path)
+				return nil
+			})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"b/config.yaml"}, paths)
+		})
+	}
+}
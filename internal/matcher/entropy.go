@@ -0,0 +1,135 @@
+package matcher
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Charset is the alphabet of the tokens the entropy is computed for
+type Charset string
+
+const (
+	CharsetBase64 Charset = "base64"
+	CharsetHex    Charset = "hex"
+)
+
+// DefaultEntropyMinLength is the minimum length of the tokens checked for their entropy
+const DefaultEntropyMinLength = 20
+
+// DefaultEntropyThresholds are the minimum entropies (in bits per character) of the charsets
+var DefaultEntropyThresholds = map[Charset]float64{
+	CharsetBase64: 4.5,
+	CharsetHex:    3.0,
+}
+
+const hexDigits = "0123456789abcdefABCDEF"
+
+// ShannonEntropy returns the entropy of the characters of a string in bits per character
+func ShannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := map[rune]int{}
+	for _, r := range s {
+		counts[r]++
+	}
+	length := float64(utf8.RuneCountInString(s))
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / length
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+/*
+ParseEntropyThresholds parses charsets with optional thresholds as <charset>[=<threshold>], e.g. "base64" or "hex=3.5".
+A charset without a threshold uses its default threshold.
+*/
+func ParseEntropyThresholds(values []string) (map[Charset]float64, error) {
+	thresholds := map[Charset]float64{}
+	for _, value := range values {
+		name, threshold, hasThreshold := strings.Cut(strings.TrimSpace(value), "=")
+		charset := Charset(strings.ToLower(name))
+		defaultThreshold, ok := DefaultEntropyThresholds[charset]
+		if !ok {
+			return nil, fmt.Errorf("invalid charset %q (base64, hex)", name)
+		}
+		thresholds[charset] = defaultThreshold
+		if hasThreshold {
+			parsed, err := strconv.ParseFloat(threshold, 64)
+			if err != nil || parsed <= 0 {
+				return nil, fmt.Errorf("invalid entropy threshold %q of charset %s, expected a positive number", threshold, charset)
+			}
+			thresholds[charset] = parsed
+		}
+	}
+	return thresholds, nil
+}
+
+// Entropy finds tokens of high entropy, e.g. generated passwords and keys without a recognizable prefix
+type Entropy struct {
+	thresholds map[Charset]float64
+	minLength  int
+	token      *regexp.Regexp
+}
+
+// NewEntropy creates an entropy matcher for the charsets of the thresholds, tokens shorter than minLength are skipped
+func NewEntropy(thresholds map[Charset]float64, minLength int) (*Entropy, error) {
+	if len(thresholds) == 0 {
+		return nil, fmt.Errorf("no charsets for the entropy detection")
+	}
+	if minLength < 1 {
+		return nil, fmt.Errorf("invalid minimum token length %d", minLength)
+	}
+	return &Entropy{
+		thresholds: thresholds,
+		minLength:  minLength,
+		// tokens consist of base64 (standard and URL-safe) characters with an optional padding
+		token: regexp.MustCompile(fmt.Sprintf(`[A-Za-z0-9+/_-]{%d,}={0,2}`, minLength)),
+	}, nil
+}
+
+// Charsets returns the charsets of the matcher with their thresholds
+func (e *Entropy) Charsets() []string {
+	charsets := make([]string, 0, len(e.thresholds))
+	for charset, threshold := range e.thresholds {
+		charsets = append(charsets, fmt.Sprintf("%s=%g", charset, threshold))
+	}
+	sort.Strings(charsets)
+	return charsets
+}
+
+// MinLength returns the minimum length of the tokens checked by the matcher
+func (e *Entropy) MinLength() int {
+	return e.minLength
+}
+
+/*
+Match returns the tokens of the text whose entropy reaches the threshold of their charset.
+Tokens of hex digits only are checked against the hex threshold (if enabled), all other tokens against the base64 threshold.
+The search of a match is the charset of its token.
+*/
+func (e *Entropy) Match(text string, context int) []MatchResult {
+	var results []MatchResult
+	for _, loc := range e.token.FindAllStringIndex(text, -1) {
+		token := text[loc[0]:loc[1]]
+		charset := CharsetBase64
+		if _, ok := e.thresholds[CharsetHex]; ok && strings.Trim(token, hexDigits) == "" {
+			charset = CharsetHex
+		}
+		threshold, ok := e.thresholds[charset]
+		if !ok || ShannonEntropy(strings.TrimRight(token, "=")) < threshold {
+			continue
+		}
+		result := createMatchResult(text, compiledPattern{search: token}, loc[0], loc[1]-loc[0], context)
+		result.Search = string(charset)
+		results = append(results, result)
+	}
+	return results
+}
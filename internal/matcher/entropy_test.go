@@ -0,0 +1,87 @@
+//go:build unit
+
+package matcher
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShannonEntropy(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected float64
+	}{
+		{text: "", expected: 0},
+		{text: "aaaa", expected: 0},
+		{text: "abab", expected: 1},
+		{text: "abcd", expected: 2},
+		{text: "äöüß", expected: 2},
+		{text: "0123456789abcdef", expected: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			assert.InDelta(t, tt.expected, ShannonEntropy(tt.text), 1e-9)
+		})
+	}
+}
+
+func TestParseEntropyThresholds(t *testing.T) {
+	thresholds, err := ParseEntropyThresholds([]string{"base64", "HEX=3.5"})
+	require.NoError(t, err)
+	assert.Equal(t, map[Charset]float64{CharsetBase64: 4.5, CharsetHex: 3.5}, thresholds)
+
+	_, err = ParseEntropyThresholds([]string{"base32"})
+	assert.EqualError(t, err, `invalid charset "base32" (base64, hex)`)
+	_, err = ParseEntropyThresholds([]string{"hex=high"})
+	assert.EqualError(t, err, `invalid entropy threshold "high" of charset hex, expected a positive number`)
+	_, err = ParseEntropyThresholds([]string{"hex=0"})
+	assert.ErrorContains(t, err, "invalid entropy threshold")
+}
+
+func TestEntropyMatch(t *testing.T) {
+	// the samples are built at runtime, so this file is not reported by secret scanners
+	base64Secret := "aB3dE5fG7hJ9kL1mN2pQ4rS6tU8vW0xYz+/" + "Q=="
+	hexSecret := "9f86d081884c7d659a2feaa0c55ad015" + "a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	tests := []struct {
+		name             string
+		thresholds       map[Charset]float64
+		content          string
+		expectedSearches []string
+	}{
+		{name: "Base64", thresholds: DefaultEntropyThresholds, content: "secret: " + base64Secret + "\n", expectedSearches: []string{"base64"}},
+		{name: "Hex", thresholds: DefaultEntropyThresholds, content: "sha: " + hexSecret + "\n", expectedSearches: []string{"hex"}},
+		{name: "Hex only", thresholds: map[Charset]float64{CharsetHex: 3.0}, content: base64Secret + " " + hexSecret, expectedSearches: []string{"hex"}},
+		{name: "Hex as base64", thresholds: map[Charset]float64{CharsetBase64: 3.0}, content: hexSecret, expectedSearches: []string{"base64"}},
+		{name: "Low entropy", thresholds: DefaultEntropyThresholds, content: "name: " + strings.Repeat("ab", 20) + "\n"},
+		{name: "Too short", thresholds: DefaultEntropyThresholds, content: "key: " + hexSecret[:12] + "\n"},
+		{name: "Words", thresholds: DefaultEntropyThresholds, content: "the quick brown fox jumps over the lazy dog\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entropy, err := NewEntropy(tt.thresholds, DefaultEntropyMinLength)
+			require.NoError(t, err)
+			var searches []string
+			for _, m := range entropy.Match(tt.content, 10) {
+				searches = append(searches, m.Search)
+			}
+			assert.Equal(t, tt.expectedSearches, searches)
+		})
+	}
+}
+
+func TestEntropyMatchPosition(t *testing.T) {
+	entropy, err := NewEntropy(DefaultEntropyThresholds, 8)
+	require.NoError(t, err)
+	matches := entropy.Match("a: 1\nkey=0a1b2c3d4e5f\n", 5)
+	require.Len(t, matches, 1)
+	assert.Equal(t, MatchResult{Line: 2, StartCol: 5, EndCol: 16}, positionOf(matches[0]))
+
+	_, err = NewEntropy(nil, 8)
+	assert.EqualError(t, err, "no charsets for the entropy detection")
+}
@@ -0,0 +1,175 @@
+package matcher
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// maxExtendDepth limits the chain of gitleaks configurations extending each other
+const maxExtendDepth = 2
+
+// gitleaksVersion is the version of the rule packs converted from gitleaks configurations
+const gitleaksVersion = "gitleaks"
+
+type gitleaksConfig struct {
+	Title  string `toml:"title"`
+	Extend struct {
+		UseDefault    bool     `toml:"useDefault"`
+		Path          string   `toml:"path"`
+		DisabledRules []string `toml:"disabledRules"`
+	} `toml:"extend"`
+	Rules      []gitleaksRule      `toml:"rules"`
+	Allowlist  *gitleaksAllowlist  `toml:"allowlist"`
+	Allowlists []gitleaksAllowlist `toml:"allowlists"`
+}
+
+type gitleaksRule struct {
+	ID          string              `toml:"id"`
+	Description string              `toml:"description"`
+	Regex       string              `toml:"regex"`
+	SecretGroup int                 `toml:"secretGroup"`
+	Entropy     float64             `toml:"entropy"`
+	Path        string              `toml:"path"`
+	Keywords    []string            `toml:"keywords"`
+	Allowlist   *gitleaksAllowlist  `toml:"allowlist"`
+	Allowlists  []gitleaksAllowlist `toml:"allowlists"`
+}
+
+type gitleaksAllowlist struct {
+	Description string   `toml:"description"`
+	Condition   string   `toml:"condition"`
+	RegexTarget string   `toml:"regexTarget"`
+	Regexes     []string `toml:"regexes"`
+	Paths       []string `toml:"paths"`
+	Stopwords   []string `toml:"stopwords"`
+	Commits     []string `toml:"commits"`
+	// TargetRules limits a global allowlist to some rules
+	TargetRules []string `toml:"targetRules"`
+}
+
+func (a gitleaksAllowlist) allowlist() Allowlist {
+	return Allowlist{
+		Description: a.Description,
+		Condition:   a.Condition,
+		RegexTarget: a.RegexTarget,
+		Regexes:     a.Regexes,
+		Paths:       a.Paths,
+		Stopwords:   a.Stopwords,
+		Commits:     a.Commits,
+	}
+}
+
+/*
+ParseGitleaksConfig converts a gitleaks configuration into a rule pack.
+The rules keep their regex, secret group, entropy, path and keywords, their allowlists and the global allowlists become allowlists of the pack.
+[extend] useDefault extends the bundled default rule pack of deep-scan, not the one of gitleaks.
+A path of [extend] is resolved relative to the directory of the configuration.
+Rules without a regex only match paths in gitleaks and are skipped.
+*/
+func ParseGitleaksConfig(name string, data []byte) (*RulePack, error) {
+	pack, err := parseGitleaksConfig(name, data, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := pack.compile(); err != nil {
+		return nil, err
+	}
+	return pack, nil
+}
+
+func parseGitleaksConfig(name string, data []byte, depth int) (*RulePack, error) {
+	var config gitleaksConfig
+	if err := toml.NewDecoder(bytes.NewReader(data)).Decode(&config); err != nil {
+		return nil, fmt.Errorf("parsing gitleaks config %s failed: %w", name, err)
+	}
+
+	pack := &RulePack{Name: name, Version: gitleaksVersion}
+	var bases []*RulePack
+	if config.Extend.UseDefault {
+		base, err := BundledRulePack(DefaultRulePack)
+		if err != nil {
+			return nil, err
+		}
+		bases = append(bases, base)
+	}
+	if config.Extend.Path != "" {
+		if depth >= maxExtendDepth {
+			return nil, fmt.Errorf("gitleaks config %s extends %s, but only %d configs can extend each other", name, config.Extend.Path, maxExtendDepth)
+		}
+		path := config.Extend.Path
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(name), path)
+		}
+		baseData, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("gitleaks config %s: reading extended config failed: %w", name, err)
+		}
+		base, err := parseGitleaksConfig(path, baseData, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		bases = append(bases, base)
+	}
+
+	// the rules of the config replace the rules with the same ID of the extended configs
+	for _, base := range bases {
+		for _, rule := range base.Rules {
+			if slices.Contains(config.Extend.DisabledRules, rule.ID) || slices.ContainsFunc(config.Rules, func(r gitleaksRule) bool { return r.ID == rule.ID }) {
+				continue
+			}
+			pack.Rules = append(pack.Rules, rule)
+		}
+		pack.Allowlists = append(pack.Allowlists, base.Allowlists...)
+	}
+	for _, rule := range config.Rules {
+		if rule.Regex == "" {
+			slog.Warn(fmt.Sprintf("gitleaks config %s: skipping rule %s, rules without a regex are not supported", name, rule.ID))
+			continue
+		}
+		converted := Rule{
+			ID:          rule.ID,
+			Description: rule.Description,
+			Regex:       rule.Regex,
+			SecretGroup: rule.SecretGroup,
+			Entropy:     rule.Entropy,
+			Path:        rule.Path,
+			Keywords:    rule.Keywords,
+		}
+		if rule.Allowlist != nil {
+			converted.Allowlists = append(converted.Allowlists, rule.Allowlist.allowlist())
+		}
+		for _, allowlist := range rule.Allowlists {
+			converted.Allowlists = append(converted.Allowlists, allowlist.allowlist())
+		}
+		pack.Rules = append(pack.Rules, converted)
+	}
+
+	allowlists := config.Allowlists
+	if config.Allowlist != nil {
+		allowlists = append(allowlists, *config.Allowlist)
+	}
+	for _, allowlist := range allowlists {
+		if len(allowlist.TargetRules) == 0 {
+			pack.Allowlists = append(pack.Allowlists, allowlist.allowlist())
+			continue
+		}
+		for i := range pack.Rules {
+			if slices.Contains(allowlist.TargetRules, pack.Rules[i].ID) {
+				pack.Rules[i].Allowlists = append(slices.Clip(pack.Rules[i].Allowlists), allowlist.allowlist())
+			}
+		}
+	}
+	return pack, nil
+}
+
+// isGitleaksConfig reports if a rule pack file is a gitleaks configuration
+func isGitleaksConfig(name string) bool {
+	return strings.EqualFold(filepath.Ext(name), ".toml")
+}
@@ -0,0 +1,209 @@
+//go:build unit
+
+package matcher
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testGitleaksConfig = `
+title = "test"
+
+[[rules]]
+id = "generic-api-key"
+description = "Generic API key"
+regex = '''(?i)api_key\s*=\s*"([a-z0-9]+)"'''
+secretGroup = 1
+entropy = 3.0
+keywords = ["API_KEY"]
+tags = ["generic"]
+
+[rules.allowlist]
+stopwords = ["example"]
+
+[[rules.allowlists]]
+condition = "AND"
+paths = ['''^test/''']
+regexTarget = "line"
+regexes = ['''# fake''']
+
+[[rules]]
+id = "internal-token"
+regex = '''itok_[0-9]{4}'''
+path = '''\.env$'''
+
+[[rules]]
+id = "key-file"
+path = '''\.pem$'''
+
+[allowlist]
+commits = ["abc123"]
+
+[[allowlists]]
+targetRules = ["internal-token"]
+regexes = ['''itok_0000''']
+`
+
+func TestParseGitleaksConfig(t *testing.T) {
+	pack, err := ParseGitleaksConfig("gitleaks.toml", []byte(testGitleaksConfig))
+	require.NoError(t, err)
+	assert.Equal(t, "gitleaks.toml", pack.Name)
+	assert.Equal(t, "gitleaks", pack.Version)
+	require.Len(t, pack.Rules, 2, "the rule without a regex is skipped")
+	rules, err := NewRules(pack)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		location Location
+		content  string
+		expected []string
+	}{
+		{name: "Secret group", content: `api_key = "k3y9x2q7w1"`, expected: []string{"generic-api-key"}},
+		{name: "Below the entropy", content: `api_key = "aaaaaaaaaa"`},
+		{name: "Stopword", content: `api_key = "example1234"`},
+		{name: "Allowlist condition AND", location: Location{Path: "test/config.py"}, content: `api_key = "k3y9x2q7w1" # fake`},
+		{name: "Allowlist condition AND not met", location: Location{Path: "src/config.py"}, content: `api_key = "k3y9x2q7w1" # fake`, expected: []string{"generic-api-key"}},
+		{name: "Path", location: Location{Path: "deploy/.env"}, content: "TOKEN=itok_1234", expected: []string{"internal-token"}},
+		{name: "Other path", location: Location{Path: "deploy/values.yaml"}, content: "TOKEN=itok_1234"},
+		{name: "Allowlist of target rules", location: Location{Path: ".env"}, content: "TOKEN=itok_0000"},
+		{name: "Global allowlist", location: Location{Path: ".env", Commit: "abc123"}, content: "TOKEN=itok_1234"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ids []string
+			for _, m := range rules.Match(tt.location, tt.content, 15) {
+				ids = append(ids, m.RuleID)
+			}
+			assert.Equal(t, tt.expected, ids)
+		})
+	}
+}
+
+func TestParseGitleaksConfigExtend(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "base.toml"), []byte(`
+[[rules]]
+id = "base-token"
+regex = '''btok_[0-9]+'''
+`), 0o600))
+	name := filepath.Join(dir, "gitleaks.toml")
+
+	pack, err := ParseGitleaksConfig(name, []byte(`
+[extend]
+useDefault = true
+path = "base.toml"
+disabledRules = ["jwt"]
+
+[[rules]]
+id = "github-pat"
+regex = '''ghp_custom'''
+`))
+	require.NoError(t, err)
+	rules, err := NewRules(pack)
+	require.NoError(t, err)
+	_, ok := rules.Rule("jwt")
+	assert.False(t, ok)
+	_, ok = rules.Rule("aws-access-key-id")
+	assert.True(t, ok)
+	_, ok = rules.Rule("base-token")
+	assert.True(t, ok)
+	rule, ok := rules.Rule("github-pat")
+	require.True(t, ok)
+	assert.Equal(t, "ghp_custom", rule.Regex)
+
+	// the extended configs are limited
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "loop.toml"), []byte("[extend]\npath = \"loop.toml\"\n"), 0o600))
+	_, err = ParseGitleaksConfig(filepath.Join(dir, "loop.toml"), []byte("[extend]\npath = \"loop.toml\"\n"))
+	assert.ErrorContains(t, err, "only 2 configs can extend each other")
+}
+
+func TestParseGitleaksConfigErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected string
+	}{
+		{name: "Invalid TOML", data: "[[rules]", expected: "parsing gitleaks config gitleaks.toml failed"},
+		{name: "Invalid regex", data: "[[rules]]\nid = \"a\"\nregex = \"(\"", expected: "rule a has an invalid regex"},
+		{name: "Invalid secret group", data: "[[rules]]\nid = \"a\"\nregex = \"(a)\"\nsecretGroup = 2", expected: "rule a has the secret group 2, but its regex has 1 groups"},
+		{name: "Invalid allowlist", data: "[allowlist]\nregexTarget = \"file\"", expected: `invalid regex target "file"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseGitleaksConfig("gitleaks.toml", []byte(tt.data))
+			assert.ErrorContains(t, err, tt.expected)
+		})
+	}
+}
+
+func TestParseTrufflehogConfig(t *testing.T) {
+	pack, err := ParseTrufflehogConfig("detectors.yaml", []byte(`
+detectors:
+  - name: hog
+    keywords: [hog]
+    regex:
+      id: 'HOG[0-9A-Z]{8}'
+      secret: 'hogsecret_([a-z0-9]{8})'
+    exclude_words: [dummy]
+    exclude_regexes_match: ['HOG0{8}']
+    verify:
+      - endpoint: http://localhost
+`))
+	require.NoError(t, err)
+	rules, err := NewRules(pack)
+	require.NoError(t, err)
+	require.Len(t, rules.List(), 2)
+	assert.Equal(t, "hog.id", rules.List()[0].ID)
+	assert.Equal(t, "hog.secret", rules.List()[1].ID)
+
+	matches := rules.Match(Location{}, "HOGA1B2C3D4 HOG00000000 hogsecret_dummy12 hogsecret_a1b2c3d4", 15)
+	require.Len(t, matches, 2)
+	assert.Equal(t, "hog.id", matches[0].RuleID)
+	assert.Equal(t, "hog.secret", matches[1].RuleID)
+}
+
+func TestLoadRulePack(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"gitleaks.toml":   "[[rules]]\nid = \"a\"\nregex = \"a\"\n",
+		"trufflehog.yaml": "detectors:\n  - name: b\n    regex:\n      b: b\n",
+		"pack.yml":        "version: 1.0.0\nrules: [{id: c, regex: c}]\n",
+	}
+	for name, data := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(data), 0o600))
+	}
+
+	tests := []struct {
+		source          string
+		expectedVersion string
+		expectedRule    string
+	}{
+		{source: DefaultRulePack, expectedVersion: "1.0.0", expectedRule: "github-pat"},
+		{source: filepath.Join(dir, "gitleaks.toml"), expectedVersion: "gitleaks", expectedRule: "a"},
+		{source: filepath.Join(dir, "trufflehog.yaml"), expectedVersion: "trufflehog", expectedRule: "b"},
+		{source: filepath.Join(dir, "pack.yml"), expectedVersion: "1.0.0", expectedRule: "c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(filepath.Base(tt.source), func(t *testing.T) {
+			pack, err := LoadRulePack(tt.source)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedVersion, pack.Version)
+			assert.True(t, slices.ContainsFunc(pack.Rules, func(r Rule) bool { return r.ID == tt.expectedRule }))
+		})
+	}
+
+	_, err := LoadRulePack("unknown")
+	assert.ErrorContains(t, err, `unknown rule pack "unknown"`)
+	_, err = LoadRulePack(filepath.Join(dir, "missing.toml"))
+	assert.ErrorContains(t, err, "reading rule pack")
+}
@@ -0,0 +1,37 @@
+package matcher
+
+import (
+	"path"
+	"strings"
+)
+
+/*
+MatchKeyPath checks if a dot separated key path (e.g. db.primary.password) matches the pattern.
+Each segment of the pattern is a glob (e.g. 'pass*'), '*' matches exactly one segment
+and '**' matches any number of segments, so 'db.*.password' matches 'db.primary.password'
+and '**.password' matches every password key.
+*/
+func MatchKeyPath(pattern, keyPath string) bool {
+	return matchSegments(strings.Split(pattern, "."), strings.Split(keyPath, "."))
+}
+
+func matchSegments(pattern, keyPath []string) bool {
+	if len(pattern) == 0 {
+		return len(keyPath) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(keyPath); i++ {
+			if matchSegments(pattern[1:], keyPath[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(keyPath) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], keyPath[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], keyPath[1:])
+}
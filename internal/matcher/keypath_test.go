@@ -0,0 +1,35 @@
+//go:build unit
+
+package matcher
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchKeyPath(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		keyPath  string
+		expected bool
+	}{
+		{pattern: "db.password", keyPath: "db.password", expected: true},
+		{pattern: "db.*.password", keyPath: "db.primary.password", expected: true},
+		{pattern: "db.*.password", keyPath: "db.password", expected: false},
+		{pattern: "db.*.password", keyPath: "db.primary.replica.password", expected: false},
+		{pattern: "db.**.password", keyPath: "db.password", expected: true},
+		{pattern: "db.**.password", keyPath: "db.primary.replica.password", expected: true},
+		{pattern: "**.password", keyPath: "spec.database.password", expected: true},
+		{pattern: "**", keyPath: "spec.database.password", expected: true},
+		{pattern: "spec.*.pass*", keyPath: "spec.database.passphrase", expected: true},
+		{pattern: "spec.containers.0.env", keyPath: "spec.containers.0.env", expected: true},
+		{pattern: "db.password", keyPath: "db.user", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.keyPath, func(t *testing.T) {
+			assert.Equal(t, tt.expected, MatchKeyPath(tt.pattern, tt.keyPath))
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package matcher
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// Pattern is a text or regex search of a multi-pattern matcher
+type Pattern struct {
+	Search string
+	Type   SearchType
+	// Regex is the compiled regex of a regex search, see NewRegexPattern
+	Regex *regexp.Regexp
+}
+
+// NewRegexPattern creates the pattern of a regex compiled before the search
+func NewRegexPattern(regex *regexp.Regexp) Pattern {
+	return Pattern{Search: regex.String(), Type: RegexSearch, Regex: regex}
+}
+
+/*
+Multi finds the matches of many patterns with few passes over the text.
+The text patterns are searched by an Aho-Corasick automaton in a single pass. The regexes are combined into one regex
+of alternatives, which only prefilters the text: the regexes are searched one by one in the texts it matches.
+*/
+type Multi struct {
+	patterns []Pattern
+	literals *ahoCorasick
+	// literalPatterns maps the patterns of the automaton to the index of their pattern
+	literalPatterns []int
+	// combined matches the texts matched by any of the regexes, nil when the regexes could not be combined
+	combined *regexp.Regexp
+	regexes  map[int]*regexp.Regexp
+}
+
+/*
+NewMulti creates a multi-pattern matcher. A regex pattern without its compiled regex is compiled like regexp.MustCompile,
+so it panics on an invalid regex. Empty text patterns never match.
+*/
+func NewMulti(patterns []Pattern) *Multi {
+	m := &Multi{patterns: patterns, regexes: map[int]*regexp.Regexp{}}
+	var literals []string
+	var alternatives []string
+	for i, p := range patterns {
+		if p.Type != RegexSearch {
+			if p.Search != "" {
+				literals = append(literals, p.Search)
+				m.literalPatterns = append(m.literalPatterns, i)
+			}
+			continue
+		}
+		regex := p.Regex
+		if regex == nil {
+			regex = regexp.MustCompile(p.Search)
+		}
+		m.regexes[i] = regex
+		alternatives = append(alternatives, "(?:"+p.Search+")")
+	}
+	if len(literals) > 0 {
+		m.literals = newAhoCorasick(literals)
+	}
+	if len(alternatives) > 0 {
+		// the combination of valid regexes can still exceed the limits of the regex package,
+		// without the combined regex every text is searched for every regex
+		combined, err := regexp.Compile(strings.Join(alternatives, "|"))
+		if err != nil {
+			slog.Debug(fmt.Sprintf("combining the regexes failed, searching them one by one: %s", err))
+		}
+		m.combined = combined
+	}
+	return m
+}
+
+// Patterns returns the patterns of the matcher, the results of Match have the same order
+func (m *Multi) Patterns() []Pattern {
+	return m.patterns
+}
+
+/*
+Match returns the matches of every pattern, the i-th slice holds the matches of the i-th pattern.
+The matches of a pattern do not overlap each other, like the ones of Text, but they can overlap the ones of other patterns.
+Empty matches of regexes (e.g. of a*) are skipped.
+*/
+func (m *Multi) Match(text string, context int) [][]MatchResult {
+	results := make([][]MatchResult, len(m.patterns))
+	if m.literals != nil {
+		// the end of the last match of each pattern, the occurrences of a pattern are found in order
+		ends := make([]int, len(m.literalPatterns))
+		m.literals.findAll(text, func(literal, start, end int) {
+			if start < ends[literal] {
+				return
+			}
+			ends[literal] = end
+			i := m.literalPatterns[literal]
+			results[i] = append(results[i], m.matchResult(text, i, start, end, context))
+		})
+	}
+	// a text without a match of the combined regex has no match of any regex
+	if m.combined != nil && !m.combined.MatchString(text) {
+		return results
+	}
+	for i, regex := range m.regexes {
+		for _, loc := range regex.FindAllStringIndex(text, -1) {
+			if loc[0] != loc[1] {
+				results[i] = append(results[i], m.matchResult(text, i, loc[0], loc[1], context))
+			}
+		}
+	}
+	return results
+}
+
+// matchResult creates the result of a match of a pattern, a match of the whole text is an exact match
+func (m *Multi) matchResult(text string, i, start, end, context int) MatchResult {
+	p := compiledPattern{search: m.patterns[i].Search, regex: m.regexes[i]}
+	if start == 0 && end == len(text) {
+		return createExactMatchResult(text, p)
+	}
+	return createMatchResult(text, p, start, end-start, context)
+}
+
+// ahoCorasick finds all occurrences of many literals in a single pass
+type ahoCorasick struct {
+	nodes []ahoCorasickNode
+	// lengths of the literals
+	lengths []int
+}
+
+type ahoCorasickNode struct {
+	next map[byte]int
+	fail int
+	// output are the literals ending at this node, dictionary links to the next node with output along the fail links
+	output     []int
+	dictionary int
+}
+
+func newAhoCorasick(literals []string) *ahoCorasick {
+	a := &ahoCorasick{nodes: []ahoCorasickNode{{next: map[byte]int{}, dictionary: -1}}}
+	for i, literal := range literals {
+		a.lengths = append(a.lengths, len(literal))
+		node := 0
+		for j := 0; j < len(literal); j++ {
+			next, ok := a.nodes[node].next[literal[j]]
+			if !ok {
+				next = len(a.nodes)
+				a.nodes = append(a.nodes, ahoCorasickNode{next: map[byte]int{}, dictionary: -1})
+				a.nodes[node].next[literal[j]] = next
+			}
+			node = next
+		}
+		a.nodes[node].output = append(a.nodes[node].output, i)
+	}
+
+	// the fail links are built breadth first, so the links of the shorter prefixes exist already
+	queue := make([]int, 0, len(a.nodes))
+	for _, child := range a.nodes[0].next {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for c, child := range a.nodes[node].next {
+			fail := a.nodes[node].fail
+			for fail > 0 && a.nodes[fail].next[c] == 0 {
+				fail = a.nodes[fail].fail
+			}
+			if next, ok := a.nodes[fail].next[c]; ok && next != child {
+				a.nodes[child].fail = next
+			}
+			if failNode := a.nodes[child].fail; len(a.nodes[failNode].output) > 0 {
+				a.nodes[child].dictionary = failNode
+			} else {
+				a.nodes[child].dictionary = a.nodes[failNode].dictionary
+			}
+			queue = append(queue, child)
+		}
+	}
+	return a
+}
+
+// findAll calls found for every occurrence of a literal, ordered by the end of the occurrences
+func (a *ahoCorasick) findAll(text string, found func(literal, start, end int)) {
+	node := 0
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		next, ok := a.nodes[node].next[c]
+		for !ok && node > 0 {
+			node = a.nodes[node].fail
+			next, ok = a.nodes[node].next[c]
+		}
+		if ok {
+			node = next
+		}
+		for output := node; output > 0; output = a.nodes[output].dictionary {
+			for _, literal := range a.nodes[output].output {
+				found(literal, i+1-a.lengths[literal], i+1)
+			}
+		}
+	}
+}
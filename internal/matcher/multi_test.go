@@ -0,0 +1,148 @@
+//go:build unit
+
+package matcher
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func positions(matches []MatchResult) []string {
+	var result []string
+	for _, m := range matches {
+		result = append(result, fmt.Sprintf("%d:%d-%d", m.Line, m.StartCol, m.EndCol))
+	}
+	return result
+}
+
+func TestMultiMatchLiterals(t *testing.T) {
+	m := NewMulti([]Pattern{
+		{Search: "he", Type: TextSearch},
+		{Search: "she", Type: TextSearch},
+		{Search: "hers", Type: TextSearch},
+		{Search: "aa", Type: TextSearch},
+		{Search: "", Type: TextSearch},
+		{Search: "missing", Type: TextSearch},
+	})
+
+	results := m.Match("ushers\naaaaa", 5)
+	require.Len(t, results, 6)
+	assert.Equal(t, []string{"1:3-4"}, positions(results[0]))
+	assert.Equal(t, []string{"1:2-4"}, positions(results[1]))
+	assert.Equal(t, []string{"1:3-6"}, positions(results[2]))
+	// the matches of a pattern do not overlap
+	assert.Equal(t, []string{"2:1-2", "2:3-4"}, positions(results[3]))
+	assert.Empty(t, results[4])
+	assert.Empty(t, results[5])
+}
+
+func TestMultiMatchRegexes(t *testing.T) {
+	m := NewMulti([]Pattern{
+		{Search: `tok_(\d+)`, Type: RegexSearch},
+		{Search: `(?i)(key)=(\w+)`, Type: RegexSearch},
+		{Search: `\d+`, Type: RegexSearch},
+		NewRegexPattern(regexp.MustCompile(`abc\b`)),
+		{Search: "KEY", Type: TextSearch},
+	})
+
+	text := "tok_12 KEY=abc 7"
+	results := m.Match(text, 5)
+	assert.Equal(t, []string{"1:1-6"}, positions(results[0]))
+	assert.Equal(t, []string{"1:8-14"}, positions(results[1]))
+	// the digits of tok_12 overlap the match of the first regex, both are reported
+	assert.Equal(t, []string{"1:5-6", "1:16-16"}, positions(results[2]))
+	assert.Equal(t, []string{"1:12-14"}, positions(results[3]))
+	assert.Equal(t, []string{"1:8-10"}, positions(results[4]))
+
+	results = m.Match("tok_1", 5)
+	assert.Equal(t, []string{"1:5-5"}, positions(results[2]))
+	assert.True(t, results[0][0].ExactMatch)
+
+	results = m.Match("nothing", 5)
+	assert.Equal(t, make([][]MatchResult, 5), results)
+
+	// invalid regexes are rejected when the options are built, the matcher does not hide them
+	assert.Panics(t, func() { NewMulti([]Pattern{{Search: `(`, Type: RegexSearch}}) })
+}
+
+func TestMultiMatchOverlappingRegexes(t *testing.T) {
+	m := NewMulti([]Pattern{
+		{Search: `password=\w+`, Type: RegexSearch},
+		{Search: `\w+=secret\d`, Type: RegexSearch},
+	})
+
+	// the matches of both regexes start at the same position, each regex reports its own
+	results := m.Match("password=secret1 x", 5)
+	assert.Equal(t, []string{"1:1-16"}, positions(results[0]))
+	assert.Equal(t, []string{"1:1-16"}, positions(results[1]))
+
+	results = m.Match("a password=secret1b", 5)
+	assert.Equal(t, []string{"1:3-19"}, positions(results[0]))
+	assert.Equal(t, []string{"1:3-18"}, positions(results[1]))
+}
+
+func TestMultiMatchEmptyAlternative(t *testing.T) {
+	m := NewMulti([]Pattern{
+		{Search: `a*`, Type: RegexSearch},
+		{Search: `b`, Type: RegexSearch},
+	})
+
+	// the empty match of a* at the position of b does not hide b
+	results := m.Match("xb", 5)
+	assert.Empty(t, results[0])
+	assert.Equal(t, []string{"1:2-2"}, positions(results[1]))
+	results = m.Match("b", 5)
+	assert.Empty(t, results[0])
+	require.Len(t, results[1], 1)
+	assert.True(t, results[1][0].ExactMatch)
+}
+
+func TestMultiMatchWithoutCombinedRegex(t *testing.T) {
+	patterns := []Pattern{
+		{Search: `tok_\d+`, Type: RegexSearch},
+		{Search: `(?i)key=(\w+)`, Type: RegexSearch},
+		{Search: "KEY", Type: TextSearch},
+	}
+	text := "tok_12 KEY=abc tok_3"
+	expected := NewMulti(patterns).Match(text, 5)
+
+	// the regexes are searched one by one when the combined regex could not be compiled
+	m := NewMulti(patterns)
+	m.combined = nil
+	results := m.Match(text, 5)
+	require.Len(t, results, len(patterns))
+	for i := range patterns {
+		assert.Equal(t, positions(expected[i]), positions(results[i]), "pattern %d", i)
+	}
+	assert.Equal(t, []string{"1:1-6", "1:16-20"}, positions(results[0]))
+}
+
+func TestMultiMatchLikeText(t *testing.T) {
+	// the matches of the literals are the same as the ones of Text
+	random := rand.New(rand.NewSource(1))
+	word := func(n int) string {
+		var b strings.Builder
+		for range n {
+			b.WriteByte("abc\n"[random.Intn(4)])
+		}
+		return b.String()
+	}
+	for i := range 50 {
+		text := word(200)
+		var patterns []Pattern
+		for range 10 {
+			patterns = append(patterns, Pattern{Search: strings.ReplaceAll(word(1+random.Intn(4)), "\n", "a"), Type: TextSearch})
+		}
+		results := NewMulti(patterns).Match(text, 10)
+		for j, p := range patterns {
+			_, _, expected := NewText().Match(text, p.Search, TextSearch, 10)
+			assert.Equal(t, positions(expected), positions(results[j]), "text %d, pattern %q", i, p.Search)
+		}
+	}
+}
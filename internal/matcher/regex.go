@@ -0,0 +1,113 @@
+package matcher
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+)
+
+// RegexError is an invalid regex with the position of the error in the pattern
+type RegexError struct {
+	Pattern string
+	// Position is the 1-based position of the invalid part of the pattern, 0 when it is unknown
+	Position int
+	Err      error
+}
+
+func (e *RegexError) Error() string {
+	var syntaxErr *syntax.Error
+	if errors.As(e.Err, &syntaxErr) {
+		if e.Position > 0 {
+			return fmt.Sprintf("invalid regex %q at position %d: %s `%s`", e.Pattern, e.Position, syntaxErr.Code, syntaxErr.Expr)
+		}
+		return fmt.Sprintf("invalid regex %q: %s `%s`", e.Pattern, syntaxErr.Code, syntaxErr.Expr)
+	}
+	return fmt.Sprintf("invalid regex %q: %s", e.Pattern, e.Err)
+}
+
+func (e *RegexError) Unwrap() error {
+	return e.Err
+}
+
+// CompileRegex compiles a regex, an invalid pattern returns a *RegexError with the position of the error
+func CompileRegex(pattern string) (*regexp.Regexp, error) {
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		regexErr := &RegexError{Pattern: pattern, Err: err}
+		var syntaxErr *syntax.Error
+		if errors.As(err, &syntaxErr) {
+			regexErr.Position = errorPosition(pattern, syntaxErr)
+		}
+		return nil, regexErr
+	}
+	return regex, nil
+}
+
+// errorPosition returns the 1-based position of a syntax error in the pattern, 0 when it is unknown
+func errorPosition(pattern string, err *syntax.Error) int {
+	// the expression of a parenthesis error is the whole pattern, so the parenthesis is searched
+	if err.Code == syntax.ErrMissingParen || err.Code == syntax.ErrUnexpectedParen {
+		return parenPosition(pattern, err.Code)
+	}
+	if err.Expr == "" {
+		return 0
+	}
+	return strings.Index(pattern, err.Expr) + 1
+}
+
+// parenPosition returns the position of the first unexpected ) or of the last unclosed (, escapes and character classes are skipped
+func parenPosition(pattern string, code syntax.ErrorCode) int {
+	var open []int
+	inClass := false
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == '\\':
+			i++
+		case inClass:
+			inClass = c != ']'
+		case c == '[':
+			inClass = true
+			// a ] right after [ or [^ is a literal
+			if strings.HasPrefix(pattern[i+1:], "^") {
+				i++
+			}
+			if strings.HasPrefix(pattern[i+1:], "]") {
+				i++
+			}
+		case c == '(':
+			open = append(open, i)
+		case c == ')':
+			if len(open) == 0 {
+				if code == syntax.ErrUnexpectedParen {
+					return i + 1
+				}
+				continue
+			}
+			open = open[:len(open)-1]
+		}
+	}
+	if code == syntax.ErrMissingParen && len(open) > 0 {
+		return open[len(open)-1] + 1
+	}
+	return 0
+}
+
+// CompileRegexes compiles the patterns before a search, the errors of all invalid patterns are joined
+func CompileRegexes(patterns []string) ([]*regexp.Regexp, error) {
+	var regexes []*regexp.Regexp
+	var errs []error
+	for _, pattern := range patterns {
+		regex, err := CompileRegex(pattern)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		regexes = append(regexes, regex)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return regexes, nil
+}
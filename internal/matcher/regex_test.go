@@ -0,0 +1,53 @@
+//go:build unit
+
+package matcher
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileRegex(t *testing.T) {
+	regex, err := CompileRegex(`tok_[0-9]+`)
+	require.NoError(t, err)
+	assert.True(t, regex.MatchString("tok_1"))
+}
+
+func TestCompileRegexErrors(t *testing.T) {
+	tests := []struct {
+		pattern          string
+		expectedPosition int
+		expected         string
+	}{
+		{pattern: "abc[", expectedPosition: 4, expected: "invalid regex \"abc[\" at position 4: missing closing ] `[`"},
+		{pattern: "(a", expectedPosition: 1, expected: "invalid regex \"(a\" at position 1: missing closing ) `(a`"},
+		{pattern: `(a(b)[(]\(`, expectedPosition: 1, expected: "invalid regex \"(a(b)[(]\\\\(\" at position 1: missing closing ) `(a(b)[(]\\(`"},
+		{pattern: "ab)c", expectedPosition: 3, expected: "invalid regex \"ab)c\" at position 3: unexpected ) `ab)c`"},
+		{pattern: `[)](a))`, expectedPosition: 7, expected: "invalid regex \"[)](a))\" at position 7: unexpected ) `[)](a))`"},
+		{pattern: "a**", expectedPosition: 2, expected: "invalid regex \"a**\" at position 2: invalid nested repetition operator `**`"},
+		{pattern: `x\q`, expectedPosition: 2, expected: "invalid regex \"x\\\\q\" at position 2: invalid escape sequence `\\q`"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			_, err := CompileRegex(tt.pattern)
+			var regexErr *RegexError
+			require.ErrorAs(t, err, &regexErr)
+			assert.Equal(t, tt.expectedPosition, regexErr.Position)
+			assert.EqualError(t, err, tt.expected)
+		})
+	}
+}
+
+func TestCompileRegexes(t *testing.T) {
+	regexes, err := CompileRegexes([]string{"a", "b+"})
+	require.NoError(t, err)
+	require.Len(t, regexes, 2)
+	assert.Equal(t, "b+", regexes[1].String())
+
+	regexes, err = CompileRegexes([]string{"a", "(", "b", "["})
+	assert.Nil(t, regexes)
+	assert.EqualError(t, err, "invalid regex \"(\" at position 1: missing closing ) `(`\ninvalid regex \"[\" at position 1: missing closing ] `[`")
+}
@@ -0,0 +1,411 @@
+package matcher
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type Severity string
+
+const (
+	SeverityLow      Severity = "low"
+	SeverityMedium   Severity = "medium"
+	SeverityHigh     Severity = "high"
+	SeverityCritical Severity = "critical"
+)
+
+// DefaultRulePack is the name of the bundled rule pack detecting common secrets
+const DefaultRulePack = "default"
+
+//go:embed rules/*.yaml
+var bundledRulePacks embed.FS
+
+// Rule detects one kind of secret by a regex
+type Rule struct {
+	ID          string `yaml:"id"`
+	Description string `yaml:"description"`
+	Regex       string `yaml:"regex"`
+	// SecretGroup is the capture group of the secret, 0 is the first non-empty group or the whole match
+	SecretGroup int `yaml:"secretGroup"`
+	// Entropy is the minimum Shannon entropy of the secret, 0 disables the check
+	Entropy float64 `yaml:"entropy"`
+	// Path limits the rule to the files whose path (relative to the repository or scanned directory) matches this regex
+	Path string `yaml:"path"`
+	// Keywords prefilter the content, the regex only runs when the content contains one of them (case-insensitive)
+	Keywords   []string    `yaml:"keywords"`
+	Severity   Severity    `yaml:"severity"`
+	Allowlists []Allowlist `yaml:"allowlists"`
+
+	regex *regexp.Regexp
+	path  *regexp.Regexp
+}
+
+// Targets of the regexes of an allowlist
+const (
+	TargetSecret = "secret"
+	TargetMatch  = "match"
+	TargetLine   = "line"
+)
+
+// Conditions combining the criteria of an allowlist
+const (
+	ConditionOr  = "or"
+	ConditionAnd = "and"
+)
+
+// Allowlist ignores the matches of a rule, which are known to be no secrets
+type Allowlist struct {
+	Description string `yaml:"description"`
+	// Condition is ConditionOr (any criterion allows a match) or ConditionAnd (all criteria which are set)
+	Condition string `yaml:"condition"`
+	// RegexTarget is the part of a match the regexes are matched against: TargetSecret, TargetMatch or TargetLine
+	RegexTarget string   `yaml:"regexTarget"`
+	Regexes     []string `yaml:"regexes"`
+	Paths       []string `yaml:"paths"`
+	// Stopwords allow secrets containing one of them (case-insensitive)
+	Stopwords []string `yaml:"stopwords"`
+	Commits   []string `yaml:"commits"`
+
+	regexes []*regexp.Regexp
+	paths   []*regexp.Regexp
+}
+
+// RulePack is a versioned set of rules, bundled with deep-scan or loaded from a file
+type RulePack struct {
+	Name    string `yaml:"-"`
+	Version string `yaml:"version"`
+	Rules   []Rule `yaml:"rules"`
+	// Allowlists apply to all rules of the pack
+	Allowlists []Allowlist `yaml:"allowlists"`
+}
+
+// Location is the file the content matched by the rules belongs to
+type Location struct {
+	// Path is relative to the repository or the scanned directory
+	Path   string
+	Commit string
+}
+
+// BundledRulePacks returns the names of the rule packs bundled with deep-scan
+func BundledRulePacks() []string {
+	entries, _ := bundledRulePacks.ReadDir("rules")
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".yaml"))
+	}
+	return names
+}
+
+// BundledRulePack loads a rule pack bundled with deep-scan by its name
+func BundledRulePack(name string) (*RulePack, error) {
+	data, err := bundledRulePacks.ReadFile("rules/" + name + ".yaml")
+	if err != nil {
+		return nil, fmt.Errorf("unknown rule pack %q, bundled rule packs: %s", name, strings.Join(BundledRulePacks(), ", "))
+	}
+	return ParseRulePack(name, data)
+}
+
+/*
+LoadRulePack loads a bundled rule pack by its name or a rule pack file.
+Files ending with .toml are gitleaks configurations, YAML files with detectors are trufflehog custom detectors,
+other YAML files use the format of the bundled rule packs.
+*/
+func LoadRulePack(source string) (*RulePack, error) {
+	if slices.Contains(BundledRulePacks(), source) {
+		return BundledRulePack(source)
+	}
+	data, err := os.ReadFile(source)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) && filepath.Ext(source) == "" {
+			return BundledRulePack(source)
+		}
+		return nil, fmt.Errorf("reading rule pack %s failed: %w", source, err)
+	}
+	switch {
+	case isGitleaksConfig(source):
+		return ParseGitleaksConfig(source, data)
+	case isTrufflehogConfig(data):
+		return ParseTrufflehogConfig(source, data)
+	default:
+		return ParseRulePack(source, data)
+	}
+}
+
+// ParseRulePack parses and validates a rule pack in the YAML format of the bundled rule packs
+func ParseRulePack(name string, data []byte) (*RulePack, error) {
+	pack := &RulePack{Name: name}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(pack); err != nil {
+		return nil, fmt.Errorf("parsing rule pack %s failed: %w", name, err)
+	}
+	if pack.Version == "" {
+		return nil, fmt.Errorf("rule pack %s has no version", name)
+	}
+	if err := pack.compile(); err != nil {
+		return nil, err
+	}
+	return pack, nil
+}
+
+// compile validates the rules and allowlists of the pack and compiles their regexes
+func (p *RulePack) compile() error {
+	var errs []error
+	for i := range p.Rules {
+		if err := p.Rules[i].compile(); err != nil {
+			errs = append(errs, fmt.Errorf("rule pack %s: %w", p.Name, err))
+		}
+	}
+	for i := range p.Allowlists {
+		if err := p.Allowlists[i].compile(); err != nil {
+			errs = append(errs, fmt.Errorf("rule pack %s: allowlist: %w", p.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Rule) compile() error {
+	if r.ID == "" {
+		return errors.New("rule without id")
+	}
+	if r.Regex == "" {
+		return fmt.Errorf("rule %s has no regex", r.ID)
+	}
+	switch r.Severity {
+	case SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical:
+	case "":
+		r.Severity = SeverityMedium
+	default:
+		return fmt.Errorf("rule %s has the invalid severity %q (low, medium, high, critical)", r.ID, r.Severity)
+	}
+	regex, err := CompileRegex(r.Regex)
+	if err != nil {
+		return fmt.Errorf("rule %s has an invalid regex: %w", r.ID, err)
+	}
+	r.regex = regex
+	if r.SecretGroup < 0 || r.SecretGroup > regex.NumSubexp() {
+		return fmt.Errorf("rule %s has the secret group %d, but its regex has %d groups", r.ID, r.SecretGroup, regex.NumSubexp())
+	}
+	if r.Path != "" {
+		if r.path, err = CompileRegex(r.Path); err != nil {
+			return fmt.Errorf("rule %s has an invalid path regex: %w", r.ID, err)
+		}
+	}
+	for i, keyword := range r.Keywords {
+		r.Keywords[i] = strings.ToLower(keyword)
+	}
+	for i := range r.Allowlists {
+		if err := r.Allowlists[i].compile(); err != nil {
+			return fmt.Errorf("rule %s: allowlist: %w", r.ID, err)
+		}
+	}
+	return nil
+}
+
+func (a *Allowlist) compile() error {
+	a.Condition = strings.ToLower(a.Condition)
+	switch a.Condition {
+	case "":
+		a.Condition = ConditionOr
+	case ConditionOr, ConditionAnd:
+	default:
+		return fmt.Errorf("invalid condition %q (or, and)", a.Condition)
+	}
+	switch a.RegexTarget {
+	case "":
+		a.RegexTarget = TargetSecret
+	case TargetSecret, TargetMatch, TargetLine:
+	default:
+		return fmt.Errorf("invalid regex target %q (secret, match, line)", a.RegexTarget)
+	}
+	a.regexes = nil
+	for _, expr := range a.Regexes {
+		regex, err := CompileRegex(expr)
+		if err != nil {
+			return fmt.Errorf("invalid regex: %w", err)
+		}
+		a.regexes = append(a.regexes, regex)
+	}
+	a.paths = nil
+	for _, expr := range a.Paths {
+		regex, err := CompileRegex(expr)
+		if err != nil {
+			return fmt.Errorf("invalid path regex: %w", err)
+		}
+		a.paths = append(a.paths, regex)
+	}
+	for i, stopword := range a.Stopwords {
+		a.Stopwords[i] = strings.ToLower(stopword)
+	}
+	return nil
+}
+
+// allows reports if the allowlist ignores a match, the criteria which are not set are skipped
+func (a *Allowlist) allows(location Location, secret, match, line string) bool {
+	var results []bool
+	if len(a.Commits) > 0 {
+		results = append(results, location.Commit != "" && slices.Contains(a.Commits, location.Commit))
+	}
+	if len(a.paths) > 0 {
+		results = append(results, matchesAny(a.paths, location.Path))
+	}
+	if len(a.regexes) > 0 {
+		target := secret
+		switch a.RegexTarget {
+		case TargetMatch:
+			target = match
+		case TargetLine:
+			target = line
+		}
+		results = append(results, matchesAny(a.regexes, target))
+	}
+	if len(a.Stopwords) > 0 {
+		results = append(results, containsAny(strings.ToLower(secret), a.Stopwords))
+	}
+	if len(results) == 0 {
+		return false
+	}
+	if a.Condition == ConditionAnd {
+		return !slices.Contains(results, false)
+	}
+	return slices.Contains(results, true)
+}
+
+func matchesAny(regexes []*regexp.Regexp, text string) bool {
+	for _, regex := range regexes {
+		if regex.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// Rules matches the rules of one or more rule packs
+type Rules struct {
+	rules []Rule
+}
+
+// NewRules combines rule packs, the rule IDs have to be unique across all packs
+func NewRules(packs ...*RulePack) (*Rules, error) {
+	rules := &Rules{}
+	seen := map[string]string{}
+	for _, pack := range packs {
+		for _, rule := range pack.Rules {
+			if other, ok := seen[rule.ID]; ok {
+				return nil, fmt.Errorf("rule %s of rule pack %s is already defined by rule pack %s", rule.ID, pack.Name, other)
+			}
+			seen[rule.ID] = pack.Name
+			// the allowlists of a pack only apply to its own rules
+			rule.Allowlists = append(slices.Clip(rule.Allowlists), pack.Allowlists...)
+			rules.rules = append(rules.rules, rule)
+		}
+	}
+	sort.SliceStable(rules.rules, func(i, j int) bool { return rules.rules[i].ID < rules.rules[j].ID })
+	return rules, nil
+}
+
+// List returns the rules sorted by their ID
+func (r *Rules) List() []Rule {
+	return r.rules
+}
+
+// Rule returns the rule with the ID
+func (r *Rules) Rule(id string) (Rule, bool) {
+	i := sort.Search(len(r.rules), func(i int) bool { return r.rules[i].ID >= id })
+	if i < len(r.rules) && r.rules[i].ID == id {
+		return r.rules[i], true
+	}
+	return Rule{}, false
+}
+
+/*
+Match returns the matches of all rules in the text of a file, the matches carry the ID and the severity of their rule.
+Matches of secrets below the entropy of their rule and matches allowed by an allowlist are skipped.
+*/
+func (r *Rules) Match(location Location, text string, context int) []MatchResult {
+	var results []MatchResult
+	lower := ""
+	for _, rule := range r.rules {
+		if rule.path != nil && !rule.path.MatchString(location.Path) {
+			continue
+		}
+		if len(rule.Keywords) > 0 {
+			if lower == "" {
+				lower = strings.ToLower(text)
+			}
+			if !containsAny(lower, rule.Keywords) {
+				continue
+			}
+		}
+		for _, loc := range rule.regex.FindAllStringSubmatchIndex(text, -1) {
+			if loc[0] == loc[1] {
+				continue
+			}
+			secret := rule.secret(text, loc)
+			if rule.Entropy > 0 && ShannonEntropy(secret) < rule.Entropy {
+				continue
+			}
+			if rule.allowed(location, text, secret, loc) {
+				continue
+			}
+			result := createMatchResult(text, compiledPattern{search: rule.Regex, regex: rule.regex}, loc[0], loc[1]-loc[0], context)
+			result.RuleID = rule.ID
+			result.Severity = rule.Severity
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// secret returns the secret of a match: its secret group, its first non-empty group or the whole match
+func (r *Rule) secret(text string, loc []int) string {
+	if r.SecretGroup > 0 {
+		if start := loc[2*r.SecretGroup]; start >= 0 {
+			return text[start:loc[2*r.SecretGroup+1]]
+		}
+		return ""
+	}
+	for group := 1; 2*group < len(loc); group++ {
+		if start, end := loc[2*group], loc[2*group+1]; start >= 0 && end > start {
+			return text[start:end]
+		}
+	}
+	return text[loc[0]:loc[1]]
+}
+
+func (r *Rule) allowed(location Location, text, secret string, loc []int) bool {
+	if len(r.Allowlists) == 0 {
+		return false
+	}
+	lineStart := strings.LastIndexByte(text[:loc[0]], '\n') + 1
+	lineEnd := len(text)
+	if i := strings.IndexByte(text[loc[1]:], '\n'); i >= 0 {
+		lineEnd = loc[1] + i
+	}
+	for i := range r.Allowlists {
+		if r.Allowlists[i].allows(location, secret, text[loc[0]:loc[1]], text[lineStart:lineEnd]) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAny(text string, keywords []string) bool {
+	for _, keyword := range keywords {
+		if strings.Contains(text, keyword) {
+			return true
+		}
+	}
+	return false
+}
@@ -3,6 +3,7 @@ package matcher
 import (
 	"regexp"
 	"strings"
+	"unicode/utf8"
 )
 
 type MatchResult struct {
@@ -13,6 +14,24 @@ type MatchResult struct {
 	FormattedSnippet           string `json:"formattedSnippet"`
 	CompressedFormattedSnippet string `json:"compressedFormattedSnippet"`
 	ExactMatch                 bool   `json:"exactMatch"`
+	// KeyPath is the key of the value the match was found in, only set for decrypted SOPS documents
+	KeyPath string `json:"keyPath,omitempty"`
+	// Rule and Search are the search option and the pattern the match was found by
+	Rule   string `json:"rule,omitempty"`
+	Search string `json:"search,omitempty"`
+	// RuleID and Severity are only set for matches of a rule pack
+	RuleID   string   `json:"ruleId,omitempty"`
+	Severity Severity `json:"severity,omitempty"`
+	// Fingerprint identifies the match independent of its line, it is used by baselines
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// ReplaceSnippet replaces the snippets of the result (e.g. with a redacted one), the replaced snippet is not highlighted
+func (m *MatchResult) ReplaceSnippet(snippet string) {
+	gray, reset := "\033[90m", "\033[0m"
+	m.Snippet = snippet
+	m.FormattedSnippet = gray + snippet + reset
+	m.CompressedFormattedSnippet = compressSnippet(m.FormattedSnippet)
 }
 
 type SearchType string
@@ -28,31 +47,49 @@ func NewText() Text {
 	return Text{}
 }
 
+// compiledPattern is a search with its compiled regex, the regex is nil for text searches
+type compiledPattern struct {
+	search string
+	regex  *regexp.Regexp
+}
+
+// Match searches the text for a text search or a regex search, a regex search panics on an invalid regex like regexp.MustCompile
 func (t Text) Match(text, search string, searchType SearchType, context int) (bool, bool, []MatchResult) {
-	if exactMatch, results := checkExactMatch(text, search, searchType); exactMatch {
+	if searchType == RegexSearch {
+		return t.MatchRegex(text, regexp.MustCompile(search), context)
+	}
+	return match(text, compiledPattern{search: search}, context)
+}
+
+// MatchRegex searches the text for a regex compiled before the search
+func (t Text) MatchRegex(text string, regex *regexp.Regexp, context int) (bool, bool, []MatchResult) {
+	return match(text, compiledPattern{search: regex.String(), regex: regex}, context)
+}
+
+func match(text string, p compiledPattern, context int) (bool, bool, []MatchResult) {
+	if exactMatch, results := checkExactMatch(text, p); exactMatch {
 		return true, true, results
 	}
 
-	return findMatches(text, search, searchType, context)
+	return findMatches(text, p, context)
 }
 
-func checkExactMatch(text, search string, searchType SearchType) (bool, []MatchResult) {
-	if searchType == TextSearch && search == text {
-		return true, []MatchResult{createExactMatchResult(text, search, searchType)}
+func checkExactMatch(text string, p compiledPattern) (bool, []MatchResult) {
+	if p.regex == nil && p.search == text {
+		return true, []MatchResult{createExactMatchResult(text, p)}
 	}
 
-	if searchType == RegexSearch {
-		re, _ := regexp.Compile(search)
-		if re.MatchString(text) && re.FindString(text) == text {
-			return true, []MatchResult{createExactMatchResult(text, search, searchType)}
+	if p.regex != nil {
+		if loc := p.regex.FindStringIndex(text); loc != nil && loc[0] == 0 && loc[1] == len(text) {
+			return true, []MatchResult{createExactMatchResult(text, p)}
 		}
 	}
 
 	return false, nil
 }
 
-func createExactMatchResult(text, search string, searchType SearchType) MatchResult {
-	formattedSnippet := formatSnippet(text, search, searchType)
+func createExactMatchResult(text string, p compiledPattern) MatchResult {
+	formattedSnippet := formatSnippet(text, p)
 	compressedSnippet := compressSnippet(formattedSnippet)
 	return MatchResult{
 		ExactMatch:                 true,
@@ -65,18 +102,25 @@ func createExactMatchResult(text, search string, searchType SearchType) MatchRes
 	}
 }
 
-func findMatches(text, search string, searchType SearchType, context int) (bool, bool, []MatchResult) {
+// findMatches returns the matches of the pattern which do not overlap, empty matches (e.g. of x*) are skipped
+func findMatches(text string, p compiledPattern, context int) (bool, bool, []MatchResult) {
 	var results []MatchResult
 	start := 0
 
 	for {
-		index, matchLength := getNextMatch(text, search, searchType, start)
+		index, matchLength := getNextMatch(text, p, start)
 		if index == -1 {
 			break
 		}
 
-		results = append(results, createMatchResult(text, search, searchType, index, matchLength, context))
-		start = index + matchLength
+		if matchLength == 0 {
+			// the next search starts one rune later, otherwise it finds the same empty match again
+			_, size := utf8.DecodeRuneInString(text[index:])
+			start = index + max(size, 1)
+		} else {
+			results = append(results, createMatchResult(text, p, index, matchLength, context))
+			start = index + matchLength
+		}
 
 		if start >= len(text) {
 			break
@@ -86,25 +130,24 @@ func findMatches(text, search string, searchType SearchType, context int) (bool,
 	return len(results) > 0, false, results
 }
 
-func getNextMatch(text, search string, searchType SearchType, start int) (int, int) {
-	if searchType == RegexSearch {
-		re := regexp.MustCompile(search)
-		loc := re.FindStringIndex(text[start:])
+func getNextMatch(text string, p compiledPattern, start int) (int, int) {
+	if p.regex != nil {
+		loc := p.regex.FindStringIndex(text[start:])
 		if loc == nil {
 			return -1, 0
 		}
 		return loc[0] + start, loc[1] - loc[0]
 	}
 
-	index := strings.Index(text[start:], search)
+	index := strings.Index(text[start:], p.search)
 	if index == -1 {
 		return -1, 0
 	}
 
-	return index + start, len(search)
+	return index + start, len(p.search)
 }
 
-func createMatchResult(text, search string, searchType SearchType, index, matchLength, context int) MatchResult {
+func createMatchResult(text string, p compiledPattern, index, matchLength, context int) MatchResult {
 	endIndex := index + matchLength
 	startContext := max(0, index-context)
 	endContext := min(len(text), endIndex+context)
@@ -118,7 +161,7 @@ func createMatchResult(text, search string, searchType SearchType, index, matchL
 	}
 
 	line, startCol, endCol := calculatePosition(text, index, endIndex)
-	formattedSnippet := formatSnippet(snippet, search, searchType)
+	formattedSnippet := formatSnippet(snippet, p)
 	compressedSnippet := compressSnippet(formattedSnippet)
 
 	return MatchResult{
@@ -151,24 +194,19 @@ func compressSnippet(snippet string) string {
 	return strings.Join(strings.Fields(snippet), " ")
 }
 
-func formatSnippet(snippet, search string, searchType SearchType) string {
+func formatSnippet(snippet string, p compiledPattern) string {
 	green, gray, reset := "\033[32m", "\033[90m", "\033[0m"
 
-	if searchType == TextSearch {
-		if index := strings.Index(snippet, search); index != -1 {
-			return gray + snippet[:index] + green + snippet[index:index+len(search)] + gray + snippet[index+len(search):] + reset
+	if p.regex == nil {
+		if index := strings.Index(snippet, p.search); index != -1 {
+			return gray + snippet[:index] + green + snippet[index:index+len(p.search)] + gray + snippet[index+len(p.search):] + reset
 		}
 		return gray + snippet + reset
 	}
 
-	re, err := regexp.Compile(search)
-	if err != nil {
-		return gray + snippet + reset
-	}
-
 	var result strings.Builder
 	lastIndex := 0
-	matches := re.FindAllStringIndex(snippet, -1)
+	matches := p.regex.FindAllStringIndex(snippet, -1)
 
 	for _, match := range matches {
 		start, end := match[0], match[1]
@@ -4,9 +4,11 @@ package matcher
 
 import (
 	"fmt"
+	"regexp"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMatchContentText(t *testing.T) {
@@ -124,6 +126,22 @@ func TestMatchContentRegex(t *testing.T) {
 			expectedLen:        1,
 			expectedExactMatch: true,
 		},
+		{
+			name:               "Empty matches are skipped",
+			content:            "äxöxxü",
+			search:             `x*`,
+			context:            5,
+			expectedLen:        2,
+			expectedExactMatch: false,
+		},
+		{
+			name:               "Optional regex",
+			content:            "ü and b",
+			search:             `a?`,
+			context:            5,
+			expectedLen:        1,
+			expectedExactMatch: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -137,3 +155,21 @@ func TestMatchContentRegex(t *testing.T) {
 		})
 	}
 }
+
+func TestMatchRegex(t *testing.T) {
+	m := NewText()
+	regex := regexp.MustCompile(`tok_\d+`)
+
+	matched, exactMatch, results := m.MatchRegex("a: tok_1, b: tok_22", regex, 5)
+	assert.True(t, matched)
+	assert.False(t, exactMatch)
+	require.Len(t, results, 2)
+	assert.Equal(t, 14, results[1].StartCol)
+	assert.Equal(t, 19, results[1].EndCol)
+
+	_, exactMatch, _ = m.MatchRegex("tok_1", regex, 5)
+	assert.True(t, exactMatch)
+
+	// invalid regexes are rejected when the options are built, Match does not hide them
+	assert.Panics(t, func() { m.Match("(and", "(and", RegexSearch, 5) })
+}
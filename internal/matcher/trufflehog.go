@@ -0,0 +1,82 @@
+package matcher
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// trufflehogVersion is the version of the rule packs converted from trufflehog custom detectors
+const trufflehogVersion = "trufflehog"
+
+type trufflehogConfig struct {
+	Detectors []trufflehogDetector `yaml:"detectors"`
+}
+
+type trufflehogDetector struct {
+	Name                  string            `yaml:"name"`
+	Keywords              []string          `yaml:"keywords"`
+	Regex                 map[string]string `yaml:"regex"`
+	Entropy               float64           `yaml:"entropy"`
+	ExcludeWords          []string          `yaml:"exclude_words"`
+	ExcludeRegexesCapture []string          `yaml:"exclude_regexes_capture"`
+	ExcludeRegexesMatch   []string          `yaml:"exclude_regexes_match"`
+}
+
+/*
+ParseTrufflehogConfig converts the custom detectors of a trufflehog configuration into a rule pack.
+Every regex of a detector becomes a rule named <detector> or, for detectors with several regexes, <detector>.<regex name>.
+Excluded words and regexes become allowlists of the rules, verification is not supported.
+*/
+func ParseTrufflehogConfig(name string, data []byte) (*RulePack, error) {
+	var config trufflehogConfig
+	if err := yaml.NewDecoder(bytes.NewReader(data)).Decode(&config); err != nil {
+		return nil, fmt.Errorf("parsing trufflehog config %s failed: %w", name, err)
+	}
+	pack := &RulePack{Name: name, Version: trufflehogVersion}
+	for _, detector := range config.Detectors {
+		var allowlists []Allowlist
+		if len(detector.ExcludeWords) > 0 || len(detector.ExcludeRegexesCapture) > 0 {
+			allowlists = append(allowlists, Allowlist{Stopwords: detector.ExcludeWords, Regexes: detector.ExcludeRegexesCapture})
+		}
+		if len(detector.ExcludeRegexesMatch) > 0 {
+			allowlists = append(allowlists, Allowlist{RegexTarget: TargetMatch, Regexes: detector.ExcludeRegexesMatch})
+		}
+		keys := make([]string, 0, len(detector.Regex))
+		for key := range detector.Regex {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			id := detector.Name
+			if len(keys) > 1 {
+				id += "." + key
+			}
+			pack.Rules = append(pack.Rules, Rule{
+				ID:          id,
+				Description: fmt.Sprintf("trufflehog detector %s", detector.Name),
+				Regex:       detector.Regex[key],
+				Entropy:     detector.Entropy,
+				Keywords:    detector.Keywords,
+				Allowlists:  slices.Clone(allowlists),
+			})
+		}
+	}
+	if err := pack.compile(); err != nil {
+		return nil, err
+	}
+	return pack, nil
+}
+
+// isTrufflehogConfig reports if a YAML rule pack file holds trufflehog custom detectors
+func isTrufflehogConfig(data []byte) bool {
+	var probe map[string]any
+	if err := yaml.Unmarshal(data, &probe); err != nil {
+		return false
+	}
+	_, ok := probe["detectors"]
+	return ok
+}
@@ -0,0 +1,110 @@
+package redact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type Mode string
+
+const (
+	// None prints secrets in plaintext
+	None Mode = "none"
+	// Mask replaces the whole secret
+	Mask Mode = "mask"
+	// Partial reveals the first and last characters of the secret
+	Partial Mode = "partial"
+	// Hash replaces the secret with a salted hash, so findings can be correlated without exposing the value
+	Hash Mode = "hash"
+)
+
+const mask = "********"
+
+// hashLength is the number of hex characters of the hash printed, enough to correlate findings
+const hashLength = 16
+
+type Redactor struct {
+	Mode Mode
+	// Reveal is the number of characters shown at the start and end of a secret in Partial mode
+	Reveal int
+	// Salt is prepended to the secret before hashing in Hash mode
+	Salt string
+}
+
+// Span is the position of a secret value in a text, End is exclusive
+type Span struct {
+	Start int
+	End   int
+	Value string
+}
+
+func ParseMode(mode string) (Mode, error) {
+	switch m := Mode(strings.ToLower(mode)); m {
+	case None, Mask, Partial, Hash:
+		return m, nil
+	}
+	return "", fmt.Errorf("unsupported redaction mode: %s (supported: %s, %s, %s, %s)", mode, None, Mask, Partial, Hash)
+}
+
+func (r Redactor) Enabled() bool {
+	return r.Mode != "" && r.Mode != None
+}
+
+// Redact returns the redacted form of a secret value
+func (r Redactor) Redact(value string) string {
+	switch r.Mode {
+	case Mask:
+		return mask
+	case Partial:
+		// values too short to reveal anything without exposing most of them are masked
+		if r.Reveal <= 0 || len(value) <= 4*r.Reveal {
+			return mask
+		}
+		return value[:r.Reveal] + mask + value[len(value)-r.Reveal:]
+	case Hash:
+		sum := sha256.Sum256([]byte(r.Salt + value))
+		return "sha256:" + hex.EncodeToString(sum[:])[:hashLength]
+	}
+	return value
+}
+
+/*
+RedactWindow returns the text between start and end with every secret overlapping it redacted.
+Secrets are always redacted as a whole (even if they are only partially in the window),
+so partial reveals and hashes are independent of the window.
+*/
+func (r Redactor) RedactWindow(text string, start, end int, secrets []Span) string {
+	if !r.Enabled() {
+		return text[start:end]
+	}
+	sorted := make([]Span, len(secrets))
+	copy(sorted, secrets)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Start < sorted[j].Start
+	})
+
+	var result strings.Builder
+	position := start
+	for _, secret := range sorted {
+		if secret.End <= position || secret.Start >= end {
+			continue
+		}
+		// overlapping secrets are hidden by the redaction of the previous one
+		if secret.Start < position && position > start {
+			position = secret.End
+			continue
+		}
+		if secret.Start > position {
+			result.WriteString(text[position:secret.Start])
+		}
+		result.WriteString(r.Redact(secret.Value))
+		position = secret.End
+	}
+	if position < end {
+		result.WriteString(text[position:end])
+	}
+	return result.String()
+}
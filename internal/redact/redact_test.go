@@ -0,0 +1,47 @@
+//go:build unit
+
+package redact
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedact(t *testing.T) {
+	secret := "glpat-1234567890abcdef"
+
+	assert.Equal(t, secret, Redactor{Mode: None}.Redact(secret))
+	assert.Equal(t, "********", Redactor{Mode: Mask}.Redact(secret))
+	assert.Equal(t, "glpa********cdef", Redactor{Mode: Partial, Reveal: 4}.Redact(secret))
+	assert.Equal(t, "********", Redactor{Mode: Partial, Reveal: 4}.Redact("changeme"))
+
+	hash := Redactor{Mode: Hash, Salt: "salt"}.Redact(secret)
+	assert.Regexp(t, `^sha256:[0-9a-f]{16}$`, hash)
+	assert.Equal(t, hash, Redactor{Mode: Hash, Salt: "salt"}.Redact(secret))
+	assert.NotEqual(t, hash, Redactor{Mode: Hash, Salt: "other"}.Redact(secret))
+}
+
+func TestRedactWindow(t *testing.T) {
+	text := "user: admin\npassword: changeme\n"
+	secrets := []Span{
+		{Start: 6, End: 11, Value: "admin"},
+		{Start: 22, End: 30, Value: "changeme"},
+	}
+	r := Redactor{Mode: Mask}
+
+	assert.Equal(t, "user: ********\npassword: ********\n", r.RedactWindow(text, 0, len(text), secrets))
+	// secrets partially in the window are redacted as a whole
+	assert.Equal(t, "********\npass", r.RedactWindow(text, 8, 16, secrets))
+	assert.Equal(t, text[8:16], Redactor{Mode: None}.RedactWindow(text, 8, 16, secrets))
+}
+
+func TestParseMode(t *testing.T) {
+	mode, err := ParseMode("Partial")
+	require.NoError(t, err)
+	assert.Equal(t, Partial, mode)
+
+	_, err = ParseMode("rot13")
+	assert.Error(t, err)
+}
@@ -1,16 +1,26 @@
 package scanner
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
-	"github.com/alican-uelger/deep-scan/internal/matcher"
+	"log/slog"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"sync"
+	"time"
+
+	"github.com/alican-uelger/deep-scan/internal/matcher"
+	"github.com/alican-uelger/deep-scan/internal/sops"
+	"github.com/alican-uelger/deep-scan/internal/worker"
 )
 
 const contextLength = 15
 
 type TextMatcher interface {
 	Match(text, search string, searchType matcher.SearchType, context int) (bool, bool, []matcher.MatchResult)
+	MatchRegex(text string, regex *regexp.Regexp, context int) (bool, bool, []matcher.MatchResult)
 }
 
 type Base struct {
@@ -64,7 +74,7 @@ func (s *Base) filterFile(file File, options SearchOptions) (bool, []matcher.Mat
 	if len(options.Name) > 0 {
 		for _, name := range options.Name {
 			_, exactMatch, matches := s.TextMatcher.Match(file.Name, name, matcher.TextSearch, contextLength)
-			results = append(results, matches...)
+			results = append(results, withRule(matches, RuleName, name)...)
 			if !exactMatch {
 				return false, results
 			}
@@ -73,7 +83,7 @@ func (s *Base) filterFile(file File, options SearchOptions) (bool, []matcher.Mat
 	if len(options.NameContains) > 0 {
 		for _, nameContains := range options.NameContains {
 			matched, _, matches := s.TextMatcher.Match(file.Name, nameContains, matcher.TextSearch, contextLength)
-			results = append(results, matches...)
+			results = append(results, withRule(matches, RuleNameContains, nameContains)...)
 			if !matched {
 				return false, results
 			}
@@ -82,8 +92,8 @@ func (s *Base) filterFile(file File, options SearchOptions) (bool, []matcher.Mat
 
 	if len(options.NameRegex) > 0 {
 		for _, nameRegex := range options.NameRegex {
-			matched, _, matches := s.TextMatcher.Match(file.Name, nameRegex, matcher.RegexSearch, contextLength)
-			results = append(results, matches...)
+			matched, _, matches := s.TextMatcher.MatchRegex(file.Name, nameRegex, contextLength)
+			results = append(results, withRule(matches, RuleNameRegex, nameRegex.String())...)
 			if !matched {
 				return false, results
 			}
@@ -94,7 +104,7 @@ func (s *Base) filterFile(file File, options SearchOptions) (bool, []matcher.Mat
 	if len(options.Path) > 0 {
 		for _, p := range options.Path {
 			_, exactMatch, matches := s.TextMatcher.Match(file.Path, p, matcher.TextSearch, contextLength)
-			results = append(results, matches...)
+			results = append(results, withRule(matches, RulePath, p)...)
 			if !exactMatch {
 				return false, results
 			}
@@ -103,7 +113,7 @@ func (s *Base) filterFile(file File, options SearchOptions) (bool, []matcher.Mat
 	if len(options.PathContains) > 0 {
 		for _, pContains := range options.PathContains {
 			matched, _, matches := s.TextMatcher.Match(file.Path, pContains, matcher.TextSearch, contextLength)
-			results = append(results, matches...)
+			results = append(results, withRule(matches, RulePathContains, pContains)...)
 			if !matched {
 				return false, results
 			}
@@ -112,8 +122,8 @@ func (s *Base) filterFile(file File, options SearchOptions) (bool, []matcher.Mat
 
 	if len(options.PathRegex) > 0 {
 		for _, pRegex := range options.PathRegex {
-			matched, _, matches := s.TextMatcher.Match(file.Path, pRegex, matcher.RegexSearch, contextLength)
-			results = append(results, matches...)
+			matched, _, matches := s.TextMatcher.MatchRegex(file.Path, pRegex, contextLength)
+			results = append(results, withRule(matches, RulePathRegex, pRegex.String())...)
 			if !matched {
 				return false, results
 			}
@@ -137,8 +147,68 @@ func (s *Base) filterSopsContentBeforeDecryption(content string, options SearchO
 	return true, results
 }
 
-func (s *Base) filterContent(content string, options SearchOptions) (bool, []matcher.MatchResult) {
+// filterSopsKey checks if the file is SOPS encrypted with at least one of the keys (or any key if none are given).
+// The metadata is parsed without decryption.
+func (s *Base) filterSopsKey(content []byte, location string, fileType sops.FileType, options SearchOptions) (sops.Metadata, bool) {
+	metadata, err := s.Sops.Metadata(content, fileType)
+	if err != nil {
+		return metadata, false
+	}
+	if len(options.SopsKey) > 0 && len(metadata.MatchingKeys(options.SopsKey)) == 0 {
+		return metadata, false
+	}
+	slog.Debug(fmt.Sprintf("found sops file encrypted with a searched key: %s", location))
+	return metadata, true
+}
+
+func (s *Base) parseSopsConfig(fileMatch *FileMatch, content []byte, run *searchRun) {
+	location := filepath.Join(fileMatch.Path, fileMatch.Name)
+	config, err := sops.ParseConfig(content)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("parsing sops config %s failed: %s", location, err))
+		return
+	}
+	slog.Debug(fmt.Sprintf("found sops config file: %s", location))
+	fileMatch.SopsConfig = &config
+	if run.sopsPolicy != nil {
+		run.sopsPolicy.addConfig(fileMatch.File, config)
+	}
+}
+
+// contentQuery holds the multi-pattern matchers of the content filters
+type contentQuery struct {
+	// filters are the --content and --content-regex patterns, nil without them
+	filters *matcher.Multi
+	// patterns are the patterns of --content-file, nil without them
+	patterns *matcher.Multi
+}
+
+// newContentQuery compiles the content patterns, so every file is searched for all of them in a single pass
+func newContentQuery(options SearchOptions) *contentQuery {
+	query := &contentQuery{}
+	var filters []matcher.Pattern
+	for _, c := range options.Content {
+		filters = append(filters, matcher.Pattern{Search: c, Type: matcher.TextSearch})
+	}
+	for _, cRegex := range options.ContentRegex {
+		filters = append(filters, matcher.NewRegexPattern(cRegex))
+	}
+	if len(filters) > 0 {
+		query.filters = matcher.NewMulti(filters)
+	}
+	if len(options.ContentPatterns) > 0 {
+		query.patterns = matcher.NewMulti(options.ContentPatterns)
+	}
+	return query
+}
+
+/*
+filterContent matches the content filters, matches ignored by deep-scan:ignore annotations are returned separately.
+A content filter with only ignored matches does not match. The location of the file scopes the path rules and allowlists of the rules.
+*/
+func (s *Base) filterContent(location matcher.Location, content string, options SearchOptions) (bool, []matcher.MatchResult, []IgnoredMatch) {
 	var results []matcher.MatchResult
+	var ignored []IgnoredMatch
 
 	// excludes
 	if len(options.ExcludeContent) > 0 {
@@ -146,37 +216,405 @@ func (s *Base) filterContent(content string, options SearchOptions) (bool, []mat
 			matched, _, matches := s.TextMatcher.Match(content, c, matcher.TextSearch, contextLength)
 			if matched {
 				results = append(results, matches...)
-				return false, results
+				return false, results, nil
 			}
 		}
 	}
 
-	// content
-	if len(options.Content) > 0 {
-		for _, c := range options.Content {
-			matched, _, matches := s.TextMatcher.Match(content, c, matcher.TextSearch, contextLength)
+	annotations := parseAnnotations(content)
+	query := options.query
+	if query == nil {
+		query = newContentQuery(options)
+	}
+	// content, every pattern has to match
+	if query.filters != nil {
+		found := query.filters.Match(content, contextLength)
+		for i, p := range query.filters.Patterns() {
+			rule := RuleContent
+			if p.Type == matcher.RegexSearch {
+				rule = RuleContentRegex
+			}
+			matches, ignoredMatches := annotations.filter(withRule(found[i], rule, p.Search))
 			results = append(results, matches...)
-			if !matched {
-				return false, results
+			ignored = append(ignored, ignoredMatches...)
+			if len(matches) == 0 {
+				return false, results, ignored
 			}
 		}
 	}
-	if len(options.ContentRegex) > 0 {
-		for _, cRegex := range options.ContentRegex {
-			matched, _, matches := s.TextMatcher.Match(content, cRegex, matcher.RegexSearch, contextLength)
-			results = append(results, matches...)
-			if !matched {
-				return false, results
+	// the patterns of the content file are a single filter, the content has to match at least one of them
+	if query.patterns != nil {
+		var fileMatches []matcher.MatchResult
+		for i, found := range query.patterns.Match(content, contextLength) {
+			fileMatches = append(fileMatches, withRule(found, RuleContentFile, query.patterns.Patterns()[i].Search)...)
+		}
+		matches, ignoredMatches := annotations.filter(fileMatches)
+		results = append(results, matches...)
+		ignored = append(ignored, ignoredMatches...)
+		if len(matches) == 0 {
+			return false, results, ignored
+		}
+	}
+	// the rules are a single filter, the content has to match at least one of them
+	if options.Rules != nil {
+		matches, ignoredMatches := annotations.filter(withRule(options.Rules.Match(location, content, contextLength), RuleRules, ""))
+		results = append(results, matches...)
+		ignored = append(ignored, ignoredMatches...)
+		if len(matches) == 0 {
+			return false, results, ignored
+		}
+	}
+	// the high-entropy tokens are a single filter as well
+	if options.Entropy != nil {
+		entropyMatches := options.Entropy.Match(content, contextLength)
+		for i := range entropyMatches {
+			entropyMatches[i].Rule = RuleEntropy
+		}
+		matches, ignoredMatches := annotations.filter(entropyMatches)
+		results = append(results, matches...)
+		ignored = append(ignored, ignoredMatches...)
+		if len(matches) == 0 {
+			return false, results, ignored
+		}
+	}
+
+	return true, results, ignored
+}
+
+func isSopsDocumentFilterSet(options SearchOptions) bool {
+	return len(options.SopsKeyPath) > 0 || len(options.SopsValue) > 0 || len(options.SopsValueRegex) > 0
+}
+
+// isSopsFileTypeNeeded reports if the SOPS file type of the files is needed to read their metadata or decrypt them
+func isSopsFileTypeNeeded(options SearchOptions) bool {
+	return options.Sops || isSopsDocumentFilterSet(options) || len(options.SopsKey) > 0 || options.SopsMetadata || options.SopsPolicy
+}
+
+/*
+filterSopsDocument matches the entries of a decrypted SOPS document.
+Every key path pattern and every value must match at least one entry.
+Values are only searched in the entries matching a key path pattern, if any are given.
+Without value filters, the whole value of every entry matching a key path pattern is reported.
+*/
+func (s *Base) filterSopsDocument(entries []sops.Entry, options SearchOptions) (bool, []matcher.MatchResult) {
+	var results []matcher.MatchResult
+	candidates := entries
+	// the first key path pattern matching each candidate
+	var candidatePatterns []string
+	if len(options.SopsKeyPath) > 0 {
+		candidates = nil
+		found := make([]bool, len(options.SopsKeyPath))
+		for _, entry := range entries {
+			matchedPattern := ""
+			for i, pattern := range options.SopsKeyPath {
+				if matcher.MatchKeyPath(pattern, entry.KeyPath) {
+					found[i] = true
+					if matchedPattern == "" {
+						matchedPattern = pattern
+					}
+				}
 			}
+			if matchedPattern != "" {
+				candidates = append(candidates, entry)
+				candidatePatterns = append(candidatePatterns, matchedPattern)
+			}
+		}
+		for _, ok := range found {
+			if !ok {
+				return false, nil
+			}
+		}
+	}
+
+	if len(options.SopsValue) == 0 && len(options.SopsValueRegex) == 0 {
+		for i, entry := range candidates {
+			_, _, matches := s.TextMatcher.Match(entry.Value, entry.Value, matcher.TextSearch, contextLength)
+			results = append(results, withRule(entryMatches(entry, matches), RuleSopsKeyPath, candidatePatterns[i])...)
 		}
+		return true, results
 	}
 
+	for _, value := range options.SopsValue {
+		ok, matches := s.matchSopsEntries(candidates, func(text string) (bool, bool, []matcher.MatchResult) {
+			return s.TextMatcher.Match(text, value, matcher.TextSearch, contextLength)
+		})
+		results = append(results, withRule(matches, RuleSopsValue, value)...)
+		if !ok {
+			return false, results
+		}
+	}
+	for _, valueRegex := range options.SopsValueRegex {
+		ok, matches := s.matchSopsEntries(candidates, func(text string) (bool, bool, []matcher.MatchResult) {
+			return s.TextMatcher.MatchRegex(text, valueRegex, contextLength)
+		})
+		results = append(results, withRule(matches, RuleSopsValueRegex, valueRegex.String())...)
+		if !ok {
+			return false, results
+		}
+	}
 	return true, results
 }
 
-func (s *Base) decryptContent(file File, encryptedContent []byte) (string, error) {
+// matchSopsEntries matches the value of every entry with the match function of a text or regex search
+func (s *Base) matchSopsEntries(entries []sops.Entry, match func(text string) (bool, bool, []matcher.MatchResult)) (bool, []matcher.MatchResult) {
+	var results []matcher.MatchResult
+	for _, entry := range entries {
+		matched, _, matches := match(entry.Value)
+		if matched {
+			results = append(results, entryMatches(entry, matches)...)
+		}
+	}
+	return len(results) > 0, results
+}
+
+// withRule records the search option and pattern the matches were found by
+func withRule(matches []matcher.MatchResult, rule, search string) []matcher.MatchResult {
+	for i := range matches {
+		matches[i].Rule = rule
+		matches[i].Search = search
+	}
+	return matches
+}
+
+// entryMatches moves the positions of matches within a value to the position of the value in the document
+func entryMatches(entry sops.Entry, matches []matcher.MatchResult) []matcher.MatchResult {
+	for i := range matches {
+		if matches[i].Line == 1 {
+			matches[i].StartCol += entry.Column - 1
+			matches[i].EndCol += entry.Column - 1
+		}
+		matches[i].Line += entry.Line - 1
+		matches[i].KeyPath = entry.KeyPath
+	}
+	return matches
+}
+
+// annotateKeyPaths sets the key path of the entry on the line of each match in a decrypted document
+func annotateKeyPaths(matches []matcher.MatchResult, entries []sops.Entry) {
+	for i := range matches {
+		if matches[i].ExactMatch {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.Line != matches[i].Line {
+				continue
+			}
+			// on lines with several entries (e.g. inline JSON), take the last one starting before the match
+			if matches[i].KeyPath == "" || entry.Column <= matches[i].StartCol {
+				matches[i].KeyPath = entry.KeyPath
+			}
+		}
+	}
+}
+
+// fileSource abstracts how a scanner reads and decrypts a single file
+type fileSource struct {
+	read    func(context.Context) ([]byte, error)
+	decrypt func(context.Context, File, []byte, sops.FileType) (string, error)
+}
+
+// searchRun holds the state of a single search, shared by all scanned files
+type searchRun struct {
+	options     SearchOptions
+	sopsPolicy  *sopsPolicy
+	incremental *incremental
+	// root is the scanned directory, the fingerprints of its files are relative to it
+	root string
+}
+
+func newSearchRun(options SearchOptions, root string) *searchRun {
+	options.query = newContentQuery(options)
+	run := &searchRun{
+		options:     options,
+		incremental: newIncremental(options.State, optionsHash(options)),
+		root:        root,
+	}
+	if options.SopsPolicy {
+		run.sopsPolicy = newSopsPolicy()
+	}
+	return run
+}
+
+// report streams a found file to the console (unless it is printed late) and the OnMatch callback
+func (r *searchRun) report(fileMatch FileMatch) {
+	if !r.options.LogLate {
+		printFileMatch(fileMatch, r.options)
+	}
+	if r.options.OnMatch != nil {
+		r.options.OnMatch(fileMatch)
+	}
+}
+
+// suppress removes the matches accepted by the baseline and reports if nothing is left of the file
+func (r *searchRun) suppress(fileMatch *FileMatch) bool {
+	return !r.options.Baseline.filter(fileMatch)
+}
+
+// ignore reports the matches of a file ignored by annotations
+func (r *searchRun) ignore(file File, ignored []IgnoredMatch) {
+	if len(ignored) == 0 || r.options.OnIgnore == nil {
+		return
+	}
+	for _, m := range ignored {
+		m.File = file
+		r.options.OnIgnore(m)
+	}
+}
+
+// skip reports a file which could not be scanned, files of a canceled search are not reported
+func (r *searchRun) skip(ctx context.Context, file File, err error) {
+	r.incremental.skip(file)
+	if ctx.Err() != nil || r.options.OnSkip == nil {
+		return
+	}
+	r.options.OnSkip(SkippedFile{File: file, Reason: err.Error()})
+}
+
+// finish returns the matches which can only be determined after all files are scanned
+func (r *searchRun) finish() []FileMatch {
+	var fileMatches []FileMatch
+	if r.sopsPolicy != nil {
+		fileMatches = r.sopsPolicy.violations()
+		for i := range fileMatches {
+			r.incremental.match(&fileMatches[i])
+		}
+	}
+	var reported []FileMatch
+	for _, fileMatch := range append(fileMatches, r.incremental.finish()...) {
+		if !r.suppress(&fileMatch) {
+			reported = append(reported, fileMatch)
+		}
+	}
+	return reported
+}
+
+// scanFile applies all filters of the search options to a single file.
+// The content is only read when the options require it.
+func (s *Base) scanFile(ctx context.Context, file File, source fileSource, run *searchRun) (FileMatch, bool) {
+	options := run.options
+	fileMatch := FileMatch{
+		File:        file,
+		Matches:     nil,
+		Fingerprint: run.fileFingerprint(file),
+	}
+	if sops.IsConfigFile(file.Name) {
+		fileMatch.Type = SOPS_CONFIG
+	}
+	location := filepath.Join(file.Path, file.Name)
+	var matches []matcher.MatchResult
+	ok, filterFileMatches := s.filterFile(fileMatch.File, options)
+	if !ok {
+		return fileMatch, false
+	}
+	run.fingerprintMatches(filterFileMatches, file, "")
+	matches = append(matches, filterFileMatches...)
+
+	content := ""
+	decrypted := false
+	var entries []sops.Entry
+	// sops configs are always parsed, they are rare and cheap to read
+	if isFileContentNeeded(options) || fileMatch.Type == SOPS_CONFIG {
+		rawContent, err := source.read(ctx)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("reading file content failed %s - skipping %s and continuing", err, location))
+			run.skip(ctx, file, err)
+			return fileMatch, false
+		}
+		content = string(rawContent)
+		// the file type is sniffed from the content, so it is only detected for the SOPS options
+		var fileType sops.FileType
+		if isSopsFileTypeNeeded(options) {
+			fileType = sops.DetectFileType(location, rawContent, options.SopsFormats)
+		}
+		if fileMatch.Type == SOPS_CONFIG {
+			s.parseSopsConfig(&fileMatch, rawContent, run)
+		} else if run.sopsPolicy != nil {
+			if _, err := s.Sops.Metadata(rawContent, fileType); err != nil {
+				run.sopsPolicy.addUnencrypted(fileMatch.File)
+			}
+		}
+		if len(options.SopsKey) > 0 || options.SopsMetadata {
+			metadata, ok := s.filterSopsKey(rawContent, location, fileType, options)
+			if !ok {
+				return fileMatch, false
+			}
+			fileMatch.Type = SOPS_SECRET
+			fileMatch.Sops = &metadata
+		}
+		if options.Sops || isSopsDocumentFilterSet(options) {
+			ok, _ := s.filterSopsContentBeforeDecryption(content, options)
+			if !ok {
+				return fileMatch, false
+			}
+			decryptedContent, err := source.decrypt(ctx, fileMatch.File, rawContent, fileType)
+			if err == nil {
+				slog.Debug(fmt.Sprintf("found sops secret file: %s", location))
+				fileMatch.Type = SOPS_SECRET
+				content = decryptedContent
+				decrypted = true
+				entries, err = sops.ParseDocument([]byte(decryptedContent), fileType)
+				if err != nil {
+					slog.Debug(fmt.Sprintf("parsing decrypted sops document %s failed: %s", location, err))
+				}
+			}
+		}
+	}
+
+	// when sops-only is enabled, only search for sops files
+	if options.SopsOnly && fileMatch.Type != SOPS_SECRET {
+		return fileMatch, false
+	}
+
+	if isSopsDocumentFilterSet(options) {
+		if !decrypted {
+			return fileMatch, false
+		}
+		ok, documentMatches := s.filterSopsDocument(entries, options)
+		if !ok {
+			return fileMatch, false
+		}
+		run.fingerprintMatches(documentMatches, file, content)
+		if options.Redact.Enabled() {
+			redactDocumentMatches(documentMatches, entries, options.Redact)
+		}
+		matches = append(matches, documentMatches...)
+	}
+
+	ok, contentMatches, ignored := s.filterContent(matcher.Location{Path: relativePath(file, run.root), Commit: file.Commit}, content, options)
+	run.ignore(file, ignored)
+	if !ok {
+		return fileMatch, false
+	}
+	if decrypted {
+		annotateKeyPaths(contentMatches, entries)
+	}
+	run.fingerprintMatches(contentMatches, file, content)
+	// decrypted secrets are always redacted, plain files only on request
+	if options.Redact.Enabled() && decrypted {
+		redactContentMatches(content, contentMatches, documentSecrets(content, entries), options.Redact)
+	} else if options.Redact.Enabled() && options.RedactAll {
+		redactContentMatches(content, contentMatches, matchSecrets(content, contentMatches), options.Redact)
+	}
+	matches = append(matches, contentMatches...)
+	fileMatch.Matches = matches
+	slog.Debug(fmt.Sprintf("found file: %s", location))
+	return fileMatch, true
+}
+
+// limitDecrypt bounds the number of concurrent decryptions of the given decrypt function
+func limitDecrypt(limiter *worker.Limiter, decrypt func(context.Context, File, []byte, sops.FileType) (string, error)) func(context.Context, File, []byte, sops.FileType) (string, error) {
+	return func(ctx context.Context, file File, rawContent []byte, fileType sops.FileType) (string, error) {
+		if err := limiter.Acquire(ctx); err != nil {
+			return "", err
+		}
+		defer limiter.Release()
+		return decrypt(ctx, file, rawContent, fileType)
+	}
+}
+
+func (s *Base) decryptContent(ctx context.Context, file File, encryptedContent []byte, fileType sops.FileType) (string, error) {
 	decryptedContent := string(encryptedContent)
-	decryptedContent, err := s.Sops.DecryptFile(filepath.Join(file.Path, file.Name))
+	decryptedContent, err := s.Sops.DecryptFile(ctx, filepath.Join(file.Path, file.Name), fileType)
 	if err != nil {
 		return decryptedContent, fmt.Errorf("decrypt error: %s", err)
 	}
@@ -186,25 +624,85 @@ func (s *Base) decryptContent(file File, encryptedContent []byte) (string, error
 var printMu sync.Mutex
 
 func printFileMatches(fileMatches []FileMatch, options SearchOptions) {
+	if options.Silent {
+		return
+	}
 	printMu.Lock()
 	defer printMu.Unlock()
 	result := ""
 	for _, fileMatch := range fileMatches {
-		result += buildFileMatchOutput(fileMatch, options.NoSnippets)
+		result += formatFileMatch(fileMatch, options)
 	}
 	fmt.Print(result)
 }
 
 func printFileMatch(fileMatch FileMatch, options SearchOptions) {
+	if options.Silent {
+		return
+	}
 	printMu.Lock()
 	defer printMu.Unlock()
-	fmt.Print(buildFileMatchOutput(fileMatch, options.NoSnippets))
+	fmt.Print(formatFileMatch(fileMatch, options))
+}
+
+func formatFileMatch(fileMatch FileMatch, options SearchOptions) string {
+	if options.Format == FormatNDJSON {
+		line, err := NDJSONLine(fileMatch)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("marshalling %s failed: %s", filepath.Join(fileMatch.Path, fileMatch.Name), err))
+			return ""
+		}
+		return string(line)
+	}
+	return buildFileMatchOutput(fileMatch, options.NoSnippets)
+}
+
+// NDJSONLine marshals a value as a single line of newline delimited JSON
+func NDJSONLine(v any) ([]byte, error) {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return append(line, '\n'), nil
 }
 
 func buildFileMatchOutput(fileMatch FileMatch, noSnippets bool) string {
 	result := "+----------------------------------------+\n"
 	result += "Match:\t" + filepath.Join(fileMatch.Path, fileMatch.Name) + "\n"
+	if fileMatch.History != nil {
+		commit := fileMatch.History
+		result += fmt.Sprintf("\tCommit:%s by %s <%s> at %s, in HEAD: %t\n", shortSHA(commit.SHA), commit.Author, commit.Email, commit.Date.Format(time.RFC3339), commit.InHead)
+	} else if fileMatch.Commit != "" {
+		result += fmt.Sprintf("\tRef:%s (%s)\n", fileMatch.Ref, shortSHA(fileMatch.Commit))
+	}
+	if fileMatch.Status != "" {
+		result += fmt.Sprintf("\tStatus:%s\n", fileMatch.Status)
+	}
+	if fileMatch.SopsRule != nil {
+		result += fmt.Sprintf("\tNot encrypted, but matches the SOPS creation rule path_regex '%s'\n", fileMatch.SopsRule.PathRegex)
+	}
+	if fileMatch.SopsConfig != nil {
+		result += fmt.Sprintf("\tSOPS config with %d creation rules\n", len(fileMatch.SopsConfig.CreationRules))
+	}
+	if fileMatch.Sops != nil {
+		for i, group := range fileMatch.Sops.KeyGroups {
+			keys := make([]string, 0, len(group))
+			for _, key := range group {
+				keys = append(keys, key.Type+":"+key.ID)
+			}
+			result += fmt.Sprintf("\tSOPS key group %d: %s\n", i+1, strings.Join(keys, ", "))
+		}
+	}
 	for i, m := range fileMatch.Matches {
+		if m.KeyPath != "" {
+			result += fmt.Sprintf("\tKey:%s\n", m.KeyPath)
+		}
+		if m.RuleID != "" {
+			result += fmt.Sprintf("\tRule:%s (%s)\n", m.RuleID, m.Severity)
+		}
+		if m.Rule == RuleEntropy {
+			result += fmt.Sprintf("\tEntropy:%s\n", m.Search)
+		}
 		result += fmt.Sprintf("\tLine:%d, ColStart:%d, ColEnd:%d\n", m.Line, m.StartCol, m.EndCol)
 		if !noSnippets {
 			result += fmt.Sprintf("\t'%s'\n", m.CompressedFormattedSnippet)
@@ -222,14 +720,25 @@ func isFileContentNeeded(options SearchOptions) bool {
 	if len(options.Content) > 0 {
 		return true
 	}
-	if len(options.ContentRegex) > 0 {
+	if len(options.ContentRegex) > 0 || len(options.ContentPatterns) > 0 {
 		return true
 	}
-	if options.Sops {
+	if options.Sops || isSopsDocumentFilterSet(options) {
 		return true
 	}
-	if len(options.ExcludeContent) > 0 {
+	if len(options.SopsKey) > 0 || options.SopsMetadata || options.SopsPolicy {
+		return true
+	}
+	if len(options.ExcludeContent) > 0 || options.Rules != nil || options.Entropy != nil {
 		return true
 	}
 	return false
 }
+
+// shortSHA abbreviates a commit SHA like git does
+func shortSHA(sha string) string {
+	if len(sha) > 8 {
+		return sha[:8]
+	}
+	return sha
+}
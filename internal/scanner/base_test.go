@@ -3,11 +3,15 @@
 package scanner
 
 import (
+	"errors"
+	"regexp"
 	"testing"
 
 	"github.com/alican-uelger/deep-scan/internal/matcher"
+	"github.com/alican-uelger/deep-scan/internal/sops"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestBaseFilterFile(t *testing.T) {
@@ -73,7 +77,7 @@ func TestBaseFilterFile(t *testing.T) {
 		{
 			name: "NameRegex",
 			options: SearchOptions{
-				NameRegex: []string{`file\.txt`},
+				NameRegex: []*regexp.Regexp{regexp.MustCompile(`file\.txt`)},
 			},
 			expected: true,
 		},
@@ -94,7 +98,7 @@ func TestBaseFilterFile(t *testing.T) {
 		{
 			name: "PathRegex",
 			options: SearchOptions{
-				PathRegex: []string{`org/repo`},
+				PathRegex: []*regexp.Regexp{regexp.MustCompile(`org/repo`)},
 			},
 			expected: true,
 		},
@@ -103,6 +107,7 @@ func TestBaseFilterFile(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockTextMatcher.On("Match", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(true, true, []matcher.MatchResult{}).Maybe()
+			mockTextMatcher.On("MatchRegex", mock.Anything, mock.Anything, mock.Anything).Return(true, true, []matcher.MatchResult{}).Maybe()
 			result, _ := base.filterFile(file, tt.options)
 			assert.Equal(t, tt.expected, result)
 		})
@@ -144,7 +149,7 @@ func TestBaseFilterContent(t *testing.T) {
 		{
 			name: "ContentRegex",
 			options: SearchOptions{
-				ContentRegex: []string{`file content`},
+				ContentRegex: []*regexp.Regexp{regexp.MustCompile(`file content`)},
 			},
 			expected: true,
 		},
@@ -153,8 +158,181 @@ func TestBaseFilterContent(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockTextMatcher.On("Match", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(true, true, []matcher.MatchResult{}).Maybe()
-			result, _ := base.filterContent(content, tt.options)
+			result, _, _ := base.filterContent(matcher.Location{}, content, tt.options)
 			assert.Equal(t, tt.expected, result)
 		})
 	}
 }
+
+func TestBaseFilterSopsKey(t *testing.T) {
+	metadata := sops.Metadata{
+		KeyGroups: []sops.KeyGroup{{{Type: sops.Age, ID: "age1abc"}}},
+	}
+
+	tests := []struct {
+		name        string
+		metadata    sops.Metadata
+		metadataErr error
+		keys        []string
+		expected    bool
+	}{
+		{name: "Matching key", metadata: metadata, keys: []string{"age1other", "age1abc"}, expected: true},
+		{name: "Other key", metadata: metadata, keys: []string{"age1other"}, expected: false},
+		{name: "Not encrypted", metadataErr: errors.New("sops metadata not found"), keys: []string{"age1abc"}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSops := NewSopsMock(t)
+			mockSops.On("Metadata", []byte("content"), sops.YAML).Return(tt.metadata, tt.metadataErr)
+			base := &Base{
+				TextMatcher: NewTextMatcherMock(t),
+				Storage:     NewStorageMock(t),
+				Sops:        mockSops,
+			}
+			_, result := base.filterSopsKey([]byte("content"), "org/repo/secret.yaml", sops.YAML, SearchOptions{SopsKey: tt.keys})
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestBaseFilterSopsDocument(t *testing.T) {
+	base := &Base{
+		TextMatcher: matcher.NewText(),
+		Storage:     NewStorageMock(t),
+		Sops:        NewSopsMock(t),
+	}
+	entries := []sops.Entry{
+		{KeyPath: "db.primary.user", Value: "admin", Line: 3, Column: 15},
+		{KeyPath: "db.primary.password", Value: "changeme", Line: 4, Column: 19},
+		{KeyPath: "db.replica.password", Value: "s3cr3t", Line: 6, Column: 19},
+		{KeyPath: "api.token", Value: "changeme-too", Line: 8, Column: 12},
+	}
+
+	tests := []struct {
+		name     string
+		options  SearchOptions
+		expected bool
+		keyPaths []string
+	}{
+		{name: "Key path", options: SearchOptions{SopsKeyPath: []string{"db.*.password"}}, expected: true, keyPaths: []string{"db.primary.password", "db.replica.password"}},
+		{name: "Recursive key path", options: SearchOptions{SopsKeyPath: []string{"**.pass*"}}, expected: true, keyPaths: []string{"db.primary.password", "db.replica.password"}},
+		{name: "Missing key path", options: SearchOptions{SopsKeyPath: []string{"db.*.password", "smtp.password"}}, expected: false},
+		{name: "Value", options: SearchOptions{SopsValue: []string{"changeme"}}, expected: true, keyPaths: []string{"db.primary.password", "api.token"}},
+		{name: "Value in key path", options: SearchOptions{SopsKeyPath: []string{"db.**"}, SopsValue: []string{"changeme"}}, expected: true, keyPaths: []string{"db.primary.password"}},
+		{name: "Value regex", options: SearchOptions{SopsValueRegex: []*regexp.Regexp{regexp.MustCompile(`^s\d`)}}, expected: true, keyPaths: []string{"db.replica.password"}},
+		{name: "Missing value", options: SearchOptions{SopsKeyPath: []string{"api.*"}, SopsValue: []string{"s3cr3t"}}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, results := base.filterSopsDocument(entries, tt.options)
+			assert.Equal(t, tt.expected, ok)
+			if !tt.expected {
+				return
+			}
+			var keyPaths []string
+			for _, result := range results {
+				keyPaths = append(keyPaths, result.KeyPath)
+			}
+			assert.Equal(t, tt.keyPaths, keyPaths)
+		})
+	}
+
+	_, results := base.filterSopsDocument(entries, SearchOptions{SopsValue: []string{"too"}})
+	assert.Equal(t, 8, results[0].Line)
+	assert.Equal(t, 21, results[0].StartCol)
+	assert.Equal(t, 23, results[0].EndCol)
+}
+
+func TestAnnotateKeyPaths(t *testing.T) {
+	entries := []sops.Entry{
+		{KeyPath: "user", Value: "admin", Line: 1, Column: 10},
+		{KeyPath: "password", Value: "changeme", Line: 1, Column: 30},
+		{KeyPath: "token", Value: "abc", Line: 2, Column: 8},
+	}
+	matches := []matcher.MatchResult{
+		{Line: 1, StartCol: 2, EndCol: 5},
+		{Line: 1, StartCol: 31, EndCol: 34},
+		{Line: 2, StartCol: 8, EndCol: 10},
+		{Line: 3, StartCol: 1, EndCol: 3},
+	}
+	annotateKeyPaths(matches, entries)
+	assert.Equal(t, "user", matches[0].KeyPath)
+	assert.Equal(t, "password", matches[1].KeyPath)
+	assert.Equal(t, "token", matches[2].KeyPath)
+	assert.Equal(t, "", matches[3].KeyPath)
+}
+
+func TestBaseFilterContentRules(t *testing.T) {
+	pack, err := matcher.ParseRulePack("test", []byte("version: 1.0.0\nrules:\n  - {id: token, regex: 'tok_[0-9]+', severity: high}\n  - {id: key, regex: 'key_[0-9]+'}\n"))
+	require.NoError(t, err)
+	rules, err := matcher.NewRules(pack)
+	require.NoError(t, err)
+	base := &Base{TextMatcher: matcher.NewText()}
+	options := SearchOptions{Rules: rules}
+
+	ok, matches, ignored := base.filterContent(matcher.Location{}, "a: tok_1\nb: key_2 # deep-scan:ignore=key\n", options)
+	assert.True(t, ok)
+	require.Len(t, matches, 1)
+	assert.Equal(t, "token", matches[0].RuleID)
+	assert.Equal(t, RuleRules, matches[0].Rule)
+	assert.Equal(t, []IgnoredMatch{{Line: 2, Rule: "key", Annotation: AnnotationIgnore}}, ignored)
+
+	// the content has to match one of the rules
+	ok, _, _ = base.filterContent(matcher.Location{}, "nothing", options)
+	assert.False(t, ok)
+}
+
+func TestBaseFilterContentEntropy(t *testing.T) {
+	entropy, err := matcher.NewEntropy(matcher.DefaultEntropyThresholds, 16)
+	require.NoError(t, err)
+	base := &Base{TextMatcher: matcher.NewText()}
+	secret := "9f86d081884c7d65" + "9a2feaa0c55ad015"
+
+	ok, matches, _ := base.filterContent(matcher.Location{}, "token: "+secret+"\n", SearchOptions{Entropy: entropy})
+	assert.True(t, ok)
+	require.Len(t, matches, 1)
+	assert.Equal(t, RuleEntropy, matches[0].Rule)
+	assert.Equal(t, string(matcher.CharsetHex), matches[0].Search)
+
+	// the entropy detection is combined with the other content filters
+	ok, _, _ = base.filterContent(matcher.Location{}, "token: "+secret+"\n", SearchOptions{Entropy: entropy, ExcludeContent: []string{"token"}})
+	assert.False(t, ok)
+	ok, _, _ = base.filterContent(matcher.Location{}, "token: "+secret+"\n", SearchOptions{Entropy: entropy, Content: []string{"password"}})
+	assert.False(t, ok)
+	ok, _, ignored := base.filterContent(matcher.Location{}, "token: "+secret+" # deep-scan:ignore=entropy\n", SearchOptions{Entropy: entropy})
+	assert.False(t, ok)
+	assert.Equal(t, []IgnoredMatch{{Line: 1, Rule: RuleEntropy, Annotation: AnnotationIgnore}}, ignored)
+}
+
+func TestBaseFilterContentPatterns(t *testing.T) {
+	base := &Base{TextMatcher: matcher.NewText()}
+	options := SearchOptions{ContentPatterns: []matcher.Pattern{
+		{Search: "leaked_1", Type: matcher.TextSearch},
+		{Search: "leaked_2", Type: matcher.TextSearch},
+		{Search: `tok_\d+`, Type: matcher.RegexSearch},
+	}}
+
+	// the content has to match one of the patterns
+	ok, matches, _ := base.filterContent(matcher.Location{}, "a: leaked_2\nb: tok_7\n", options)
+	assert.True(t, ok)
+	require.Len(t, matches, 2)
+	assert.Equal(t, RuleContentFile, matches[0].Rule)
+	assert.Equal(t, "leaked_2", matches[0].Search)
+	assert.Equal(t, `tok_\d+`, matches[1].Search)
+
+	ok, _, _ = base.filterContent(matcher.Location{}, "nothing", options)
+	assert.False(t, ok)
+	ok, _, ignored := base.filterContent(matcher.Location{}, "a: leaked_1 # deep-scan:ignore=content-file\n", options)
+	assert.False(t, ok)
+	assert.Equal(t, []IgnoredMatch{{Line: 1, Rule: RuleContentFile, Annotation: AnnotationIgnore}}, ignored)
+
+	// combined with --content, every value of --content has to match as well
+	options.Content = []string{"a:", "c:"}
+	ok, _, _ = base.filterContent(matcher.Location{}, "a: leaked_2\n", options)
+	assert.False(t, ok)
+	options.Content = []string{"a:"}
+	ok, _, _ = base.filterContent(matcher.Location{}, "a: leaked_2\n", options)
+	assert.True(t, ok)
+}
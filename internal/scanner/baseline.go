@@ -0,0 +1,196 @@
+package scanner
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/alican-uelger/deep-scan/internal/matcher"
+)
+
+const baselineVersion = 2
+
+// baselineKeySize is the size of the random key of the fingerprints of a baseline
+const baselineKeySize = 32
+
+/*
+Baseline holds the fingerprints of accepted findings, matching findings are not reported.
+The fingerprints are keyed with the random key of the baseline, so the matched secrets can not be guessed from the
+fingerprints of an output without the baseline. Everyone with the baseline has the key and can test guesses.
+*/
+type Baseline struct {
+	Version  int             `json:"version"`
+	Key      string          `json:"key"`
+	Findings []BaselineEntry `json:"findings"`
+
+	key          []byte
+	fingerprints map[string]bool
+	suppressed   atomic.Int64
+}
+
+// BaselineEntry is an accepted finding, the path and rule only tell a reviewer what was accepted
+type BaselineEntry struct {
+	Fingerprint string `json:"fingerprint"`
+	Repository  string `json:"repository,omitempty"`
+	Path        string `json:"path"`
+	Rule        string `json:"rule,omitempty"`
+}
+
+// NewBaseline creates an empty baseline with a new random key
+func NewBaseline() *Baseline {
+	key := make([]byte, baselineKeySize)
+	// crypto/rand never returns an error
+	_, _ = rand.Read(key)
+	return &Baseline{Version: baselineVersion, Key: hex.EncodeToString(key), Findings: []BaselineEntry{}, key: key, fingerprints: map[string]bool{}}
+}
+
+// WithoutFindings returns an empty baseline with the key of the baseline, its fingerprints stay the same
+func (b *Baseline) WithoutFindings() *Baseline {
+	return &Baseline{Version: baselineVersion, Key: b.Key, Findings: []BaselineEntry{}, key: b.key, fingerprints: map[string]bool{}}
+}
+
+// ReadBaseline parses a baseline written by --write-baseline
+func ReadBaseline(data []byte) (*Baseline, error) {
+	baseline := &Baseline{Findings: []BaselineEntry{}, fingerprints: map[string]bool{}}
+	if err := json.Unmarshal(data, baseline); err != nil {
+		return nil, err
+	}
+	if baseline.Version != baselineVersion {
+		return nil, fmt.Errorf("unsupported baseline version %d, expected %d", baseline.Version, baselineVersion)
+	}
+	key, err := hex.DecodeString(baseline.Key)
+	if err != nil || len(key) == 0 {
+		return nil, fmt.Errorf("baseline has no valid key")
+	}
+	baseline.key = key
+	for _, entry := range baseline.Findings {
+		baseline.fingerprints[entry.Fingerprint] = true
+	}
+	return baseline, nil
+}
+
+func (b *Baseline) Contains(fingerprint string) bool {
+	return b.fingerprints[fingerprint]
+}
+
+// Add accepts the matches of the files, resolved findings of incremental scans are skipped
+func (b *Baseline) Add(fileMatches []FileMatch) {
+	add := func(fileMatch FileMatch, fingerprint, rule string) {
+		if fingerprint == "" || b.fingerprints[fingerprint] {
+			return
+		}
+		b.fingerprints[fingerprint] = true
+		b.Findings = append(b.Findings, BaselineEntry{
+			Fingerprint: fingerprint,
+			Repository:  fileMatch.Repository,
+			Path:        filepath.ToSlash(fileLocation(fileMatch.File)),
+			Rule:        rule,
+		})
+	}
+	for _, fileMatch := range fileMatches {
+		if fileMatch.Status == StatusResolved {
+			continue
+		}
+		if len(fileMatch.Matches) == 0 {
+			add(fileMatch, fileMatch.Fingerprint, "")
+		}
+		for _, m := range fileMatch.Matches {
+			add(fileMatch, m.Fingerprint, matchRule(m))
+		}
+	}
+	sort.SliceStable(b.Findings, func(i, j int) bool {
+		if b.Findings[i].Repository != b.Findings[j].Repository {
+			return b.Findings[i].Repository < b.Findings[j].Repository
+		}
+		return b.Findings[i].Path < b.Findings[j].Path
+	})
+}
+
+// Suppressed returns the number of findings which were not reported, because they are in the baseline
+func (b *Baseline) Suppressed() int64 {
+	return b.suppressed.Load()
+}
+
+/*
+filter removes the accepted matches of a file and reports if anything is left to report.
+Files without matches (e.g. found by their SOPS key) are accepted by the fingerprint of the file.
+*/
+func (b *Baseline) filter(fileMatch *FileMatch) bool {
+	if b == nil {
+		return true
+	}
+	if len(fileMatch.Matches) == 0 {
+		if b.Contains(fileMatch.Fingerprint) {
+			b.suppressed.Add(1)
+			return false
+		}
+		return true
+	}
+	var matches []matcher.MatchResult
+	for _, m := range fileMatch.Matches {
+		if b.Contains(m.Fingerprint) {
+			b.suppressed.Add(1)
+			continue
+		}
+		matches = append(matches, m)
+	}
+	fileMatch.Matches = matches
+	return len(matches) > 0
+}
+
+/*
+fingerprint identifies a finding independent of its line: it is an HMAC of the repository, the path relative to the
+scanned directory or repository and the matched text, so a finding keeps its fingerprint when lines are
+added above it or the directory is scanned from another location.
+*/
+func (b *Baseline) fingerprint(file File, root, text string) string {
+	h := hmac.New(sha256.New, b.key)
+	_, _ = fmt.Fprintf(h, "%s\x00%s\x00%s", file.Repository, relativePath(file, root), text)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// fileFingerprint identifies a file without matches, fingerprints are only computed for searches with a baseline
+func (r *searchRun) fileFingerprint(file File) string {
+	if r.options.Baseline == nil {
+		return ""
+	}
+	return r.options.Baseline.fingerprint(file, r.root, "")
+}
+
+// fingerprintMatches sets the fingerprints of the matches, the matched text is taken from the content before it is redacted
+func (r *searchRun) fingerprintMatches(matches []matcher.MatchResult, file File, content string) {
+	if r.options.Baseline == nil {
+		return
+	}
+	offsets := lineOffsets(content)
+	for i := range matches {
+		text := matches[i].Snippet
+		if content != "" {
+			if start, end, ok := matchRange(content, offsets, matches[i]); ok {
+				text = content[start:end]
+			}
+		}
+		matches[i].Fingerprint = r.options.Baseline.fingerprint(file, r.root, text)
+	}
+}
+
+// relativePath returns the path of a file relative to its repository or the scanned directory
+func relativePath(file File, root string) string {
+	location := filepath.ToSlash(fileLocation(file))
+	if file.Repository != "" {
+		return strings.TrimPrefix(location, file.Repository+"/")
+	}
+	if root != "" {
+		if rel, err := filepath.Rel(root, fileLocation(file)); err == nil {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return location
+}
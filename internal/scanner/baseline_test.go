@@ -0,0 +1,147 @@
+//go:build unit
+
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"github.com/alican-uelger/deep-scan/internal/git"
+	"github.com/alican-uelger/deep-scan/internal/matcher"
+	"github.com/alican-uelger/deep-scan/internal/sops"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadBaseline(t *testing.T) {
+	baseline, err := ReadBaseline([]byte(`{"version": 2, "key": "0123456789abcdef", "findings": [{"fingerprint": "abc", "path": "config.yaml"}]}`))
+	require.NoError(t, err)
+	assert.True(t, baseline.Contains("abc"))
+	assert.False(t, baseline.Contains("def"))
+
+	_, err = ReadBaseline([]byte(`{"version": 1}`))
+	assert.EqualError(t, err, "unsupported baseline version 1, expected 2")
+
+	_, err = ReadBaseline([]byte(`{"version": 2, "findings": []}`))
+	assert.EqualError(t, err, "baseline has no valid key")
+}
+
+func TestBaselineFingerprint(t *testing.T) {
+	file := File{Name: "config.yaml", Path: "org/repo", Repository: "org/repo"}
+	baseline := NewBaseline()
+	fingerprint := baseline.fingerprint(file, "", "password: secret")
+
+	assert.Equal(t, fingerprint, baseline.fingerprint(file, "", "password: secret"))
+	assert.NotEqual(t, fingerprint, baseline.fingerprint(file, "", "password: other"))
+	// the fingerprints of another baseline use another key
+	other := NewBaseline()
+	assert.NotEqual(t, baseline.Key, other.Key)
+	assert.NotEqual(t, fingerprint, other.fingerprint(file, "", "password: secret"))
+}
+
+// osBaselineSearch scans a directory with a single config.yaml with the content
+func osBaselineSearch(t *testing.T, dir, content string, baseline *Baseline) []FileMatch {
+	mockStorage := NewStorageMock(t)
+	mockStorage.On("ReadDir", dir).Return([]string{dir + "/config.yaml"}, nil)
+	mockStorage.On("IsDir", dir+"/config.yaml").Return(false, nil)
+	mockStorage.On("ReadFile", dir+"/config.yaml").Return([]byte(content), nil)
+	o := &Os{
+		Base: Base{
+			Storage:     mockStorage,
+			Sops:        NewSopsMock(t),
+			TextMatcher: matcher.NewText(),
+		},
+	}
+	options := SearchOptions{ContentRegex: []*regexp.Regexp{regexp.MustCompile(`(password|token): \w+`)}, Silent: true, Baseline: baseline}
+	result, err := o.Search(context.Background(), dir, options)
+	require.NoError(t, err)
+	return result
+}
+
+func TestOsSearchBaseline(t *testing.T) {
+	baseline := NewBaseline()
+	result := osBaselineSearch(t, "dir", "password: accepted\n", baseline)
+	require.Len(t, result, 1)
+	baseline.Add(result)
+	require.Len(t, baseline.Findings, 1)
+	assert.Equal(t, BaselineEntry{Fingerprint: result[0].Matches[0].Fingerprint, Path: "dir/config.yaml", Rule: RuleContentRegex}, baseline.Findings[0])
+
+	// the accepted match moved down and is scanned from another location, only the new match is reported
+	data, err := json.Marshal(baseline)
+	require.NoError(t, err)
+	baseline, err = ReadBaseline(data)
+	require.NoError(t, err)
+	result = osBaselineSearch(t, "/tmp/clone", "# comment\n\npassword: accepted\ntoken: new\n", baseline)
+	require.Len(t, result, 1)
+	require.Len(t, result[0].Matches, 1)
+	assert.Equal(t, 4, result[0].Matches[0].Line)
+	assert.Equal(t, int64(1), baseline.Suppressed())
+
+	// files with only accepted matches are not reported
+	result = osBaselineSearch(t, "dir", "password: accepted\n", baseline)
+	assert.Empty(t, result)
+	assert.Equal(t, int64(2), baseline.Suppressed())
+
+	// a changed value is a new finding
+	result = osBaselineSearch(t, "dir", "password: changed\n", baseline)
+	assert.Len(t, result, 1)
+}
+
+func TestGitSearchBaseline(t *testing.T) {
+	mockProject := git.Project{ID: 1, PathWithNamespace: "org/repo"}
+	mockClient := NewGitClientMock(t)
+	mockClient.
+		On("ListGroupProjects", mock.Anything, "org", mock.Anything).
+		Return([]git.Project{mockProject}, nil)
+	mockClient.
+		On("ResolveRef", mock.Anything, mockProject, "").
+		Return(git.Ref{Name: "HEAD", SHA: "abc123"}, nil)
+	mockClient.
+		On("ListRepositoryTree", mock.Anything, mockProject, "abc123").
+		Return([]git.TreeNode{{Path: "config.yaml", Type: "blob"}, {Path: "other.yaml", Type: "blob"}}, nil)
+	mockClient.
+		On("GetRawFile", mock.Anything, mockProject, "abc123", "config.yaml").
+		Return([]byte("password: accepted\n"), nil)
+	mockClient.
+		On("GetRawFile", mock.Anything, mockProject, "abc123", "other.yaml").
+		Return([]byte("password: accepted\n"), nil)
+
+	mockStorage := NewStorageMock(t)
+	g := &Git{
+		Client: mockClient,
+		Base: Base{
+			Storage:     mockStorage,
+			Sops:        sops.New(mockStorage),
+			TextMatcher: matcher.NewText(),
+		},
+	}
+	baseline := NewBaseline()
+	options := SearchOptions{Fetch: FetchFiles, Content: []string{"password"}, Silent: true, Baseline: baseline}
+
+	result, err := g.Search(context.Background(), "org", options)
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	// the same match in another file has another fingerprint
+	assert.NotEqual(t, result[0].Matches[0].Fingerprint, result[1].Matches[0].Fingerprint)
+	baseline.Add(result[:1])
+
+	result, err = g.Search(context.Background(), "org", options)
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	assert.Equal(t, baseline.Findings[0].Repository, result[0].Repository)
+	assert.NotEqual(t, baseline.Findings[0].Path, fileLocation(result[0].File))
+	assert.Equal(t, int64(1), baseline.Suppressed())
+}
+
+func TestBaselineAddSkipsResolved(t *testing.T) {
+	baseline := NewBaseline()
+	baseline.Add([]FileMatch{
+		{File: File{Name: "a.yaml", Path: "org/repo", Repository: "org/repo"}, Fingerprint: "file", Status: StatusNew},
+		{File: File{Name: "b.yaml", Path: "org/repo", Repository: "org/repo"}, Matches: []matcher.MatchResult{{Fingerprint: "match"}}, Status: StatusResolved},
+	})
+	assert.True(t, baseline.Contains("file"))
+	assert.False(t, baseline.Contains("match"))
+}
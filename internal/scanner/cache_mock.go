@@ -0,0 +1,291 @@
+// Code generated by mockery v2.53.4. DO NOT EDIT.
+
+package scanner
+
+import (
+	git "github.com/alican-uelger/deep-scan/internal/git"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// CacheMock is an autogenerated mock type for the Cache type
+type CacheMock struct {
+	mock.Mock
+}
+
+type CacheMock_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *CacheMock) EXPECT() *CacheMock_Expecter {
+	return &CacheMock_Expecter{mock: &_m.Mock}
+}
+
+// Blob provides a mock function with given fields: sha
+func (_m *CacheMock) Blob(sha string) ([]byte, bool) {
+	ret := _m.Called(sha)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Blob")
+	}
+
+	var r0 []byte
+	var r1 bool
+	if rf, ok := ret.Get(0).(func(string) ([]byte, bool)); ok {
+		return rf(sha)
+	}
+	if rf, ok := ret.Get(0).(func(string) []byte); ok {
+		r0 = rf(sha)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) bool); ok {
+		r1 = rf(sha)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
+// CacheMock_Blob_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Blob'
+type CacheMock_Blob_Call struct {
+	*mock.Call
+}
+
+// Blob is a helper method to define mock.On call
+//   - sha string
+func (_e *CacheMock_Expecter) Blob(sha interface{}) *CacheMock_Blob_Call {
+	return &CacheMock_Blob_Call{Call: _e.mock.On("Blob", sha)}
+}
+
+func (_c *CacheMock_Blob_Call) Run(run func(sha string)) *CacheMock_Blob_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *CacheMock_Blob_Call) Return(_a0 []byte, _a1 bool) *CacheMock_Blob_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *CacheMock_Blob_Call) RunAndReturn(run func(string) ([]byte, bool)) *CacheMock_Blob_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// HasBlob provides a mock function with given fields: sha
+func (_m *CacheMock) HasBlob(sha string) bool {
+	ret := _m.Called(sha)
+
+	if len(ret) == 0 {
+		panic("no return value specified for HasBlob")
+	}
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = rf(sha)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// CacheMock_HasBlob_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'HasBlob'
+type CacheMock_HasBlob_Call struct {
+	*mock.Call
+}
+
+// HasBlob is a helper method to define mock.On call
+//   - sha string
+func (_e *CacheMock_Expecter) HasBlob(sha interface{}) *CacheMock_HasBlob_Call {
+	return &CacheMock_HasBlob_Call{Call: _e.mock.On("HasBlob", sha)}
+}
+
+func (_c *CacheMock_HasBlob_Call) Run(run func(sha string)) *CacheMock_HasBlob_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *CacheMock_HasBlob_Call) Return(_a0 bool) *CacheMock_HasBlob_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *CacheMock_HasBlob_Call) RunAndReturn(run func(string) bool) *CacheMock_HasBlob_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PutBlob provides a mock function with given fields: sha, content
+func (_m *CacheMock) PutBlob(sha string, content []byte) error {
+	ret := _m.Called(sha, content)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PutBlob")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, []byte) error); ok {
+		r0 = rf(sha, content)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CacheMock_PutBlob_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PutBlob'
+type CacheMock_PutBlob_Call struct {
+	*mock.Call
+}
+
+// PutBlob is a helper method to define mock.On call
+//   - sha string
+//   - content []byte
+func (_e *CacheMock_Expecter) PutBlob(sha interface{}, content interface{}) *CacheMock_PutBlob_Call {
+	return &CacheMock_PutBlob_Call{Call: _e.mock.On("PutBlob", sha, content)}
+}
+
+func (_c *CacheMock_PutBlob_Call) Run(run func(sha string, content []byte)) *CacheMock_PutBlob_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].([]byte))
+	})
+	return _c
+}
+
+func (_c *CacheMock_PutBlob_Call) Return(_a0 error) *CacheMock_PutBlob_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *CacheMock_PutBlob_Call) RunAndReturn(run func(string, []byte) error) *CacheMock_PutBlob_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PutTree provides a mock function with given fields: commit, tree
+func (_m *CacheMock) PutTree(commit string, tree []git.TreeNode) error {
+	ret := _m.Called(commit, tree)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PutTree")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, []git.TreeNode) error); ok {
+		r0 = rf(commit, tree)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CacheMock_PutTree_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PutTree'
+type CacheMock_PutTree_Call struct {
+	*mock.Call
+}
+
+// PutTree is a helper method to define mock.On call
+//   - commit string
+//   - tree []git.TreeNode
+func (_e *CacheMock_Expecter) PutTree(commit interface{}, tree interface{}) *CacheMock_PutTree_Call {
+	return &CacheMock_PutTree_Call{Call: _e.mock.On("PutTree", commit, tree)}
+}
+
+func (_c *CacheMock_PutTree_Call) Run(run func(commit string, tree []git.TreeNode)) *CacheMock_PutTree_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].([]git.TreeNode))
+	})
+	return _c
+}
+
+func (_c *CacheMock_PutTree_Call) Return(_a0 error) *CacheMock_PutTree_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *CacheMock_PutTree_Call) RunAndReturn(run func(string, []git.TreeNode) error) *CacheMock_PutTree_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Tree provides a mock function with given fields: commit
+func (_m *CacheMock) Tree(commit string) ([]git.TreeNode, bool) {
+	ret := _m.Called(commit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Tree")
+	}
+
+	var r0 []git.TreeNode
+	var r1 bool
+	if rf, ok := ret.Get(0).(func(string) ([]git.TreeNode, bool)); ok {
+		return rf(commit)
+	}
+	if rf, ok := ret.Get(0).(func(string) []git.TreeNode); ok {
+		r0 = rf(commit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]git.TreeNode)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) bool); ok {
+		r1 = rf(commit)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	return r0, r1
+}
+
+// CacheMock_Tree_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Tree'
+type CacheMock_Tree_Call struct {
+	*mock.Call
+}
+
+// Tree is a helper method to define mock.On call
+//   - commit string
+func (_e *CacheMock_Expecter) Tree(commit interface{}) *CacheMock_Tree_Call {
+	return &CacheMock_Tree_Call{Call: _e.mock.On("Tree", commit)}
+}
+
+func (_c *CacheMock_Tree_Call) Run(run func(commit string)) *CacheMock_Tree_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *CacheMock_Tree_Call) Return(_a0 []git.TreeNode, _a1 bool) *CacheMock_Tree_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *CacheMock_Tree_Call) RunAndReturn(run func(string) ([]git.TreeNode, bool)) *CacheMock_Tree_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewCacheMock creates a new instance of CacheMock. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewCacheMock(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *CacheMock {
+	mock := &CacheMock{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
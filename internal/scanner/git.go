@@ -1,15 +1,19 @@
 package scanner
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"github.com/alican-uelger/deep-scan/internal/git"
+	"io"
 	"log/slog"
 	"path/filepath"
 	"sync"
 
+	"github.com/alican-uelger/deep-scan/internal/git"
 	"github.com/alican-uelger/deep-scan/internal/matcher"
 	"github.com/alican-uelger/deep-scan/internal/sops"
 	"github.com/alican-uelger/deep-scan/internal/storage"
+	"github.com/alican-uelger/deep-scan/internal/worker"
 )
 
 type Git struct {
@@ -29,121 +33,332 @@ func NewGitlab(client GitClient) *Git {
 	}
 }
 
-func (s *Git) Search(org string, options SearchOptions) ([]FileMatch, error) {
+// Search scans all projects of the org (or the projects of the options) and returns all matching files.
+// When the context is canceled, the matches found so far are returned together with the context error.
+func (s *Git) Search(ctx context.Context, org string, options SearchOptions) ([]FileMatch, error) {
 	var result []FileMatch
+	var searchErr error
 	var mu sync.Mutex
-	var wg sync.WaitGroup
 
 	var projects []git.Project
 	var err error
-	if options.Project != "" {
-		project, err := s.Client.GetProjectByName(options.Project)
+	if len(options.Projects) > 0 {
+		projects, err = s.getProjects(ctx, options)
 		if err != nil {
 			return result, err
 		}
-		projects = []git.Project{project}
 	} else {
-		projects, err = s.Client.ListGroupProjects(org)
+		projects, err = s.Client.ListGroupProjects(ctx, org, options.ProjectFilter)
 		if err != nil {
 			return result, err
 		}
 	}
 
-	for _, project := range projects {
-		tree, err := s.Client.ListRepositoryTree(project)
-		if err != nil {
-			return result, err
-		}
+	// every task of the pool does at most one API call, so the pool size bounds the API concurrency
+	run := newSearchRun(options, "")
+	pool := worker.NewPool(ctx, options.Concurrency)
+	decrypt := limitDecrypt(worker.NewLimiter(options.SopsConcurrency), s.decryptContent)
 
-		for _, treeEntry := range tree {
-			if treeEntry.IsTree {
-				continue
+	fail := func(err error) {
+		mu.Lock()
+		if searchErr == nil {
+			searchErr = err
+		}
+		mu.Unlock()
+	}
+	add := func(fileMatch FileMatch) {
+		if run.suppress(&fileMatch) {
+			return
+		}
+		run.report(fileMatch)
+		mu.Lock()
+		result = append(result, fileMatch)
+		mu.Unlock()
+	}
+	scanNow := func(file File, source fileSource) {
+		fileMatch, ok := s.scanFile(ctx, file, source, run)
+		if !ok {
+			return
+		}
+		run.incremental.match(&fileMatch)
+		add(fileMatch)
+	}
+	scan := func(file File, source fileSource) {
+		pool.Submit(func() {
+			scanNow(file, source)
+		})
+	}
+	scanBlob := func(project git.Project, ref git.Ref, treeEntry git.TreeNode) {
+		source := fileSource{
+			read: func(ctx context.Context) ([]byte, error) {
+				return s.readBlob(ctx, project, ref, treeEntry, options)
+			},
+			decrypt: decrypt,
+		}
+		scan(projectFile(project, ref, treeEntry.Path), source)
+	}
+	for _, project := range projects {
+		pool.Submit(func() {
+			refs, err := s.projectRefs(ctx, project, options)
+			if err != nil {
+				fail(err)
+				return
 			}
-
-			wg.Add(1)
-			go func(treeEntry git.TreeNode) {
-				defer wg.Done()
-				entry := filepath.Join(project.PathWithNamespace, treeEntry.Path)
-				fileMatch := FileMatch{
-					File: File{
-						Name: filepath.Base(entry),
-						Path: filepath.Dir(entry),
-						Type: FILE,
-					},
-					Matches: nil,
-				}
-				var matches []matcher.MatchResult
-
-				ok, filterFileMatches := s.filterFile(fileMatch.File, options)
-				if !ok {
-					return
-				}
-				matches = append(matches, filterFileMatches...)
-
-				content := ""
-				if isFileContentNeeded(options) {
-					rawContent, err := s.Client.GetRawFile(project, treeEntry.Path)
+			for _, ref := range refs {
+				pool.Submit(func() {
+					previousCommit := run.incremental.begin(project, ref)
+					if previousCommit != "" && !options.SopsPolicy {
+						carried, ok := s.scanChanges(ctx, project, ref, previousCommit, run, scanBlob)
+						if ok {
+							for _, fileMatch := range carried {
+								add(fileMatch)
+							}
+							return
+						}
+					}
+					if options.Fetch == FetchArchive {
+						if err := s.scanArchive(ctx, project, ref, options, decrypt, scanNow); err != nil {
+							fail(err)
+						}
+						return
+					}
+					tree, err := s.repositoryTree(ctx, project, ref, options)
 					if err != nil {
-						slog.Warn(fmt.Sprintf("reading file content failed %s - skipping %s and continuing", err, entry))
+						fail(err)
 						return
 					}
-					content = string(rawContent)
-					if options.Sops {
-						ok, _ := s.filterSopsContentBeforeDecryption(content, options)
-						if !ok {
-							return
+					if options.Fetch == FetchAuto && s.isArchiveCheaper(project, ref, tree, options) {
+						if err := s.scanArchive(ctx, project, ref, options, decrypt, scanNow); err != nil {
+							fail(err)
 						}
-						decryptedContent, err := s.decryptContent(fileMatch.File, rawContent)
-						if err == nil {
-							slog.Debug(fmt.Sprintf("found sops secret file: %s", entry))
-							fileMatch.Type = SOPS_SECRET
-							content = decryptedContent
+						return
+					}
+
+					for _, treeEntry := range tree {
+						if treeEntry.IsTree {
+							continue
 						}
+						scanBlob(project, ref, treeEntry)
 					}
-				}
+				})
+			}
+		})
+	}
+	pool.Wait()
+	for _, fileMatch := range run.finish() {
+		run.report(fileMatch)
+		result = append(result, fileMatch)
+	}
+	if options.LogLate {
+		printFileMatches(result, options)
+	}
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+	return result, searchErr
+}
 
-				// when sops-only is enabled, only search for sops files
-				if options.SopsOnly && fileMatch.Type != SOPS_SECRET {
-					return
-				}
+// getProjects resolves the projects of the options, the errors of all projects which could not be resolved are returned together
+func (s *Git) getProjects(ctx context.Context, options SearchOptions) ([]git.Project, error) {
+	var projects []git.Project
+	var errs []error
+	seen := map[string]bool{}
+	for _, name := range options.Projects {
+		project, err := s.Client.GetProjectByName(ctx, name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		// the same project can be given by its path and its ID
+		if seen[project.PathWithNamespace] {
+			continue
+		}
+		seen[project.PathWithNamespace] = true
+		projects = append(projects, project)
+	}
+	return projects, errors.Join(errs...)
+}
 
-				ok, contentMatches := s.filterContent(content, options)
-				if !ok {
-					return
-				}
-				matches = append(matches, contentMatches...)
-				fileMatch.Matches = matches
-				slog.Debug(fmt.Sprintf("found file: %s", entry))
-				if !options.LogLate {
-					printFileMatch(fileMatch, options)
-				}
-				mu.Lock()
-				result = append(result, fileMatch)
-				mu.Unlock()
-			}(treeEntry)
+/*
+scanChanges scans the files changed since the commit of the previous scan and returns the previous findings
+of all other files. It returns false when the commits can not be compared, then all files need to be scanned.
+*/
+func (s *Git) scanChanges(ctx context.Context, project git.Project, ref git.Ref, previousCommit string, run *searchRun, scanBlob func(git.Project, git.Ref, git.TreeNode)) ([]FileMatch, bool) {
+	var changes []git.FileChange
+	if previousCommit != ref.SHA {
+		var err error
+		changes, err = s.Client.CompareCommits(ctx, project, previousCommit, ref.SHA)
+		if err != nil {
+			slog.Info(fmt.Sprintf("scanning all files of %s@%s: %s", project.PathWithNamespace, ref.Name, err))
+			return nil, false
 		}
 	}
-	wg.Wait()
-	if options.LogLate {
-		printFileMatches(result, options)
+	slog.Debug(fmt.Sprintf("scanning %d files of %s@%s changed since %s", len(changes), project.PathWithNamespace, ref.Name, shortSHA(previousCommit)))
+	carried := run.incremental.carry(project, ref, changes)
+	for _, change := range changes {
+		if change.Status == git.ChangeRemoved {
+			continue
+		}
+		scanBlob(project, ref, git.TreeNode{Path: change.Path, Type: "blob", SHA: change.SHA})
 	}
-	return result, nil
+	return carried, true
 }
 
-func (s *Git) decryptContent(file File, rawContent []byte) (string, error) {
-	fileLocation := filepath.Join(file.Path, file.Name)
-	content := string(rawContent)
-	err := s.Storage.MkdirAll(file.Path)
+// repositoryTree lists the tree of a ref, the tree of a commit never changes, so it is cached by the commit SHA
+func (s *Git) repositoryTree(ctx context.Context, project git.Project, ref git.Ref, options SearchOptions) ([]git.TreeNode, error) {
+	if options.Cache != nil {
+		if tree, ok := options.Cache.Tree(ref.SHA); ok {
+			slog.Debug(fmt.Sprintf("using cached tree of %s@%s", project.PathWithNamespace, ref.Name))
+			return tree, nil
+		}
+	}
+	tree, err := s.Client.ListRepositoryTree(ctx, project, ref.SHA)
 	if err != nil {
-		return content, fmt.Errorf("decrypt: mkdirall error: %s", err)
+		return nil, err
+	}
+	if options.Cache != nil {
+		if err := options.Cache.PutTree(ref.SHA, tree); err != nil {
+			slog.Debug(fmt.Sprintf("caching tree of %s@%s failed: %s", project.PathWithNamespace, ref.Name, err))
+		}
+	}
+	return tree, nil
+}
+
+// readBlob reads the content of a file, unchanged files are read from the cache by their blob SHA
+func (s *Git) readBlob(ctx context.Context, project git.Project, ref git.Ref, treeEntry git.TreeNode, options SearchOptions) ([]byte, error) {
+	cached := options.Cache != nil && treeEntry.SHA != ""
+	if cached {
+		if content, ok := options.Cache.Blob(treeEntry.SHA); ok {
+			return content, nil
+		}
 	}
-	err = s.Storage.WriteFile(fileLocation, rawContent)
+	content, err := s.Client.GetRawFile(ctx, project, ref.SHA, treeEntry.Path)
 	if err != nil {
-		return content, fmt.Errorf("decrypt: write file error: %s", err)
+		return nil, err
+	}
+	if cached {
+		if err := options.Cache.PutBlob(treeEntry.SHA, content); err != nil {
+			slog.Debug(fmt.Sprintf("caching %s of %s failed: %s", treeEntry.Path, project.PathWithNamespace, err))
+		}
+	}
+	return content, nil
+}
+
+// projectFile returns the file of a path of the repository of a project
+func projectFile(project git.Project, ref git.Ref, path string) File {
+	entry := filepath.Join(project.PathWithNamespace, path)
+	return File{
+		Name:          filepath.Base(entry),
+		Path:          filepath.Dir(entry),
+		Type:          FILE,
+		Repository:    project.PathWithNamespace,
+		RepositoryURL: project.WebURL,
+		Ref:           ref.Name,
+		Commit:        ref.SHA,
+	}
+}
+
+/*
+isArchiveCheaper decides the fetch strategy of FetchAuto: the archive is downloaded
+when more files pass the file filters and need their content than archiveThreshold.
+Cached files do not need to be fetched, they are not counted.
+*/
+func (s *Git) isArchiveCheaper(project git.Project, ref git.Ref, tree []git.TreeNode, options SearchOptions) bool {
+	if !isFileContentNeeded(options) {
+		return false
 	}
-	content, err = s.Sops.DecryptFile(fileLocation)
+	candidates := 0
+	for _, treeEntry := range tree {
+		if treeEntry.IsTree {
+			continue
+		}
+		if options.Cache != nil && treeEntry.SHA != "" && options.Cache.HasBlob(treeEntry.SHA) {
+			continue
+		}
+		if ok, _ := s.filterFile(projectFile(project, ref, treeEntry.Path), options); ok {
+			candidates++
+		}
+	}
+	slog.Debug(fmt.Sprintf("%d of %d files of %s@%s pass the file filters", candidates, len(tree), project.PathWithNamespace, ref.Name))
+	return candidates > archiveThreshold
+}
+
+/*
+scanArchive downloads the archive of a ref once and scans its files while it is read instead of fetching them one by one.
+Every file is scanned by scan before the next one is read, so only the content of one file of the archive is in memory.
+The request timeout does not apply to the download.
+*/
+func (s *Git) scanArchive(ctx context.Context, project git.Project, ref git.Ref, options SearchOptions, decrypt func(context.Context, File, []byte, sops.FileType) (string, error), scan func(File, fileSource)) error {
+	archive, err := s.Client.GetArchive(ctx, project, ref.SHA)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+	return git.ReadArchive(archive, func(path string, content io.Reader) error {
+		file := projectFile(project, ref, path)
+		if ok, _ := s.filterFile(file, options); !ok {
+			return ctx.Err()
+		}
+		var data []byte
+		if isFileContentNeeded(options) || sops.IsConfigFile(file.Name) {
+			data, err = io.ReadAll(content)
+			if err != nil {
+				return err
+			}
+			// the archive has no blob SHAs, they are computed to use the files in later scans
+			if options.Cache != nil {
+				if err := options.Cache.PutBlob(git.BlobSHA(data), data); err != nil {
+					slog.Debug(fmt.Sprintf("caching %s of %s failed: %s", path, project.PathWithNamespace, err))
+				}
+			}
+		}
+		scan(file, fileSource{
+			read: func(context.Context) ([]byte, error) {
+				return data, nil
+			},
+			decrypt: decrypt,
+		})
+		return ctx.Err()
+	})
+}
+
+/*
+projectRefs returns the refs of a project to scan: the branches selected by --all-branches or --branch-regex,
+otherwise the ref of the options or the default branch. The files are read at the commit SHA of a ref,
+so a branch moving during the scan is scanned consistently.
+*/
+func (s *Git) projectRefs(ctx context.Context, project git.Project, options SearchOptions) ([]git.Ref, error) {
+	if !options.AllBranches && options.BranchRegex == nil {
+		name := options.Ref
+		if name == "" {
+			name = project.DefaultBranch
+		}
+		ref, err := s.Client.ResolveRef(ctx, project, name)
+		if err != nil {
+			return nil, err
+		}
+		return []git.Ref{ref}, nil
+	}
+	branches, err := s.Client.ListBranches(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	var refs []git.Ref
+	for _, branch := range branches {
+		if options.BranchRegex != nil && !options.BranchRegex.MatchString(branch.Name) {
+			continue
+		}
+		refs = append(refs, branch)
+	}
+	slog.Debug(fmt.Sprintf("scanning %d of %d branches of %s", len(refs), len(branches), project.PathWithNamespace))
+	return refs, nil
+}
+
+// decryptContent decrypts the content as it was read, the files of the refs of a repository are decrypted concurrently
+func (s *Git) decryptContent(ctx context.Context, file File, rawContent []byte, fileType sops.FileType) (string, error) {
+	content, err := s.Sops.Decrypt(ctx, rawContent, fileType)
 	if err != nil {
-		return content, fmt.Errorf("decrypt: decrypt error: %s", err)
+		return string(rawContent), fmt.Errorf("decrypt: decrypt error: %s", err)
 	}
 	return content, nil
 }
@@ -3,6 +3,9 @@
 package scanner
 
 import (
+	context "context"
+	io "io"
+
 	git "github.com/alican-uelger/deep-scan/internal/git"
 	mock "github.com/stretchr/testify/mock"
 )
@@ -20,9 +23,130 @@ func (_m *GitClientMock) EXPECT() *GitClientMock_Expecter {
 	return &GitClientMock_Expecter{mock: &_m.Mock}
 }
 
-// GetProjectByName provides a mock function with given fields: name
-func (_m *GitClientMock) GetProjectByName(name string) (git.Project, error) {
-	ret := _m.Called(name)
+// CompareCommits provides a mock function with given fields: ctx, project, base, head
+func (_m *GitClientMock) CompareCommits(ctx context.Context, project git.Project, base string, head string) ([]git.FileChange, error) {
+	ret := _m.Called(ctx, project, base, head)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CompareCommits")
+	}
+
+	var r0 []git.FileChange
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, git.Project, string, string) ([]git.FileChange, error)); ok {
+		return rf(ctx, project, base, head)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, git.Project, string, string) []git.FileChange); ok {
+		r0 = rf(ctx, project, base, head)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]git.FileChange)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, git.Project, string, string) error); ok {
+		r1 = rf(ctx, project, base, head)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GitClientMock_CompareCommits_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CompareCommits'
+type GitClientMock_CompareCommits_Call struct {
+	*mock.Call
+}
+
+// CompareCommits is a helper method to define mock.On call
+//   - ctx context.Context
+//   - project git.Project
+//   - base string
+//   - head string
+func (_e *GitClientMock_Expecter) CompareCommits(ctx interface{}, project interface{}, base interface{}, head interface{}) *GitClientMock_CompareCommits_Call {
+	return &GitClientMock_CompareCommits_Call{Call: _e.mock.On("CompareCommits", ctx, project, base, head)}
+}
+
+func (_c *GitClientMock_CompareCommits_Call) Run(run func(ctx context.Context, project git.Project, base string, head string)) *GitClientMock_CompareCommits_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(git.Project), args[2].(string), args[3].(string))
+	})
+	return _c
+}
+
+func (_c *GitClientMock_CompareCommits_Call) Return(_a0 []git.FileChange, _a1 error) *GitClientMock_CompareCommits_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GitClientMock_CompareCommits_Call) RunAndReturn(run func(context.Context, git.Project, string, string) ([]git.FileChange, error)) *GitClientMock_CompareCommits_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetArchive provides a mock function with given fields: ctx, project, ref
+func (_m *GitClientMock) GetArchive(ctx context.Context, project git.Project, ref string) (io.ReadCloser, error) {
+	ret := _m.Called(ctx, project, ref)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetArchive")
+	}
+
+	var r0 io.ReadCloser
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, git.Project, string) (io.ReadCloser, error)); ok {
+		return rf(ctx, project, ref)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, git.Project, string) io.ReadCloser); ok {
+		r0 = rf(ctx, project, ref)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(io.ReadCloser)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, git.Project, string) error); ok {
+		r1 = rf(ctx, project, ref)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GitClientMock_GetArchive_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetArchive'
+type GitClientMock_GetArchive_Call struct {
+	*mock.Call
+}
+
+// GetArchive is a helper method to define mock.On call
+//   - ctx context.Context
+//   - project git.Project
+//   - ref string
+func (_e *GitClientMock_Expecter) GetArchive(ctx interface{}, project interface{}, ref interface{}) *GitClientMock_GetArchive_Call {
+	return &GitClientMock_GetArchive_Call{Call: _e.mock.On("GetArchive", ctx, project, ref)}
+}
+
+func (_c *GitClientMock_GetArchive_Call) Run(run func(ctx context.Context, project git.Project, ref string)) *GitClientMock_GetArchive_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(git.Project), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *GitClientMock_GetArchive_Call) Return(_a0 io.ReadCloser, _a1 error) *GitClientMock_GetArchive_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GitClientMock_GetArchive_Call) RunAndReturn(run func(context.Context, git.Project, string) (io.ReadCloser, error)) *GitClientMock_GetArchive_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetProjectByName provides a mock function with given fields: ctx, name
+func (_m *GitClientMock) GetProjectByName(ctx context.Context, name string) (git.Project, error) {
+	ret := _m.Called(ctx, name)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetProjectByName")
@@ -30,17 +154,17 @@ func (_m *GitClientMock) GetProjectByName(name string) (git.Project, error) {
 
 	var r0 git.Project
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (git.Project, error)); ok {
-		return rf(name)
+	if rf, ok := ret.Get(0).(func(context.Context, string) (git.Project, error)); ok {
+		return rf(ctx, name)
 	}
-	if rf, ok := ret.Get(0).(func(string) git.Project); ok {
-		r0 = rf(name)
+	if rf, ok := ret.Get(0).(func(context.Context, string) git.Project); ok {
+		r0 = rf(ctx, name)
 	} else {
 		r0 = ret.Get(0).(git.Project)
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(name)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -54,14 +178,15 @@ type GitClientMock_GetProjectByName_Call struct {
 }
 
 // GetProjectByName is a helper method to define mock.On call
+//   - ctx context.Context
 //   - name string
-func (_e *GitClientMock_Expecter) GetProjectByName(name interface{}) *GitClientMock_GetProjectByName_Call {
-	return &GitClientMock_GetProjectByName_Call{Call: _e.mock.On("GetProjectByName", name)}
+func (_e *GitClientMock_Expecter) GetProjectByName(ctx interface{}, name interface{}) *GitClientMock_GetProjectByName_Call {
+	return &GitClientMock_GetProjectByName_Call{Call: _e.mock.On("GetProjectByName", ctx, name)}
 }
 
-func (_c *GitClientMock_GetProjectByName_Call) Run(run func(name string)) *GitClientMock_GetProjectByName_Call {
+func (_c *GitClientMock_GetProjectByName_Call) Run(run func(ctx context.Context, name string)) *GitClientMock_GetProjectByName_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string))
+		run(args[0].(context.Context), args[1].(string))
 	})
 	return _c
 }
@@ -71,14 +196,14 @@ func (_c *GitClientMock_GetProjectByName_Call) Return(_a0 git.Project, _a1 error
 	return _c
 }
 
-func (_c *GitClientMock_GetProjectByName_Call) RunAndReturn(run func(string) (git.Project, error)) *GitClientMock_GetProjectByName_Call {
+func (_c *GitClientMock_GetProjectByName_Call) RunAndReturn(run func(context.Context, string) (git.Project, error)) *GitClientMock_GetProjectByName_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetRawFile provides a mock function with given fields: project, path
-func (_m *GitClientMock) GetRawFile(project git.Project, path string) ([]byte, error) {
-	ret := _m.Called(project, path)
+// GetRawFile provides a mock function with given fields: ctx, project, ref, path
+func (_m *GitClientMock) GetRawFile(ctx context.Context, project git.Project, ref string, path string) ([]byte, error) {
+	ret := _m.Called(ctx, project, ref, path)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetRawFile")
@@ -86,19 +211,19 @@ func (_m *GitClientMock) GetRawFile(project git.Project, path string) ([]byte, e
 
 	var r0 []byte
 	var r1 error
-	if rf, ok := ret.Get(0).(func(git.Project, string) ([]byte, error)); ok {
-		return rf(project, path)
+	if rf, ok := ret.Get(0).(func(context.Context, git.Project, string, string) ([]byte, error)); ok {
+		return rf(ctx, project, ref, path)
 	}
-	if rf, ok := ret.Get(0).(func(git.Project, string) []byte); ok {
-		r0 = rf(project, path)
+	if rf, ok := ret.Get(0).(func(context.Context, git.Project, string, string) []byte); ok {
+		r0 = rf(ctx, project, ref, path)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]byte)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(git.Project, string) error); ok {
-		r1 = rf(project, path)
+	if rf, ok := ret.Get(1).(func(context.Context, git.Project, string, string) error); ok {
+		r1 = rf(ctx, project, ref, path)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -112,15 +237,17 @@ type GitClientMock_GetRawFile_Call struct {
 }
 
 // GetRawFile is a helper method to define mock.On call
+//   - ctx context.Context
 //   - project git.Project
+//   - ref string
 //   - path string
-func (_e *GitClientMock_Expecter) GetRawFile(project interface{}, path interface{}) *GitClientMock_GetRawFile_Call {
-	return &GitClientMock_GetRawFile_Call{Call: _e.mock.On("GetRawFile", project, path)}
+func (_e *GitClientMock_Expecter) GetRawFile(ctx interface{}, project interface{}, ref interface{}, path interface{}) *GitClientMock_GetRawFile_Call {
+	return &GitClientMock_GetRawFile_Call{Call: _e.mock.On("GetRawFile", ctx, project, ref, path)}
 }
 
-func (_c *GitClientMock_GetRawFile_Call) Run(run func(project git.Project, path string)) *GitClientMock_GetRawFile_Call {
+func (_c *GitClientMock_GetRawFile_Call) Run(run func(ctx context.Context, project git.Project, ref string, path string)) *GitClientMock_GetRawFile_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(git.Project), args[1].(string))
+		run(args[0].(context.Context), args[1].(git.Project), args[2].(string), args[3].(string))
 	})
 	return _c
 }
@@ -130,14 +257,73 @@ func (_c *GitClientMock_GetRawFile_Call) Return(_a0 []byte, _a1 error) *GitClien
 	return _c
 }
 
-func (_c *GitClientMock_GetRawFile_Call) RunAndReturn(run func(git.Project, string) ([]byte, error)) *GitClientMock_GetRawFile_Call {
+func (_c *GitClientMock_GetRawFile_Call) RunAndReturn(run func(context.Context, git.Project, string, string) ([]byte, error)) *GitClientMock_GetRawFile_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// ListGroupProjects provides a mock function with given fields: group
-func (_m *GitClientMock) ListGroupProjects(group string) ([]git.Project, error) {
-	ret := _m.Called(group)
+// ListBranches provides a mock function with given fields: ctx, project
+func (_m *GitClientMock) ListBranches(ctx context.Context, project git.Project) ([]git.Ref, error) {
+	ret := _m.Called(ctx, project)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListBranches")
+	}
+
+	var r0 []git.Ref
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, git.Project) ([]git.Ref, error)); ok {
+		return rf(ctx, project)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, git.Project) []git.Ref); ok {
+		r0 = rf(ctx, project)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]git.Ref)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, git.Project) error); ok {
+		r1 = rf(ctx, project)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GitClientMock_ListBranches_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListBranches'
+type GitClientMock_ListBranches_Call struct {
+	*mock.Call
+}
+
+// ListBranches is a helper method to define mock.On call
+//   - ctx context.Context
+//   - project git.Project
+func (_e *GitClientMock_Expecter) ListBranches(ctx interface{}, project interface{}) *GitClientMock_ListBranches_Call {
+	return &GitClientMock_ListBranches_Call{Call: _e.mock.On("ListBranches", ctx, project)}
+}
+
+func (_c *GitClientMock_ListBranches_Call) Run(run func(ctx context.Context, project git.Project)) *GitClientMock_ListBranches_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(git.Project))
+	})
+	return _c
+}
+
+func (_c *GitClientMock_ListBranches_Call) Return(_a0 []git.Ref, _a1 error) *GitClientMock_ListBranches_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GitClientMock_ListBranches_Call) RunAndReturn(run func(context.Context, git.Project) ([]git.Ref, error)) *GitClientMock_ListBranches_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListGroupProjects provides a mock function with given fields: ctx, group, filter
+func (_m *GitClientMock) ListGroupProjects(ctx context.Context, group string, filter git.ProjectFilter) ([]git.Project, error) {
+	ret := _m.Called(ctx, group, filter)
 
 	if len(ret) == 0 {
 		panic("no return value specified for ListGroupProjects")
@@ -145,19 +331,19 @@ func (_m *GitClientMock) ListGroupProjects(group string) ([]git.Project, error)
 
 	var r0 []git.Project
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) ([]git.Project, error)); ok {
-		return rf(group)
+	if rf, ok := ret.Get(0).(func(context.Context, string, git.ProjectFilter) ([]git.Project, error)); ok {
+		return rf(ctx, group, filter)
 	}
-	if rf, ok := ret.Get(0).(func(string) []git.Project); ok {
-		r0 = rf(group)
+	if rf, ok := ret.Get(0).(func(context.Context, string, git.ProjectFilter) []git.Project); ok {
+		r0 = rf(ctx, group, filter)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]git.Project)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(group)
+	if rf, ok := ret.Get(1).(func(context.Context, string, git.ProjectFilter) error); ok {
+		r1 = rf(ctx, group, filter)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -171,14 +357,16 @@ type GitClientMock_ListGroupProjects_Call struct {
 }
 
 // ListGroupProjects is a helper method to define mock.On call
+//   - ctx context.Context
 //   - group string
-func (_e *GitClientMock_Expecter) ListGroupProjects(group interface{}) *GitClientMock_ListGroupProjects_Call {
-	return &GitClientMock_ListGroupProjects_Call{Call: _e.mock.On("ListGroupProjects", group)}
+//   - filter git.ProjectFilter
+func (_e *GitClientMock_Expecter) ListGroupProjects(ctx interface{}, group interface{}, filter interface{}) *GitClientMock_ListGroupProjects_Call {
+	return &GitClientMock_ListGroupProjects_Call{Call: _e.mock.On("ListGroupProjects", ctx, group, filter)}
 }
 
-func (_c *GitClientMock_ListGroupProjects_Call) Run(run func(group string)) *GitClientMock_ListGroupProjects_Call {
+func (_c *GitClientMock_ListGroupProjects_Call) Run(run func(ctx context.Context, group string, filter git.ProjectFilter)) *GitClientMock_ListGroupProjects_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string))
+		run(args[0].(context.Context), args[1].(string), args[2].(git.ProjectFilter))
 	})
 	return _c
 }
@@ -188,14 +376,14 @@ func (_c *GitClientMock_ListGroupProjects_Call) Return(_a0 []git.Project, _a1 er
 	return _c
 }
 
-func (_c *GitClientMock_ListGroupProjects_Call) RunAndReturn(run func(string) ([]git.Project, error)) *GitClientMock_ListGroupProjects_Call {
+func (_c *GitClientMock_ListGroupProjects_Call) RunAndReturn(run func(context.Context, string, git.ProjectFilter) ([]git.Project, error)) *GitClientMock_ListGroupProjects_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// ListRepositoryTree provides a mock function with given fields: project
-func (_m *GitClientMock) ListRepositoryTree(project git.Project) ([]git.TreeNode, error) {
-	ret := _m.Called(project)
+// ListRepositoryTree provides a mock function with given fields: ctx, project, ref
+func (_m *GitClientMock) ListRepositoryTree(ctx context.Context, project git.Project, ref string) ([]git.TreeNode, error) {
+	ret := _m.Called(ctx, project, ref)
 
 	if len(ret) == 0 {
 		panic("no return value specified for ListRepositoryTree")
@@ -203,19 +391,19 @@ func (_m *GitClientMock) ListRepositoryTree(project git.Project) ([]git.TreeNode
 
 	var r0 []git.TreeNode
 	var r1 error
-	if rf, ok := ret.Get(0).(func(git.Project) ([]git.TreeNode, error)); ok {
-		return rf(project)
+	if rf, ok := ret.Get(0).(func(context.Context, git.Project, string) ([]git.TreeNode, error)); ok {
+		return rf(ctx, project, ref)
 	}
-	if rf, ok := ret.Get(0).(func(git.Project) []git.TreeNode); ok {
-		r0 = rf(project)
+	if rf, ok := ret.Get(0).(func(context.Context, git.Project, string) []git.TreeNode); ok {
+		r0 = rf(ctx, project, ref)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]git.TreeNode)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(git.Project) error); ok {
-		r1 = rf(project)
+	if rf, ok := ret.Get(1).(func(context.Context, git.Project, string) error); ok {
+		r1 = rf(ctx, project, ref)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -229,14 +417,16 @@ type GitClientMock_ListRepositoryTree_Call struct {
 }
 
 // ListRepositoryTree is a helper method to define mock.On call
+//   - ctx context.Context
 //   - project git.Project
-func (_e *GitClientMock_Expecter) ListRepositoryTree(project interface{}) *GitClientMock_ListRepositoryTree_Call {
-	return &GitClientMock_ListRepositoryTree_Call{Call: _e.mock.On("ListRepositoryTree", project)}
+//   - ref string
+func (_e *GitClientMock_Expecter) ListRepositoryTree(ctx interface{}, project interface{}, ref interface{}) *GitClientMock_ListRepositoryTree_Call {
+	return &GitClientMock_ListRepositoryTree_Call{Call: _e.mock.On("ListRepositoryTree", ctx, project, ref)}
 }
 
-func (_c *GitClientMock_ListRepositoryTree_Call) Run(run func(project git.Project)) *GitClientMock_ListRepositoryTree_Call {
+func (_c *GitClientMock_ListRepositoryTree_Call) Run(run func(ctx context.Context, project git.Project, ref string)) *GitClientMock_ListRepositoryTree_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(git.Project))
+		run(args[0].(context.Context), args[1].(git.Project), args[2].(string))
 	})
 	return _c
 }
@@ -246,7 +436,65 @@ func (_c *GitClientMock_ListRepositoryTree_Call) Return(_a0 []git.TreeNode, _a1
 	return _c
 }
 
-func (_c *GitClientMock_ListRepositoryTree_Call) RunAndReturn(run func(git.Project) ([]git.TreeNode, error)) *GitClientMock_ListRepositoryTree_Call {
+func (_c *GitClientMock_ListRepositoryTree_Call) RunAndReturn(run func(context.Context, git.Project, string) ([]git.TreeNode, error)) *GitClientMock_ListRepositoryTree_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ResolveRef provides a mock function with given fields: ctx, project, ref
+func (_m *GitClientMock) ResolveRef(ctx context.Context, project git.Project, ref string) (git.Ref, error) {
+	ret := _m.Called(ctx, project, ref)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResolveRef")
+	}
+
+	var r0 git.Ref
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, git.Project, string) (git.Ref, error)); ok {
+		return rf(ctx, project, ref)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, git.Project, string) git.Ref); ok {
+		r0 = rf(ctx, project, ref)
+	} else {
+		r0 = ret.Get(0).(git.Ref)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, git.Project, string) error); ok {
+		r1 = rf(ctx, project, ref)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GitClientMock_ResolveRef_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ResolveRef'
+type GitClientMock_ResolveRef_Call struct {
+	*mock.Call
+}
+
+// ResolveRef is a helper method to define mock.On call
+//   - ctx context.Context
+//   - project git.Project
+//   - ref string
+func (_e *GitClientMock_Expecter) ResolveRef(ctx interface{}, project interface{}, ref interface{}) *GitClientMock_ResolveRef_Call {
+	return &GitClientMock_ResolveRef_Call{Call: _e.mock.On("ResolveRef", ctx, project, ref)}
+}
+
+func (_c *GitClientMock_ResolveRef_Call) Run(run func(ctx context.Context, project git.Project, ref string)) *GitClientMock_ResolveRef_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(git.Project), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *GitClientMock_ResolveRef_Call) Return(_a0 git.Ref, _a1 error) *GitClientMock_ResolveRef_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GitClientMock_ResolveRef_Call) RunAndReturn(run func(context.Context, git.Project, string) (git.Ref, error)) *GitClientMock_ResolveRef_Call {
 	_c.Call.Return(run)
 	return _c
 }
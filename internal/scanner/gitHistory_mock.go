@@ -0,0 +1,145 @@
+// Code generated by mockery v2.53.4. DO NOT EDIT.
+
+package scanner
+
+import (
+	context "context"
+
+	history "github.com/alican-uelger/deep-scan/internal/history"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// GitHistoryMock is an autogenerated mock type for the GitHistory type
+type GitHistoryMock struct {
+	mock.Mock
+}
+
+type GitHistoryMock_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *GitHistoryMock) EXPECT() *GitHistoryMock_Expecter {
+	return &GitHistoryMock_Expecter{mock: &_m.Mock}
+}
+
+// Show provides a mock function with given fields: ctx, rev, path
+func (_m *GitHistoryMock) Show(ctx context.Context, rev string, path string) ([]byte, error) {
+	ret := _m.Called(ctx, rev, path)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Show")
+	}
+
+	var r0 []byte
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) ([]byte, error)); ok {
+		return rf(ctx, rev, path)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []byte); ok {
+		r0 = rf(ctx, rev, path)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, rev, path)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GitHistoryMock_Show_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Show'
+type GitHistoryMock_Show_Call struct {
+	*mock.Call
+}
+
+// Show is a helper method to define mock.On call
+//   - ctx context.Context
+//   - rev string
+//   - path string
+func (_e *GitHistoryMock_Expecter) Show(ctx interface{}, rev interface{}, path interface{}) *GitHistoryMock_Show_Call {
+	return &GitHistoryMock_Show_Call{Call: _e.mock.On("Show", ctx, rev, path)}
+}
+
+func (_c *GitHistoryMock_Show_Call) Run(run func(ctx context.Context, rev string, path string)) *GitHistoryMock_Show_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *GitHistoryMock_Show_Call) Return(_a0 []byte, _a1 error) *GitHistoryMock_Show_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *GitHistoryMock_Show_Call) RunAndReturn(run func(context.Context, string, string) ([]byte, error)) *GitHistoryMock_Show_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Walk provides a mock function with given fields: ctx, options, fn
+func (_m *GitHistoryMock) Walk(ctx context.Context, options history.LogOptions, fn func(history.Change) error) error {
+	ret := _m.Called(ctx, options, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Walk")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, history.LogOptions, func(history.Change) error) error); ok {
+		r0 = rf(ctx, options, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GitHistoryMock_Walk_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Walk'
+type GitHistoryMock_Walk_Call struct {
+	*mock.Call
+}
+
+// Walk is a helper method to define mock.On call
+//   - ctx context.Context
+//   - options history.LogOptions
+//   - fn func(history.Change) error
+func (_e *GitHistoryMock_Expecter) Walk(ctx interface{}, options interface{}, fn interface{}) *GitHistoryMock_Walk_Call {
+	return &GitHistoryMock_Walk_Call{Call: _e.mock.On("Walk", ctx, options, fn)}
+}
+
+func (_c *GitHistoryMock_Walk_Call) Run(run func(ctx context.Context, options history.LogOptions, fn func(history.Change) error)) *GitHistoryMock_Walk_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(history.LogOptions), args[2].(func(history.Change) error))
+	})
+	return _c
+}
+
+func (_c *GitHistoryMock_Walk_Call) Return(_a0 error) *GitHistoryMock_Walk_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *GitHistoryMock_Walk_Call) RunAndReturn(run func(context.Context, history.LogOptions, func(history.Change) error) error) *GitHistoryMock_Walk_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewGitHistoryMock creates a new instance of GitHistoryMock. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewGitHistoryMock(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *GitHistoryMock {
+	mock := &GitHistoryMock{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -3,22 +3,37 @@
 package scanner
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
 	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"regexp"
 	"testing"
 
+	"github.com/alican-uelger/deep-scan/internal/cache"
 	"github.com/alican-uelger/deep-scan/internal/git"
+	"github.com/alican-uelger/deep-scan/internal/matcher"
 	"github.com/alican-uelger/deep-scan/internal/sops"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGitSearchSuccessfulSearch(t *testing.T) {
 	mockProject := git.Project{ID: 1, PathWithNamespace: "org/repo"}
 	mockClient := NewGitClientMock(t)
 	mockClient.
-		On("ListGroupProjects", "org").
+		On("ListGroupProjects", mock.Anything, "org", mock.Anything).
 		Return([]git.Project{mockProject}, nil)
 	mockClient.
-		On("ListRepositoryTree", mockProject).
+		On("ResolveRef", mock.Anything, mockProject, "").
+		Return(git.Ref{Name: "HEAD", SHA: "abc123"}, nil)
+	mockClient.
+		On("ListRepositoryTree", mock.Anything, mockProject, "abc123").
 		Return([]git.TreeNode{{Path: "file.txt", IsTree: false}}, nil)
 
 	mockTextMatcher := NewTextMatcherMock(t)
@@ -33,9 +48,9 @@ func TestGitSearchSuccessfulSearch(t *testing.T) {
 		},
 	}
 
-	result, err := g.Search("org", SearchOptions{})
+	result, err := g.Search(context.Background(), "org", SearchOptions{})
 	expected := []FileMatch{
-		{File: File{Name: "file.txt", Path: "org/repo", Type: FILE}},
+		{File: File{Name: "file.txt", Path: "org/repo", Type: FILE, Repository: "org/repo", Ref: "HEAD", Commit: "abc123"}},
 	}
 
 	assert.Equal(t, expected, result)
@@ -45,7 +60,7 @@ func TestGitSearchSuccessfulSearch(t *testing.T) {
 func TestGitSearchClientError(t *testing.T) {
 	mockClient := NewGitClientMock(t)
 	mockClient.
-		On("ListGroupProjects", "org").
+		On("ListGroupProjects", mock.Anything, "org", mock.Anything).
 		Return(nil, errors.New("client error"))
 
 	mockStorage := NewStorageMock(t)
@@ -60,7 +75,7 @@ func TestGitSearchClientError(t *testing.T) {
 		},
 	}
 
-	result, err := g.Search("org", SearchOptions{})
+	result, err := g.Search(context.Background(), "org", SearchOptions{})
 
 	mockClient.AssertNumberOfCalls(t, "ListGroupProjects", 1)
 
@@ -73,7 +88,7 @@ func TestGitSearchClientError(t *testing.T) {
 func TestGitSearchEmptyGroupProjects(t *testing.T) {
 	mockClient := NewGitClientMock(t)
 	mockClient.
-		On("ListGroupProjects", "org").
+		On("ListGroupProjects", mock.Anything, "org", mock.Anything).
 		Return([]git.Project{}, nil)
 
 	mockStorage := NewStorageMock(t)
@@ -88,7 +103,7 @@ func TestGitSearchEmptyGroupProjects(t *testing.T) {
 		},
 	}
 
-	result, err := g.Search("org", SearchOptions{})
+	result, err := g.Search(context.Background(), "org", SearchOptions{})
 
 	mockClient.AssertNumberOfCalls(t, "ListGroupProjects", 1)
 	mockClient.AssertNumberOfCalls(t, "ListRepositoryTree", 0)
@@ -103,10 +118,13 @@ func TestGitSearchEmptyRepositoryTree(t *testing.T) {
 	mockProject := git.Project{ID: 1, PathWithNamespace: "org/repo"}
 	mockClient := NewGitClientMock(t)
 	mockClient.
-		On("ListGroupProjects", "org").
+		On("ListGroupProjects", mock.Anything, "org", mock.Anything).
 		Return([]git.Project{mockProject}, nil)
 	mockClient.
-		On("ListRepositoryTree", mockProject).
+		On("ResolveRef", mock.Anything, mockProject, "").
+		Return(git.Ref{Name: "HEAD", SHA: "abc123"}, nil)
+	mockClient.
+		On("ListRepositoryTree", mock.Anything, mockProject, "abc123").
 		Return([]git.TreeNode{}, nil)
 
 	mockStorage := NewStorageMock(t)
@@ -121,7 +139,7 @@ func TestGitSearchEmptyRepositoryTree(t *testing.T) {
 		},
 	}
 
-	result, err := g.Search("org", SearchOptions{})
+	result, err := g.Search(context.Background(), "org", SearchOptions{})
 	var expected []FileMatch
 
 	assert.Equal(t, expected, result)
@@ -132,10 +150,13 @@ func TestGitSearchByProject_Success(t *testing.T) {
 	mockProject := git.Project{ID: 2, PathWithNamespace: "owner/repo"}
 	mockClient := NewGitClientMock(t)
 	mockClient.
-		On("GetProjectByName", "owner/repo").
+		On("GetProjectByName", mock.Anything, "owner/repo").
 		Return(mockProject, nil)
 	mockClient.
-		On("ListRepositoryTree", mockProject).
+		On("ResolveRef", mock.Anything, mockProject, "").
+		Return(git.Ref{Name: "HEAD", SHA: "abc123"}, nil)
+	mockClient.
+		On("ListRepositoryTree", mock.Anything, mockProject, "abc123").
 		Return([]git.TreeNode{{Path: "main.go", IsTree: false}}, nil)
 
 	mockTextMatcher := NewTextMatcherMock(t)
@@ -150,13 +171,13 @@ func TestGitSearchByProject_Success(t *testing.T) {
 		},
 	}
 
-	result, err := g.Search("", SearchOptions{Project: "owner/repo"})
+	result, err := g.Search(context.Background(), "", SearchOptions{Projects: []string{"owner/repo"}})
 
 	mockClient.AssertNumberOfCalls(t, "ListGroupProjects", 0)
 	mockClient.AssertNumberOfCalls(t, "GetProjectByName", 1)
 
 	expected := []FileMatch{
-		{File: File{Name: "main.go", Path: "owner/repo", Type: FILE}},
+		{File: File{Name: "main.go", Path: "owner/repo", Type: FILE, Repository: "owner/repo", Ref: "HEAD", Commit: "abc123"}},
 	}
 	assert.Equal(t, expected, result)
 	assert.Nil(t, err)
@@ -165,7 +186,7 @@ func TestGitSearchByProject_Success(t *testing.T) {
 func TestGitSearchByProject_NotFound(t *testing.T) {
 	mockClient := NewGitClientMock(t)
 	mockClient.
-		On("GetProjectByName", "owner/nonexistent").
+		On("GetProjectByName", mock.Anything, "owner/nonexistent").
 		Return(git.Project{}, errors.New("project not found: owner/nonexistent"))
 
 	mockStorage := NewStorageMock(t)
@@ -180,7 +201,7 @@ func TestGitSearchByProject_NotFound(t *testing.T) {
 		},
 	}
 
-	result, err := g.Search("", SearchOptions{Project: "owner/nonexistent"})
+	result, err := g.Search(context.Background(), "", SearchOptions{Projects: []string{"owner/nonexistent"}})
 
 	mockClient.AssertNumberOfCalls(t, "ListGroupProjects", 0)
 	mockClient.AssertNumberOfCalls(t, "GetProjectByName", 1)
@@ -190,3 +211,573 @@ func TestGitSearchByProject_NotFound(t *testing.T) {
 	assert.EqualError(t, err, "project not found: owner/nonexistent")
 }
 
+func TestGitSearchByProjects(t *testing.T) {
+	mockProject := git.Project{ID: 2, PathWithNamespace: "owner/repo"}
+	mockClient := NewGitClientMock(t)
+	mockClient.
+		On("GetProjectByName", mock.Anything, "owner/repo").
+		Return(mockProject, nil)
+	mockClient.
+		On("GetProjectByName", mock.Anything, "2").
+		Return(mockProject, nil)
+	mockClient.
+		On("ResolveRef", mock.Anything, mockProject, "").
+		Return(git.Ref{Name: "HEAD", SHA: "abc123"}, nil)
+	mockClient.
+		On("ListRepositoryTree", mock.Anything, mockProject, "abc123").
+		Return([]git.TreeNode{{Path: "main.go", IsTree: false}}, nil)
+
+	mockStorage := NewStorageMock(t)
+	g := &Git{
+		Client: mockClient,
+		Base: Base{
+			Storage:     mockStorage,
+			Sops:        sops.New(mockStorage),
+			TextMatcher: NewTextMatcherMock(t),
+		},
+	}
+
+	// the same project given by its path and its ID is scanned once
+	result, err := g.Search(context.Background(), "", SearchOptions{Projects: []string{"owner/repo", "2"}})
+
+	assert.NoError(t, err)
+	assert.Len(t, result, 1)
+	mockClient.AssertNumberOfCalls(t, "ListRepositoryTree", 1)
+}
+
+func TestGitSearchByProjects_NotFound(t *testing.T) {
+	mockClient := NewGitClientMock(t)
+	mockClient.
+		On("GetProjectByName", mock.Anything, "owner/repo").
+		Return(git.Project{ID: 2, PathWithNamespace: "owner/repo"}, nil)
+	mockClient.
+		On("GetProjectByName", mock.Anything, "owner/a").
+		Return(git.Project{}, errors.New("project not found: owner/a"))
+	mockClient.
+		On("GetProjectByName", mock.Anything, "owner/b").
+		Return(git.Project{}, errors.New("project not found: owner/b"))
+
+	mockStorage := NewStorageMock(t)
+	g := &Git{
+		Client: mockClient,
+		Base: Base{
+			Storage:     mockStorage,
+			Sops:        sops.New(mockStorage),
+			TextMatcher: NewTextMatcherMock(t),
+		},
+	}
+
+	result, err := g.Search(context.Background(), "", SearchOptions{Projects: []string{"owner/a", "owner/repo", "owner/b"}})
+
+	assert.Empty(t, result)
+	assert.EqualError(t, err, "project not found: owner/a\nproject not found: owner/b")
+	mockClient.AssertNumberOfCalls(t, "ListRepositoryTree", 0)
+}
+
+func TestGitSearchBranches(t *testing.T) {
+	mockProject := git.Project{ID: 1, PathWithNamespace: "org/repo", DefaultBranch: "main"}
+	mockClient := NewGitClientMock(t)
+	mockClient.
+		On("ListGroupProjects", mock.Anything, "org", mock.Anything).
+		Return([]git.Project{mockProject}, nil)
+	mockClient.
+		On("ListBranches", mock.Anything, mockProject).
+		Return([]git.Ref{{Name: "main", SHA: "abc123"}, {Name: "release/1.0", SHA: "def456"}, {Name: "feature/x", SHA: "789abc"}}, nil)
+	mockClient.
+		On("ListRepositoryTree", mock.Anything, mockProject, "abc123").
+		Return([]git.TreeNode{{Path: "file.txt", IsTree: false}}, nil)
+	mockClient.
+		On("ListRepositoryTree", mock.Anything, mockProject, "def456").
+		Return([]git.TreeNode{{Path: "file.txt", IsTree: false}}, nil)
+
+	mockStorage := NewStorageMock(t)
+	g := &Git{
+		Client: mockClient,
+		Base: Base{
+			Storage:     mockStorage,
+			Sops:        sops.New(mockStorage),
+			TextMatcher: NewTextMatcherMock(t),
+		},
+	}
+
+	result, err := g.Search(context.Background(), "org", SearchOptions{BranchRegex: regexp.MustCompile(`^(main|release/.*)$`)})
+
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []FileMatch{
+		{File: File{Name: "file.txt", Path: "org/repo", Type: FILE, Repository: "org/repo", Ref: "main", Commit: "abc123"}},
+		{File: File{Name: "file.txt", Path: "org/repo", Type: FILE, Repository: "org/repo", Ref: "release/1.0", Commit: "def456"}},
+	}, result)
+	mockClient.AssertNumberOfCalls(t, "ResolveRef", 0)
+}
+
+func TestGitSearchRef(t *testing.T) {
+	mockProject := git.Project{ID: 1, PathWithNamespace: "org/repo", DefaultBranch: "main"}
+	mockClient := NewGitClientMock(t)
+	mockClient.
+		On("ListGroupProjects", mock.Anything, "org", mock.Anything).
+		Return([]git.Project{mockProject}, nil)
+	mockClient.
+		On("ResolveRef", mock.Anything, mockProject, "v1.0.0").
+		Return(git.Ref{}, errors.New("could not resolve ref v1.0.0 of org/repo: 404 Not Found"))
+
+	mockStorage := NewStorageMock(t)
+	g := &Git{
+		Client: mockClient,
+		Base: Base{
+			Storage:     mockStorage,
+			Sops:        sops.New(mockStorage),
+			TextMatcher: NewTextMatcherMock(t),
+		},
+	}
+
+	result, err := g.Search(context.Background(), "org", SearchOptions{Ref: "v1.0.0"})
+
+	assert.Empty(t, result)
+	assert.EqualError(t, err, "could not resolve ref v1.0.0 of org/repo: 404 Not Found")
+}
+
+// testArchive creates a gzipped tarball with the files below a root directory, like the archives of GitHub and GitLab
+func testArchive(t *testing.T, files map[string]string) io.ReadCloser {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	writer := tar.NewWriter(gz)
+	for name, content := range files {
+		require.NoError(t, writer.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: "org-repo-abc123/" + name, Mode: 0644, Size: int64(len(content))}))
+		_, err := writer.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+	require.NoError(t, gz.Close())
+	return io.NopCloser(&buf)
+}
+
+func TestGitSearchFetchArchive(t *testing.T) {
+	mockProject := git.Project{ID: 1, PathWithNamespace: "org/repo"}
+	mockClient := NewGitClientMock(t)
+	mockClient.
+		On("ListGroupProjects", mock.Anything, "org", mock.Anything).
+		Return([]git.Project{mockProject}, nil)
+	mockClient.
+		On("ResolveRef", mock.Anything, mockProject, "").
+		Return(git.Ref{Name: "HEAD", SHA: "abc123"}, nil)
+	mockClient.
+		On("GetArchive", mock.Anything, mockProject, "abc123").
+		Return(testArchive(t, map[string]string{
+			"config/app.yaml": "token: secret",
+			"README.md":       "# repo",
+		}), nil)
+
+	mockStorage := NewStorageMock(t)
+	g := &Git{
+		Client: mockClient,
+		Base: Base{
+			Storage:     mockStorage,
+			Sops:        sops.New(mockStorage),
+			TextMatcher: matcher.NewText(),
+		},
+	}
+
+	result, err := g.Search(context.Background(), "org", SearchOptions{Fetch: FetchArchive, Content: []string{"secret"}, Silent: true})
+
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	assert.Equal(t, File{Name: "app.yaml", Path: "org/repo/config", Type: FILE, Repository: "org/repo", Ref: "HEAD", Commit: "abc123"}, result[0].File)
+	require.Len(t, result[0].Matches, 1)
+	mockClient.AssertNumberOfCalls(t, "ListRepositoryTree", 0)
+	mockClient.AssertNumberOfCalls(t, "GetRawFile", 0)
+}
+
+func TestGitSearchFetchAuto(t *testing.T) {
+	mockProject := git.Project{ID: 1, PathWithNamespace: "org/repo"}
+	var tree []git.TreeNode
+	files := map[string]string{}
+	for i := 0; i <= archiveThreshold; i++ {
+		path := fmt.Sprintf("config/%d.yaml", i)
+		tree = append(tree, git.TreeNode{Path: path})
+		files[path] = "token: secret"
+	}
+	tree = append(tree, git.TreeNode{Path: "docs/README.md"})
+	mockClient := NewGitClientMock(t)
+	mockClient.
+		On("ListGroupProjects", mock.Anything, "org", mock.Anything).
+		Return([]git.Project{mockProject}, nil)
+	mockClient.
+		On("ResolveRef", mock.Anything, mockProject, "").
+		Return(git.Ref{Name: "HEAD", SHA: "abc123"}, nil)
+	mockClient.
+		On("ListRepositoryTree", mock.Anything, mockProject, "abc123").
+		Return(tree, nil)
+
+	mockStorage := NewStorageMock(t)
+	g := &Git{
+		Client: mockClient,
+		Base: Base{
+			Storage:     mockStorage,
+			Sops:        sops.New(mockStorage),
+			TextMatcher: matcher.NewText(),
+		},
+	}
+
+	// the files passing the filters are below the threshold, they are fetched one by one
+	mockClient.
+		On("GetRawFile", mock.Anything, mockProject, "abc123", "docs/README.md").
+		Return([]byte("# secret docs"), nil)
+	result, err := g.Search(context.Background(), "org", SearchOptions{Fetch: FetchAuto, Content: []string{"secret"}, PathContains: []string{"docs"}, Silent: true})
+	require.NoError(t, err)
+	assert.Len(t, result, 1)
+	mockClient.AssertNumberOfCalls(t, "GetArchive", 0)
+
+	// too many files need their content, the archive is downloaded
+	mockClient.
+		On("GetArchive", mock.Anything, mockProject, "abc123").
+		Return(testArchive(t, files), nil)
+	result, err = g.Search(context.Background(), "org", SearchOptions{Fetch: FetchAuto, Content: []string{"secret"}, PathContains: []string{"config"}, Silent: true})
+	require.NoError(t, err)
+	assert.Len(t, result, archiveThreshold+1)
+	mockClient.AssertNumberOfCalls(t, "GetArchive", 1)
+	mockClient.AssertNumberOfCalls(t, "GetRawFile", 1)
+}
+
+func TestGitSearchSkippedFiles(t *testing.T) {
+	mockProject := git.Project{ID: 1, PathWithNamespace: "org/repo"}
+	mockClient := NewGitClientMock(t)
+	mockClient.
+		On("ListGroupProjects", mock.Anything, "org", mock.Anything).
+		Return([]git.Project{mockProject}, nil)
+	mockClient.
+		On("ResolveRef", mock.Anything, mockProject, "").
+		Return(git.Ref{Name: "HEAD", SHA: "abc123"}, nil)
+	mockClient.
+		On("ListRepositoryTree", mock.Anything, mockProject, "abc123").
+		Return([]git.TreeNode{{Path: "a.txt"}, {Path: "b.txt"}}, nil)
+	mockClient.
+		On("GetRawFile", mock.Anything, mockProject, "abc123", "a.txt").
+		Return([]byte("secret"), nil)
+	mockClient.
+		On("GetRawFile", mock.Anything, mockProject, "abc123", "b.txt").
+		Return(nil, errors.New("503 Service Unavailable"))
+
+	mockStorage := NewStorageMock(t)
+	g := &Git{
+		Client: mockClient,
+		Base: Base{
+			Storage:     mockStorage,
+			Sops:        sops.New(mockStorage),
+			TextMatcher: matcher.NewText(),
+		},
+	}
+
+	var skipped []SkippedFile
+	result, err := g.Search(context.Background(), "org", SearchOptions{
+		Fetch:   FetchFiles,
+		Content: []string{"secret"},
+		Silent:  true,
+		OnSkip: func(file SkippedFile) {
+			skipped = append(skipped, file)
+		},
+	})
+	require.NoError(t, err)
+	assert.Len(t, result, 1)
+	assert.Equal(t, []SkippedFile{
+		{
+			File:   File{Name: "b.txt", Path: "org/repo", Type: FILE, Repository: "org/repo", Ref: "HEAD", Commit: "abc123"},
+			Reason: "503 Service Unavailable",
+		},
+	}, skipped)
+}
+
+func TestGitSearchCache(t *testing.T) {
+	mockProject := git.Project{ID: 1, PathWithNamespace: "org/repo"}
+	content := []byte("token: secret")
+	tree := []git.TreeNode{{Path: "config.yaml", Type: "blob", SHA: git.BlobSHA(content)}}
+	mockClient := NewGitClientMock(t)
+	mockClient.
+		On("ListGroupProjects", mock.Anything, "org", mock.Anything).
+		Return([]git.Project{mockProject}, nil)
+	mockClient.
+		On("ResolveRef", mock.Anything, mockProject, "").
+		Return(git.Ref{Name: "HEAD", SHA: "abc123"}, nil)
+	mockClient.
+		On("ListRepositoryTree", mock.Anything, mockProject, "abc123").
+		Return(tree, nil).Once()
+	mockClient.
+		On("GetRawFile", mock.Anything, mockProject, "abc123", "config.yaml").
+		Return(content, nil).Once()
+
+	c, err := cache.New(t.TempDir(), cache.Options{})
+	require.NoError(t, err)
+	mockStorage := NewStorageMock(t)
+	g := &Git{
+		Client: mockClient,
+		Base: Base{
+			Storage:     mockStorage,
+			Sops:        sops.New(mockStorage),
+			TextMatcher: matcher.NewText(),
+		},
+	}
+	options := SearchOptions{Fetch: FetchFiles, Content: []string{"secret"}, Silent: true, Cache: c}
+
+	// the second search reads the tree and the file from the cache
+	for i := 0; i < 2; i++ {
+		result, err := g.Search(context.Background(), "org", options)
+		require.NoError(t, err)
+		assert.Len(t, result, 1)
+	}
+	hits, misses := c.Stats()
+	assert.Equal(t, int64(2), hits)
+	assert.Equal(t, int64(2), misses)
+}
+
+func TestGitSearchDecryptsEachRef(t *testing.T) {
+	mockProject := git.Project{ID: 1, PathWithNamespace: "org/repo"}
+	mockClient := NewGitClientMock(t)
+	mockClient.
+		On("ListGroupProjects", mock.Anything, "org", mock.Anything).
+		Return([]git.Project{mockProject}, nil)
+	mockClient.
+		On("ListBranches", mock.Anything, mockProject).
+		Return([]git.Ref{{Name: "main", SHA: "abc123"}, {Name: "release", SHA: "def456"}}, nil)
+	mockSops := NewSopsMock(t)
+	for ref, sha := range map[string]string{"main": "abc123", "release": "def456"} {
+		mockClient.
+			On("ListRepositoryTree", mock.Anything, mockProject, sha).
+			Return([]git.TreeNode{{Path: "secrets.yaml"}}, nil)
+		mockClient.
+			On("GetRawFile", mock.Anything, mockProject, sha, "secrets.yaml").
+			Return([]byte("encrypted: "+ref), nil)
+		mockSops.
+			On("Decrypt", mock.Anything, []byte("encrypted: "+ref), sops.YAML).
+			Return("password: "+ref, nil)
+	}
+
+	g := &Git{
+		Client: mockClient,
+		Base: Base{
+			Storage:     NewStorageMock(t),
+			Sops:        mockSops,
+			TextMatcher: matcher.NewText(),
+		},
+	}
+
+	// the files of both refs have the same path, each one is decrypted from its own content
+	result, err := g.Search(context.Background(), "org", SearchOptions{Fetch: FetchFiles, AllBranches: true, Sops: true, Content: []string{"password"}, Silent: true, Concurrency: 2})
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	for _, fileMatch := range result {
+		require.Len(t, fileMatch.Matches, 1)
+		assert.Equal(t, "password: "+fileMatch.Ref, fileMatch.Matches[0].Snippet)
+	}
+}
+
+func TestGitSearchIncremental(t *testing.T) {
+	mockProject := git.Project{ID: 1, PathWithNamespace: "org/repo", DefaultBranch: "main"}
+	previous := func(name, commit string) FileMatch {
+		return FileMatch{File: File{Name: name, Path: "org/repo", Type: FILE, Repository: "org/repo", Ref: "main", Commit: commit}}
+	}
+	state := NewState()
+	options := SearchOptions{Fetch: FetchFiles, Content: []string{"secret"}, Silent: true, State: state}
+	state.Refs["org/repo@main"] = RefState{
+		Repository: "org/repo",
+		Ref:        "main",
+		Commit:     "old",
+		Options:    optionsHash(options),
+		Findings:   []FileMatch{previous("a.txt", "old"), previous("b.txt", "old"), previous("c.txt", "old")},
+	}
+	mockClient := NewGitClientMock(t)
+	mockClient.
+		On("ListGroupProjects", mock.Anything, "org", mock.Anything).
+		Return([]git.Project{mockProject}, nil)
+	mockClient.
+		On("ResolveRef", mock.Anything, mockProject, "main").
+		Return(git.Ref{Name: "main", SHA: "new"}, nil)
+	mockClient.
+		On("CompareCommits", mock.Anything, mockProject, "old", "new").
+		Return([]git.FileChange{
+			{Path: "b.txt", Status: git.ChangeModified},
+			{Path: "c.txt", Status: git.ChangeRemoved},
+			{Path: "d.txt", Status: git.ChangeAdded},
+			{Path: "e.txt", Status: git.ChangeAdded},
+		}, nil)
+	mockClient.
+		On("GetRawFile", mock.Anything, mockProject, "new", "b.txt").
+		Return([]byte("still a secret"), nil)
+	mockClient.
+		On("GetRawFile", mock.Anything, mockProject, "new", "d.txt").
+		Return([]byte("new secret"), nil)
+	mockClient.
+		On("GetRawFile", mock.Anything, mockProject, "new", "e.txt").
+		Return([]byte("nothing"), nil)
+
+	mockStorage := NewStorageMock(t)
+	g := &Git{
+		Client: mockClient,
+		Base: Base{
+			Storage:     mockStorage,
+			Sops:        sops.New(mockStorage),
+			TextMatcher: matcher.NewText(),
+		},
+	}
+
+	result, err := g.Search(context.Background(), "org", options)
+	require.NoError(t, err)
+	statuses := map[string]string{}
+	for _, fileMatch := range result {
+		statuses[fileMatch.Name] = fileMatch.Status
+		if fileMatch.Status != StatusResolved {
+			assert.Equal(t, "new", fileMatch.Commit)
+		}
+	}
+	assert.Equal(t, map[string]string{
+		"a.txt": StatusUnchanged,
+		"b.txt": StatusUnchanged,
+		"c.txt": StatusResolved,
+		"d.txt": StatusNew,
+	}, statuses)
+
+	refState := state.Refs["org/repo@main"]
+	assert.Equal(t, "new", refState.Commit)
+	var names []string
+	for _, finding := range refState.Findings {
+		names = append(names, finding.Name)
+		assert.Empty(t, finding.Status)
+	}
+	assert.Equal(t, []string{"a.txt", "b.txt", "d.txt"}, names)
+	mockClient.AssertNotCalled(t, "ListRepositoryTree", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestGitSearchIncrementalFallback(t *testing.T) {
+	mockProject := git.Project{ID: 1, PathWithNamespace: "org/repo", DefaultBranch: "main"}
+	state := NewState()
+	options := SearchOptions{Fetch: FetchFiles, Content: []string{"secret"}, Silent: true, State: state}
+	state.Refs["org/repo@main"] = RefState{
+		Repository: "org/repo",
+		Ref:        "main",
+		Commit:     "old",
+		Options:    optionsHash(options),
+		Findings:   []FileMatch{{File: File{Name: "a.txt", Path: "org/repo", Type: FILE, Repository: "org/repo", Ref: "main", Commit: "old"}}},
+	}
+	mockClient := NewGitClientMock(t)
+	mockClient.
+		On("ListGroupProjects", mock.Anything, "org", mock.Anything).
+		Return([]git.Project{mockProject}, nil)
+	mockClient.
+		On("ResolveRef", mock.Anything, mockProject, "main").
+		Return(git.Ref{Name: "main", SHA: "new"}, nil)
+	mockClient.
+		On("CompareCommits", mock.Anything, mockProject, "old", "new").
+		Return(nil, errors.New("the head is diverged"))
+	mockClient.
+		On("ListRepositoryTree", mock.Anything, mockProject, "new").
+		Return([]git.TreeNode{{Path: "a.txt"}}, nil)
+	mockClient.
+		On("GetRawFile", mock.Anything, mockProject, "new", "a.txt").
+		Return([]byte("nothing"), nil)
+
+	mockStorage := NewStorageMock(t)
+	g := &Git{
+		Client: mockClient,
+		Base: Base{
+			Storage:     mockStorage,
+			Sops:        sops.New(mockStorage),
+			TextMatcher: matcher.NewText(),
+		},
+	}
+
+	// the commits can not be compared, all files are scanned and the finding is resolved
+	result, err := g.Search(context.Background(), "org", options)
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	assert.Equal(t, StatusResolved, result[0].Status)
+	assert.Equal(t, RefState{Repository: "org/repo", Ref: "main", Commit: "new", Options: optionsHash(options), Findings: []FileMatch{}}, state.Refs["org/repo@main"])
+}
+
+func TestGitSearchIncrementalOptionsChanged(t *testing.T) {
+	mockProject := git.Project{ID: 1, PathWithNamespace: "org/repo", DefaultBranch: "main"}
+	state := NewState()
+	options := SearchOptions{Fetch: FetchFiles, Content: []string{"secret"}, Silent: true, State: state}
+	state.Refs["org/repo@main"] = RefState{
+		Repository: "org/repo",
+		Ref:        "main",
+		Commit:     "old",
+		Options:    optionsHash(SearchOptions{Content: []string{"password"}}),
+		Findings:   []FileMatch{{File: File{Name: "a.txt", Path: "org/repo", Type: FILE, Repository: "org/repo", Ref: "main", Commit: "old"}}},
+	}
+	mockClient := NewGitClientMock(t)
+	mockClient.
+		On("ListGroupProjects", mock.Anything, "org", mock.Anything).
+		Return([]git.Project{mockProject}, nil)
+	mockClient.
+		On("ResolveRef", mock.Anything, mockProject, "main").
+		Return(git.Ref{Name: "main", SHA: "new"}, nil)
+	mockClient.
+		On("ListRepositoryTree", mock.Anything, mockProject, "new").
+		Return([]git.TreeNode{{Path: "a.txt"}, {Path: "b.txt"}}, nil)
+	mockClient.
+		On("GetRawFile", mock.Anything, mockProject, "new", "a.txt").
+		Return([]byte("nothing"), nil)
+	mockClient.
+		On("GetRawFile", mock.Anything, mockProject, "new", "b.txt").
+		Return([]byte("a secret"), nil)
+
+	mockStorage := NewStorageMock(t)
+	g := &Git{
+		Client: mockClient,
+		Base: Base{
+			Storage:     mockStorage,
+			Sops:        sops.New(mockStorage),
+			TextMatcher: matcher.NewText(),
+		},
+	}
+
+	// the previous findings were found with other options, all files are scanned
+	result, err := g.Search(context.Background(), "org", options)
+	require.NoError(t, err)
+	statuses := map[string]string{}
+	for _, fileMatch := range result {
+		statuses[fileMatch.Name] = fileMatch.Status
+	}
+	assert.Equal(t, map[string]string{"a.txt": StatusResolved, "b.txt": StatusNew}, statuses)
+	assert.Equal(t, optionsHash(options), state.Refs["org/repo@main"].Options)
+	mockClient.AssertNotCalled(t, "CompareCommits", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// countingReader counts the bytes read from the archive
+type countingReader struct {
+	io.ReadCloser
+	read int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.read += n
+	return n, err
+}
+
+func TestGitScanArchiveStreams(t *testing.T) {
+	mockProject := git.Project{ID: 1, PathWithNamespace: "org/repo"}
+	// random content does not compress, so the archive is read while the files are scanned
+	random := rand.New(rand.NewSource(1))
+	files := map[string]string{}
+	for _, name := range []string{"a.bin", "b.bin", "c.bin"} {
+		content := make([]byte, 256*1024)
+		random.Read(content)
+		files[name] = string(content)
+	}
+	archive := &countingReader{ReadCloser: testArchive(t, files)}
+	mockClient := NewGitClientMock(t)
+	mockClient.On("GetArchive", mock.Anything, mockProject, "abc123").Return(archive, nil)
+	g := &Git{Client: mockClient, Base: Base{TextMatcher: matcher.NewText()}}
+
+	var scanned []int
+	err := g.scanArchive(context.Background(), mockProject, git.Ref{Name: "HEAD", SHA: "abc123"}, SearchOptions{Content: []string{"secret"}}, nil, func(file File, source fileSource) {
+		content, err := source.read(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, files[file.Name], string(content))
+		scanned = append(scanned, archive.read)
+	})
+	require.NoError(t, err)
+	// every file is scanned before the next one is read
+	require.Len(t, scanned, 3)
+	assert.Less(t, scanned[0], scanned[1])
+	assert.Less(t, scanned[1], scanned[2])
+}
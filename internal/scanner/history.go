@@ -0,0 +1,184 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/alican-uelger/deep-scan/internal/history"
+	"github.com/alican-uelger/deep-scan/internal/matcher"
+	"github.com/alican-uelger/deep-scan/internal/sops"
+	"github.com/alican-uelger/deep-scan/internal/storage"
+	"github.com/alican-uelger/deep-scan/internal/worker"
+)
+
+var errHistoryDecrypt = errors.New("sops decryption is not supported for the git history")
+
+// History scans the lines added by the commits of a local git repository
+type History struct {
+	Base
+	Open func(ctx context.Context, dir string) (GitHistory, error)
+}
+
+func NewHistory() *History {
+	memStorage := storage.NewMem()
+	return &History{
+		Base: Base{
+			Storage:     memStorage,
+			Sops:        sops.New(memStorage),
+			TextMatcher: matcher.NewText(),
+		},
+		Open: func(ctx context.Context, dir string) (GitHistory, error) {
+			return history.Open(ctx, dir)
+		},
+	}
+}
+
+/*
+Search walks the commits of the repository containing the directory and returns the files with matching added lines.
+The file and content filters are applied to the lines each commit added, placed at their line numbers.
+When the context is canceled, the matches found so far are returned together with the context error.
+*/
+func (s *History) Search(ctx context.Context, dir string, options SearchOptions) ([]FileMatch, error) {
+	var result []FileMatch
+	var mu sync.Mutex
+
+	repository, err := s.Open(ctx, dir)
+	if err != nil {
+		return result, err
+	}
+
+	run := newSearchRun(options, "")
+	pool := worker.NewPool(ctx, options.Concurrency)
+	head := newHeadFiles(repository)
+
+	walkErr := repository.Walk(ctx, options.History, func(change history.Change) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		pool.Submit(func() {
+			file := File{
+				Name:   filepath.Base(change.Path),
+				Path:   filepath.Dir(change.Path),
+				Type:   FILE,
+				Commit: change.Commit.SHA,
+			}
+			content := change.Content()
+			source := fileSource{
+				read: func(context.Context) ([]byte, error) {
+					return []byte(content), nil
+				},
+				decrypt: func(context.Context, File, []byte, sops.FileType) (string, error) {
+					return "", errHistoryDecrypt
+				},
+			}
+			fileMatch, ok := s.scanFile(ctx, file, source, run)
+			if !ok || run.suppress(&fileMatch) {
+				return
+			}
+			fileMatch.History = &HistoryCommit{
+				Commit: change.Commit,
+				InHead: head.contains(ctx, change.Path, content, fileMatch.Matches),
+			}
+			run.report(fileMatch)
+			mu.Lock()
+			result = append(result, fileMatch)
+			mu.Unlock()
+		})
+		return nil
+	})
+	pool.Wait()
+	for _, fileMatch := range run.finish() {
+		run.report(fileMatch)
+		result = append(result, fileMatch)
+	}
+	if options.LogLate {
+		printFileMatches(result, options)
+	}
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+	return result, walkErr
+}
+
+// headFiles caches the files at HEAD, the same file is usually changed by many commits
+type headFiles struct {
+	repository GitHistory
+	mu         sync.Mutex
+	files      map[string]*headFile
+}
+
+type headFile struct {
+	once    sync.Once
+	content string
+	exists  bool
+}
+
+func newHeadFiles(repository GitHistory) *headFiles {
+	return &headFiles{
+		repository: repository,
+		files:      map[string]*headFile{},
+	}
+}
+
+func (h *headFiles) get(ctx context.Context, path string) (string, bool) {
+	h.mu.Lock()
+	file, ok := h.files[path]
+	if !ok {
+		file = &headFile{}
+		h.files[path] = file
+	}
+	h.mu.Unlock()
+	file.once.Do(func() {
+		content, err := h.repository.Show(ctx, "HEAD", path)
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				slog.Warn(fmt.Sprintf("reading %s at HEAD failed %s - reporting it as removed", path, err))
+			}
+			return
+		}
+		file.content, file.exists = string(content), true
+	})
+	return file.content, file.exists
+}
+
+/*
+contains reports if a match of a commit still exists in the file at HEAD.
+Content matches must be found verbatim in the file, file name and path matches only need the file to exist.
+*/
+func (h *headFiles) contains(ctx context.Context, path, content string, matches []matcher.MatchResult) bool {
+	headContent, exists := h.get(ctx, path)
+	if !exists {
+		return false
+	}
+	offsets := lineOffsets(content)
+	contentMatch := false
+	for _, m := range matches {
+		if !isContentMatch(m) {
+			continue
+		}
+		start, end, ok := matchRange(content, offsets, m)
+		if !ok {
+			continue
+		}
+		contentMatch = true
+		if strings.Contains(headContent, content[start:end]) {
+			return true
+		}
+	}
+	return !contentMatch
+}
+
+// isContentMatch reports if a match was found in the content of a file, instead of its name or path
+func isContentMatch(m matcher.MatchResult) bool {
+	switch m.Rule {
+	case RuleName, RuleNameContains, RuleNameRegex, RulePath, RulePathContains, RulePathRegex:
+		return false
+	}
+	return true
+}
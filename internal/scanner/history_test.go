@@ -0,0 +1,113 @@
+//go:build unit
+
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/alican-uelger/deep-scan/internal/history"
+	"github.com/alican-uelger/deep-scan/internal/matcher"
+	"github.com/alican-uelger/deep-scan/internal/sops"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestHistory(t *testing.T, repository GitHistory) *History {
+	mockStorage := NewStorageMock(t)
+	return &History{
+		Base: Base{
+			Storage:     mockStorage,
+			Sops:        sops.New(mockStorage),
+			TextMatcher: matcher.NewText(),
+		},
+		Open: func(context.Context, string) (GitHistory, error) {
+			return repository, nil
+		},
+	}
+}
+
+func TestHistorySearch(t *testing.T) {
+	removed := history.Commit{SHA: "abc123", Author: "Dev One", Email: "dev@example.com", Date: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)}
+	kept := history.Commit{SHA: "def456", Author: "Dev Two", Email: "two@example.com", Date: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)}
+	changes := []history.Change{
+		{Commit: kept, Path: "deploy/values.yaml", Lines: []history.Line{{Number: 3, Text: "token: ghp_kept"}}},
+		{Commit: removed, Path: "config.yaml", Lines: []history.Line{{Number: 2, Text: "token: ghp_removed"}}},
+		{Commit: removed, Path: "README.md", Lines: []history.Line{{Number: 1, Text: "# app"}}},
+	}
+	repository := NewGitHistoryMock(t)
+	repository.
+		On("Walk", mock.Anything, history.LogOptions{MaxCount: 10}, mock.Anything).
+		Run(func(args mock.Arguments) {
+			fn := args.Get(2).(func(history.Change) error)
+			for _, change := range changes {
+				require.NoError(t, fn(change))
+			}
+		}).
+		Return(nil)
+	repository.
+		On("Show", mock.Anything, "HEAD", "deploy/values.yaml").
+		Return([]byte("name: app\n\ntoken: ghp_kept\n"), nil)
+	repository.
+		On("Show", mock.Anything, "HEAD", "config.yaml").
+		Return(nil, fmt.Errorf("HEAD:config.yaml: %w", os.ErrNotExist))
+
+	s := newTestHistory(t, repository)
+	result, err := s.Search(context.Background(), ".", SearchOptions{
+		ContentRegex: []*regexp.Regexp{regexp.MustCompile(`ghp_\w+`)},
+		History:      history.LogOptions{MaxCount: 10},
+		Silent:       true,
+	})
+
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	byName := map[string]FileMatch{}
+	for _, fileMatch := range result {
+		byName[fileMatch.Name] = fileMatch
+	}
+
+	values := byName["values.yaml"]
+	assert.Equal(t, "deploy", values.Path)
+	assert.Equal(t, "def456", values.Commit)
+	assert.Equal(t, &HistoryCommit{Commit: kept, InHead: true}, values.History)
+	require.Len(t, values.Matches, 1)
+	assert.Equal(t, 3, values.Matches[0].Line)
+
+	config := byName["config.yaml"]
+	assert.Equal(t, &HistoryCommit{Commit: removed, InHead: false}, config.History)
+	assert.Equal(t, 2, config.Matches[0].Line)
+}
+
+func TestHistorySearchOpenError(t *testing.T) {
+	s := newTestHistory(t, nil)
+	s.Open = func(context.Context, string) (GitHistory, error) {
+		return nil, errors.New("not a git repository")
+	}
+
+	result, err := s.Search(context.Background(), ".", SearchOptions{})
+
+	assert.Empty(t, result)
+	assert.EqualError(t, err, "not a git repository")
+}
+
+func TestHeadFilesContains(t *testing.T) {
+	repository := NewGitHistoryMock(t)
+	repository.
+		On("Show", mock.Anything, "HEAD", "app.env").
+		Return([]byte("KEY=changed\n"), nil).
+		Once()
+	head := newHeadFiles(repository)
+	content := "KEY=secret"
+	contentMatch := matcher.MatchResult{Line: 1, StartCol: 5, EndCol: 10, Rule: RuleContent}
+	nameMatch := matcher.MatchResult{Rule: RuleName}
+
+	assert.False(t, head.contains(context.Background(), "app.env", content, []matcher.MatchResult{contentMatch}))
+	// files matching only by name still exist, the content is read once
+	assert.True(t, head.contains(context.Background(), "app.env", content, []matcher.MatchResult{nameMatch}))
+}
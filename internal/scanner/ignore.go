@@ -0,0 +1,108 @@
+package scanner
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/alican-uelger/deep-scan/internal/matcher"
+)
+
+// Inline annotations ignoring the content matches of a line or file, written in a comment of any syntax
+const (
+	AnnotationIgnore         = "ignore"
+	AnnotationIgnoreNextLine = "ignore-next-line"
+	AnnotationIgnoreFile     = "ignore-file"
+)
+
+const annotationPrefix = "deep-scan:"
+
+// annotationRegex matches e.g. "deep-scan:ignore" or "deep-scan:ignore-next-line=content-regex,sops-value"
+var annotationRegex = regexp.MustCompile(`deep-scan:(ignore-next-line|ignore-file|ignore)(?:=([\w.-]+(?:,[\w.-]+)*))?`)
+
+// matchRule returns the ID of the rule of a match, or the search option it was found by
+func matchRule(m matcher.MatchResult) string {
+	if m.RuleID != "" {
+		return m.RuleID
+	}
+	return m.Rule
+}
+
+// annotation ignores the matches of the given rules, all matches without rules
+type annotation struct {
+	kind  string
+	rules []string
+}
+
+func (a annotation) ignores(m matcher.MatchResult) bool {
+	return len(a.rules) == 0 || slices.Contains(a.rules, m.Rule) || (m.RuleID != "" && slices.Contains(a.rules, m.RuleID))
+}
+
+// annotations are the ignore annotations of a file by the line they apply to
+type annotations struct {
+	file  []annotation
+	lines map[int][]annotation
+}
+
+// parseAnnotations returns the ignore annotations of the content, nil when it has none
+func parseAnnotations(content string) *annotations {
+	if !strings.Contains(content, annotationPrefix) {
+		return nil
+	}
+	a := &annotations{lines: map[int][]annotation{}}
+	for i, line := range strings.Split(content, "\n") {
+		if !strings.Contains(line, annotationPrefix) {
+			continue
+		}
+		for _, groups := range annotationRegex.FindAllStringSubmatch(line, -1) {
+			parsed := annotation{kind: groups[1]}
+			if groups[2] != "" {
+				parsed.rules = strings.Split(groups[2], ",")
+			}
+			switch parsed.kind {
+			case AnnotationIgnoreFile:
+				a.file = append(a.file, parsed)
+			case AnnotationIgnoreNextLine:
+				a.lines[i+2] = append(a.lines[i+2], parsed)
+			default:
+				a.lines[i+1] = append(a.lines[i+1], parsed)
+			}
+		}
+	}
+	if len(a.file) == 0 && len(a.lines) == 0 {
+		return nil
+	}
+	return a
+}
+
+// annotation returns the annotation ignoring a match, matches spanning several lines are ignored by their first line
+func (a *annotations) annotation(m matcher.MatchResult) (string, bool) {
+	for _, annotation := range a.lines[m.Line] {
+		if annotation.ignores(m) {
+			return annotation.kind, true
+		}
+	}
+	for _, annotation := range a.file {
+		if annotation.ignores(m) {
+			return annotation.kind, true
+		}
+	}
+	return "", false
+}
+
+// filter splits the matches into the reported and the ignored ones
+func (a *annotations) filter(matches []matcher.MatchResult) ([]matcher.MatchResult, []IgnoredMatch) {
+	if a == nil {
+		return matches, nil
+	}
+	var kept []matcher.MatchResult
+	var ignored []IgnoredMatch
+	for _, m := range matches {
+		if kind, ok := a.annotation(m); ok {
+			ignored = append(ignored, IgnoredMatch{Line: m.Line, Rule: matchRule(m), Annotation: kind})
+			continue
+		}
+		kept = append(kept, m)
+	}
+	return kept, ignored
+}
@@ -0,0 +1,112 @@
+//go:build unit
+
+package scanner
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/alican-uelger/deep-scan/internal/matcher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterContentIgnoreAnnotations(t *testing.T) {
+	tests := []struct {
+		name            string
+		content         string
+		options         SearchOptions
+		expectedOk      bool
+		expectedLines   []int
+		expectedIgnored []IgnoredMatch
+	}{
+		{
+			name:          "No annotations",
+			content:       "password: a\npassword: b\n",
+			options:       SearchOptions{Content: []string{"password"}},
+			expectedOk:    true,
+			expectedLines: []int{1, 2},
+		},
+		{
+			name:            "Same line",
+			content:         "password: a # deep-scan:ignore\npassword: b\n",
+			options:         SearchOptions{Content: []string{"password"}},
+			expectedOk:      true,
+			expectedLines:   []int{2},
+			expectedIgnored: []IgnoredMatch{{Line: 1, Rule: RuleContent, Annotation: AnnotationIgnore}},
+		},
+		{
+			name:            "Next line",
+			content:         "// deep-scan:ignore-next-line\npassword: a\npassword: b\n",
+			options:         SearchOptions{Content: []string{"password"}},
+			expectedOk:      true,
+			expectedLines:   []int{3},
+			expectedIgnored: []IgnoredMatch{{Line: 2, Rule: RuleContent, Annotation: AnnotationIgnoreNextLine}},
+		},
+		{
+			name:    "File",
+			content: "<!-- deep-scan:ignore-file -->\npassword: a\npassword: b\n",
+			options: SearchOptions{Content: []string{"password"}},
+			// the file does not match, because all matches of the filter are ignored
+			expectedOk: false,
+			expectedIgnored: []IgnoredMatch{
+				{Line: 2, Rule: RuleContent, Annotation: AnnotationIgnoreFile},
+				{Line: 3, Rule: RuleContent, Annotation: AnnotationIgnoreFile},
+			},
+		},
+		{
+			name:            "Rule names",
+			content:         "# deep-scan:ignore-file=content-regex\npassword: a # deep-scan:ignore=content,sops-value\n",
+			options:         SearchOptions{Content: []string{"password"}, ContentRegex: []*regexp.Regexp{regexp.MustCompile(`a\b`)}},
+			expectedOk:      false,
+			expectedIgnored: []IgnoredMatch{{Line: 2, Rule: RuleContent, Annotation: AnnotationIgnore}},
+		},
+		{
+			name:          "Other rule",
+			content:       "password: a # deep-scan:ignore=content-regex\n",
+			options:       SearchOptions{Content: []string{"password"}},
+			expectedOk:    true,
+			expectedLines: []int{1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := &Base{TextMatcher: matcher.NewText()}
+			ok, matches, ignored := base.filterContent(matcher.Location{}, tt.content, tt.options)
+			assert.Equal(t, tt.expectedOk, ok)
+			if tt.expectedOk {
+				var lines []int
+				for _, m := range matches {
+					lines = append(lines, m.Line)
+				}
+				assert.Equal(t, tt.expectedLines, lines)
+			}
+			assert.Equal(t, tt.expectedIgnored, ignored)
+		})
+	}
+}
+
+func TestOsSearchIgnoreAnnotations(t *testing.T) {
+	mockStorage := NewStorageMock(t)
+	mockStorage.On("ReadDir", "dir").Return([]string{"dir/config.yaml"}, nil)
+	mockStorage.On("IsDir", "dir/config.yaml").Return(false, nil)
+	mockStorage.On("ReadFile", "dir/config.yaml").Return([]byte("token: a # deep-scan:ignore\n"), nil)
+	o := &Os{
+		Base: Base{
+			Storage:     mockStorage,
+			Sops:        NewSopsMock(t),
+			TextMatcher: matcher.NewText(),
+		},
+	}
+	var ignored []IgnoredMatch
+	options := SearchOptions{Content: []string{"token"}, Silent: true, Concurrency: 1, OnIgnore: func(m IgnoredMatch) {
+		ignored = append(ignored, m)
+	}}
+
+	result, err := o.Search(context.Background(), "dir", options)
+	require.NoError(t, err)
+	assert.Empty(t, result)
+	assert.Equal(t, []IgnoredMatch{{File: File{Name: "config.yaml", Path: "dir", Type: FILE}, Line: 1, Rule: RuleContent, Annotation: AnnotationIgnore}}, ignored)
+}
@@ -0,0 +1,75 @@
+package scanner
+
+import (
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/alican-uelger/deep-scan/internal/sops"
+)
+
+// SopsKeyUsage lists all files and repositories encrypted with a single SOPS key
+type SopsKeyUsage struct {
+	sops.Key     `yaml:",inline"`
+	Files        []SopsKeyFile `json:"files" yaml:"files"`
+	Repositories []string      `json:"repositories,omitempty" yaml:"repositories,omitempty"`
+}
+
+type SopsKeyFile struct {
+	Path         string    `json:"path" yaml:"path"`
+	Repository   string    `json:"repository,omitempty" yaml:"repository,omitempty"`
+	LastModified time.Time `json:"lastModified" yaml:"lastModified"`
+	Version      string    `json:"version" yaml:"version"`
+}
+
+// SopsKeyInventory groups the SOPS files by the keys they are encrypted with, sorted by key type and ID.
+// Files without SOPS metadata are ignored. With keys (see sops.Key.Matches), only the matching keys are listed.
+func SopsKeyInventory(files []FileMatch, keys []string) []SopsKeyUsage {
+	usages := map[sops.Key]*SopsKeyUsage{}
+	for _, file := range files {
+		if file.Sops == nil {
+			continue
+		}
+		keyFile := SopsKeyFile{
+			Path:         filepath.Join(file.Path, file.Name),
+			Repository:   file.Repository,
+			LastModified: file.Sops.LastModified,
+			Version:      file.Sops.Version,
+		}
+		fileKeys := file.Sops.Keys()
+		if len(keys) > 0 {
+			fileKeys = file.Sops.MatchingKeys(keys)
+		}
+		for _, key := range fileKeys {
+			usage, ok := usages[key]
+			if !ok {
+				usage = &SopsKeyUsage{Key: key}
+				usages[key] = usage
+			}
+			usage.Files = append(usage.Files, keyFile)
+		}
+	}
+
+	result := make([]SopsKeyUsage, 0, len(usages))
+	for _, usage := range usages {
+		sort.Slice(usage.Files, func(i, j int) bool {
+			return usage.Files[i].Path < usage.Files[j].Path
+		})
+		repositories := map[string]bool{}
+		for _, file := range usage.Files {
+			if file.Repository != "" && !repositories[file.Repository] {
+				repositories[file.Repository] = true
+				usage.Repositories = append(usage.Repositories, file.Repository)
+			}
+		}
+		sort.Strings(usage.Repositories)
+		result = append(result, *usage)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Type != result[j].Type {
+			return result[i].Type < result[j].Type
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
@@ -0,0 +1,54 @@
+//go:build unit
+
+package scanner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alican-uelger/deep-scan/internal/sops"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSopsKeyInventory(t *testing.T) {
+	lastModified := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	ageKey := sops.Key{Type: sops.Age, ID: "age1abc"}
+	pgpKey := sops.Key{Type: sops.PGP, ID: "ABCD"}
+
+	files := []FileMatch{
+		{
+			File: File{Name: "b.yaml", Path: "org/repo-b", Repository: "org/repo-b"},
+			Sops: &sops.Metadata{KeyGroups: []sops.KeyGroup{{ageKey, pgpKey}}, LastModified: lastModified, Version: "3.9.4"},
+		},
+		{
+			File: File{Name: "a.yaml", Path: "org/repo-a", Repository: "org/repo-a"},
+			Sops: &sops.Metadata{KeyGroups: []sops.KeyGroup{{ageKey}}, LastModified: lastModified, Version: "3.8.1"},
+		},
+		{
+			File: File{Name: "plain.yaml", Path: "org/repo-a", Repository: "org/repo-a"},
+		},
+	}
+
+	expected := []SopsKeyUsage{
+		{
+			Key: ageKey,
+			Files: []SopsKeyFile{
+				{Path: "org/repo-a/a.yaml", Repository: "org/repo-a", LastModified: lastModified, Version: "3.8.1"},
+				{Path: "org/repo-b/b.yaml", Repository: "org/repo-b", LastModified: lastModified, Version: "3.9.4"},
+			},
+			Repositories: []string{"org/repo-a", "org/repo-b"},
+		},
+		{
+			Key: pgpKey,
+			Files: []SopsKeyFile{
+				{Path: "org/repo-b/b.yaml", Repository: "org/repo-b", LastModified: lastModified, Version: "3.9.4"},
+			},
+			Repositories: []string{"org/repo-b"},
+		},
+	}
+
+	assert.Equal(t, expected, SopsKeyInventory(files, nil))
+
+	// only the requested keys are listed, not the other recipients of the files
+	assert.Equal(t, expected[1:], SopsKeyInventory(files, []string{"abcd"}))
+}
@@ -1,6 +1,7 @@
 package scanner
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"path/filepath"
@@ -9,6 +10,7 @@ import (
 	"github.com/alican-uelger/deep-scan/internal/matcher"
 	"github.com/alican-uelger/deep-scan/internal/sops"
 	"github.com/alican-uelger/deep-scan/internal/storage"
+	"github.com/alican-uelger/deep-scan/internal/worker"
 )
 
 type Os struct {
@@ -26,104 +28,73 @@ func NewOs() *Os {
 	}
 }
 
-func (s *Os) Search(dir string, options SearchOptions) ([]FileMatch, error) {
+// Search walks the directory and returns all matching files.
+// When the context is canceled, the matches found so far are returned together with the context error.
+func (s *Os) Search(ctx context.Context, dir string, options SearchOptions) ([]FileMatch, error) {
 	var result []FileMatch
 	var mu sync.Mutex
-	var wg sync.WaitGroup
 
 	dirEntries, err := s.Storage.ReadDir(dir)
 	if err != nil {
 		return result, err
 	}
 
-	for _, entry := range dirEntries {
-		wg.Add(1)
-		go func(entry string) {
-			defer wg.Done()
-			isDir, err := s.Storage.IsDir(entry)
+	// the pool bounds the local I/O, the limiter the SOPS decryption
+	run := newSearchRun(options, dir)
+	pool := worker.NewPool(ctx, options.Concurrency)
+	decrypt := limitDecrypt(worker.NewLimiter(options.SopsConcurrency), s.decryptContent)
+
+	var visit func(entry string)
+	visit = func(entry string) {
+		isDir, err := s.Storage.IsDir(entry)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("is directory function failed with err %s - skipping %s and continuing", err, entry))
+			run.skip(ctx, File{Name: filepath.Base(entry), Path: filepath.Dir(entry), Type: FILE}, err)
+			return
+		}
+		if isDir {
+			nestedEntries, err := s.Storage.ReadDir(entry)
 			if err != nil {
-				slog.Warn(fmt.Sprintf("is directory function failed with err %s - skipping %s and continuing", err, entry))
-				return
-			}
-			if isDir {
-				nestedFiles, err := s.Search(entry, options)
-				if err != nil {
-					slog.Warn(fmt.Sprintf("nested directory search failed with err %s - skipping %s and continuing", err, entry))
-					return
-				}
-				mu.Lock()
-				result = append(result, nestedFiles...)
-				mu.Unlock()
+				slog.Warn(fmt.Sprintf("nested directory search failed with err %s - skipping %s and continuing", err, entry))
+				run.skip(ctx, File{Name: filepath.Base(entry), Path: filepath.Dir(entry), Type: FILE}, err)
 				return
 			}
-			fileMatch := FileMatch{
-				File: File{
-					Name: filepath.Base(entry),
-					Path: filepath.Dir(entry),
-					Type: FILE,
-				},
-				Matches: nil,
-			}
-			var matches []matcher.MatchResult
-			ok, filterFileMatches := s.filterFile(fileMatch.File, options)
-			if !ok {
-				return
-			}
-			matches = append(matches, filterFileMatches...)
-
-			content := ""
-			if isFileContentNeeded(options) {
-				rawContent, err := s.Storage.ReadFile(entry)
-				if err != nil {
-					slog.Warn(fmt.Sprintf("reading file content failed %s - skipping %s and continuing", err, entry))
-					return
-				}
-				content = string(rawContent)
-				if options.Sops {
-					ok, _ := s.filterSopsContentBeforeDecryption(content, options)
-					if !ok {
-						return
-					}
-					decryptedContent, err := s.decryptContent(fileMatch.File)
-					if err == nil {
-						slog.Debug(fmt.Sprintf("found sops secret file: %s", entry))
-						fileMatch.Type = SOPS_SECRET
-						content = decryptedContent
-					}
-				}
-			}
-
-			// when sops-only is enabled, only search for sops files
-			if options.SopsOnly && fileMatch.Type != SOPS_SECRET {
-				return
+			for _, nestedEntry := range nestedEntries {
+				pool.Submit(func() { visit(nestedEntry) })
 			}
+			return
+		}
+		file := File{
+			Name: filepath.Base(entry),
+			Path: filepath.Dir(entry),
+			Type: FILE,
+		}
+		source := fileSource{
+			read: func(context.Context) ([]byte, error) {
+				return s.Storage.ReadFile(entry)
+			},
+			decrypt: decrypt,
+		}
+		fileMatch, ok := s.scanFile(ctx, file, source, run)
+		if !ok || run.suppress(&fileMatch) {
+			return
+		}
+		run.report(fileMatch)
+		mu.Lock()
+		result = append(result, fileMatch)
+		mu.Unlock()
+	}
 
-			ok, contentMatches := s.filterContent(content, options)
-			if !ok {
-				return
-			}
-			matches = append(matches, contentMatches...)
-			fileMatch.Matches = matches
-			slog.Debug(fmt.Sprintf("found file: %s", entry))
-			if !options.LogLate {
-				printFileMatch(fileMatch, options)
-			}
-			mu.Lock()
-			result = append(result, fileMatch)
-			mu.Unlock()
-		}(entry)
+	for _, entry := range dirEntries {
+		pool.Submit(func() { visit(entry) })
+	}
+	pool.Wait()
+	for _, fileMatch := range run.finish() {
+		run.report(fileMatch)
+		result = append(result, fileMatch)
 	}
-	wg.Wait()
 	if options.LogLate {
 		printFileMatches(result, options)
 	}
-	return result, nil
-}
-
-func (s *Os) decryptContent(file File) (string, error) {
-	content, err := s.Sops.DecryptFile(filepath.Join(file.Path, file.Name))
-	if err != nil {
-		return content, fmt.Errorf("decrypt error: %s", err)
-	}
-	return content, nil
+	return result, ctx.Err()
 }
@@ -3,10 +3,15 @@
 package scanner
 
 import (
+	"context"
 	"errors"
 	"testing"
 
+	"github.com/alican-uelger/deep-scan/internal/matcher"
+	"github.com/alican-uelger/deep-scan/internal/sops"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestOsSearchSuccessfulSearch(t *testing.T) {
@@ -29,7 +34,7 @@ func TestOsSearchSuccessfulSearch(t *testing.T) {
 		},
 	}
 
-	result, err := o.Search("dir", SearchOptions{})
+	result, err := o.Search(context.Background(), "dir", SearchOptions{})
 	expected := []FileMatch{
 		{File: File{Name: "file.txt", Path: "dir", Type: FILE}},
 	}
@@ -55,7 +60,7 @@ func TestOsSearchStorageError(t *testing.T) {
 		},
 	}
 
-	result, err := o.Search("dir", SearchOptions{})
+	result, err := o.Search(context.Background(), "dir", SearchOptions{})
 
 	var expected []FileMatch
 
@@ -80,10 +85,134 @@ func TestOsSearchEmptyDirectory(t *testing.T) {
 		},
 	}
 
-	result, err := o.Search("dir", SearchOptions{})
+	result, err := o.Search(context.Background(), "dir", SearchOptions{})
 
 	var expected []FileMatch
 
 	assert.Equal(t, expected, result)
 	assert.Nil(t, err)
 }
+
+func TestOsSearchNestedDirectory(t *testing.T) {
+	mockStorage := NewStorageMock(t)
+	mockStorage.
+		On("ReadDir", "dir").
+		Return([]string{"dir/nested"}, nil)
+	mockStorage.
+		On("IsDir", "dir/nested").
+		Return(true, nil)
+	mockStorage.
+		On("ReadDir", "dir/nested").
+		Return([]string{"dir/nested/file.txt"}, nil)
+	mockStorage.
+		On("IsDir", "dir/nested/file.txt").
+		Return(false, nil)
+
+	mockSops := NewSopsMock(t)
+	mockTestMatcher := NewTextMatcherMock(t)
+
+	o := &Os{
+		Base: Base{
+			Storage:     mockStorage,
+			Sops:        mockSops,
+			TextMatcher: mockTestMatcher,
+		},
+	}
+
+	result, err := o.Search(context.Background(), "dir", SearchOptions{Concurrency: 1})
+	expected := []FileMatch{
+		{File: File{Name: "file.txt", Path: "dir/nested", Type: FILE}},
+	}
+
+	assert.Equal(t, expected, result)
+	assert.Nil(t, err)
+}
+
+func TestOsSearchCanceledContext(t *testing.T) {
+	mockStorage := NewStorageMock(t)
+	mockStorage.
+		On("ReadDir", "dir").
+		Return([]string{"dir/file.txt"}, nil)
+
+	o := &Os{
+		Base: Base{
+			Storage:     mockStorage,
+			Sops:        NewSopsMock(t),
+			TextMatcher: NewTextMatcherMock(t),
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, err := o.Search(ctx, "dir", SearchOptions{})
+
+	mockStorage.AssertNumberOfCalls(t, "IsDir", 0)
+	assert.Empty(t, result)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestOsSearchSopsPolicy(t *testing.T) {
+	mockStorage := NewStorageMock(t)
+	mockStorage.
+		On("ReadDir", "dir").
+		Return([]string{"dir/.sops.yaml", "dir/secret.yaml"}, nil)
+	mockStorage.
+		On("IsDir", mock.Anything).
+		Return(false, nil)
+	mockStorage.
+		On("ReadFile", "dir/.sops.yaml").
+		Return([]byte("creation_rules:\n  - path_regex: secret\\.yaml$\n    age: age1abc\n"), nil)
+	mockStorage.
+		On("ReadFile", "dir/secret.yaml").
+		Return([]byte("password: plaintext\n"), nil)
+
+	mockSops := NewSopsMock(t)
+	mockSops.
+		On("Metadata", []byte("password: plaintext\n"), sops.YAML).
+		Return(sops.Metadata{}, errors.New("no sops metadata"))
+
+	o := &Os{
+		Base: Base{
+			Storage:     mockStorage,
+			Sops:        mockSops,
+			TextMatcher: NewTextMatcherMock(t),
+		},
+	}
+
+	result, err := o.Search(context.Background(), "dir", SearchOptions{SopsPolicy: true, Concurrency: 1})
+
+	assert.Nil(t, err)
+	assert.Len(t, result, 3)
+	assert.Equal(t, SOPS_CONFIG, result[0].Type)
+	assert.Len(t, result[0].SopsConfig.CreationRules, 1)
+	assert.Equal(t, FILE, result[1].Type)
+	assert.Equal(t, File{Name: "secret.yaml", Path: "dir", Type: SOPS_UNENCRYPTED}, result[2].File)
+	assert.Equal(t, `secret\.yaml$`, result[2].SopsRule.PathRegex)
+}
+
+func TestOsSearchRulesLocation(t *testing.T) {
+	pack, err := matcher.ParseGitleaksConfig("gitleaks.toml", []byte("[[rules]]\nid = \"env-token\"\nregex = '''tok_[0-9]+'''\npath = '''^config/\\.env$'''\n"))
+	require.NoError(t, err)
+	rules, err := matcher.NewRules(pack)
+	require.NoError(t, err)
+	mockStorage := NewStorageMock(t)
+	mockStorage.On("ReadDir", "dir").Return([]string{"dir/config/.env", "dir/.env"}, nil)
+	mockStorage.On("IsDir", "dir/config/.env").Return(false, nil)
+	mockStorage.On("IsDir", "dir/.env").Return(false, nil)
+	mockStorage.On("ReadFile", "dir/config/.env").Return([]byte("TOKEN=tok_1\n"), nil)
+	mockStorage.On("ReadFile", "dir/.env").Return([]byte("TOKEN=tok_2\n"), nil)
+	o := &Os{
+		Base: Base{
+			Storage:     mockStorage,
+			Sops:        NewSopsMock(t),
+			TextMatcher: matcher.NewText(),
+		},
+	}
+
+	// the path of the rule is matched against the path relative to the scanned directory
+	result, err := o.Search(context.Background(), "dir", SearchOptions{Rules: rules, Silent: true, Concurrency: 1})
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	assert.Equal(t, "dir/config", result[0].Path)
+	assert.Equal(t, "env-token", result[0].Matches[0].RuleID)
+}
@@ -0,0 +1,91 @@
+package scanner
+
+import (
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/alican-uelger/deep-scan/internal/sops"
+)
+
+/*
+sopsPolicy collects the sops configs and the unencrypted files of a search.
+After the search, the unencrypted files matching a creation rule of the nearest config
+are reported, since sops policy says they should be encrypted.
+*/
+type sopsPolicy struct {
+	mu          sync.Mutex
+	configs     map[configKey]sops.Config
+	unencrypted []File
+}
+
+// configKey locates a config, the commit separates the configs of the branches of a repository
+type configKey struct {
+	commit string
+	dir    string
+}
+
+func newSopsPolicy() *sopsPolicy {
+	return &sopsPolicy{
+		configs: map[configKey]sops.Config{},
+	}
+}
+
+func (p *sopsPolicy) addConfig(file File, config sops.Config) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.configs[configKey{commit: file.Commit, dir: file.Path}] = config
+}
+
+func (p *sopsPolicy) addUnencrypted(file File) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.unencrypted = append(p.unencrypted, file)
+}
+
+// violations returns all unencrypted files matching a creation rule, must be called after the search is complete
+func (p *sopsPolicy) violations() []FileMatch {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var result []FileMatch
+	for _, file := range p.unencrypted {
+		configDir, config, ok := p.nearestConfig(file.Commit, file.Path)
+		if !ok {
+			continue
+		}
+		relativePath, err := filepath.Rel(configDir, filepath.Join(file.Path, file.Name))
+		if err != nil {
+			continue
+		}
+		rule, ok := config.MatchingRule(filepath.ToSlash(relativePath))
+		if !ok {
+			continue
+		}
+		file.Type = SOPS_UNENCRYPTED
+		result = append(result, FileMatch{
+			File:     file,
+			SopsRule: &rule,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Ref != result[j].Ref {
+			return result[i].Ref < result[j].Ref
+		}
+		return filepath.Join(result[i].Path, result[i].Name) < filepath.Join(result[j].Path, result[j].Name)
+	})
+	return result
+}
+
+// nearestConfig finds the config in the directory or the closest parent directory
+func (p *sopsPolicy) nearestConfig(commit, dir string) (string, sops.Config, bool) {
+	for {
+		if config, ok := p.configs[configKey{commit: commit, dir: dir}]; ok {
+			return dir, config, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", sops.Config{}, false
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,37 @@
+//go:build unit
+
+package scanner
+
+import (
+	"testing"
+
+	"github.com/alican-uelger/deep-scan/internal/sops"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSopsPolicyViolations(t *testing.T) {
+	rootConfig := sops.Config{CreationRules: []sops.CreationRule{{PathRegex: `secrets/.*\.yaml$`}}}
+	nestedConfig := sops.Config{CreationRules: []sops.CreationRule{{PathRegex: `^values\.yaml$`}}}
+
+	policy := newSopsPolicy()
+	policy.addConfig(File{Path: "org/repo"}, rootConfig)
+	policy.addConfig(File{Path: "org/repo/charts/app"}, nestedConfig)
+	policy.addUnencrypted(File{Name: "db.yaml", Path: "org/repo/secrets", Type: FILE})
+	policy.addUnencrypted(File{Name: "README.md", Path: "org/repo", Type: FILE})
+	policy.addUnencrypted(File{Name: "values.yaml", Path: "org/repo/charts/app", Type: FILE})
+	// the nested config is the nearest one, so the root rule does not apply
+	policy.addUnencrypted(File{Name: "db.yaml", Path: "org/repo/charts/app/secrets", Type: FILE})
+	policy.addUnencrypted(File{Name: "db.yaml", Path: "other/secrets", Type: FILE})
+
+	expected := []FileMatch{
+		{
+			File:     File{Name: "values.yaml", Path: "org/repo/charts/app", Type: SOPS_UNENCRYPTED},
+			SopsRule: &nestedConfig.CreationRules[0],
+		},
+		{
+			File:     File{Name: "db.yaml", Path: "org/repo/secrets", Type: SOPS_UNENCRYPTED},
+			SopsRule: &rootConfig.CreationRules[0],
+		},
+	}
+	assert.Equal(t, expected, policy.violations())
+}
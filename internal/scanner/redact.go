@@ -0,0 +1,134 @@
+package scanner
+
+import (
+	"strings"
+
+	"github.com/alican-uelger/deep-scan/internal/matcher"
+	"github.com/alican-uelger/deep-scan/internal/redact"
+	"github.com/alican-uelger/deep-scan/internal/sops"
+)
+
+// redactDocumentMatches redacts the matches of filterSopsDocument, their snippets are always within a single value
+func redactDocumentMatches(matches []matcher.MatchResult, entries []sops.Entry, redactor redact.Redactor) {
+	for i := range matches {
+		value, ok := entryValue(entries, matches[i])
+		if !ok {
+			matches[i].ReplaceSnippet(redactor.Redact(matches[i].Snippet))
+			continue
+		}
+		matches[i].ReplaceSnippet(redactor.Redact(value))
+	}
+}
+
+// entryValue returns the value of the entry a match was found in
+func entryValue(entries []sops.Entry, match matcher.MatchResult) (string, bool) {
+	value, found := "", false
+	for _, entry := range entries {
+		if entry.KeyPath == match.KeyPath && entry.Line <= match.Line {
+			value, found = entry.Value, true
+		}
+	}
+	return value, found
+}
+
+// redactContentMatches rebuilds the snippets of the content matches with every secret in them redacted
+func redactContentMatches(content string, matches []matcher.MatchResult, secrets []redact.Span, redactor redact.Redactor) {
+	offsets := lineOffsets(content)
+	for i := range matches {
+		start, end, ok := matchRange(content, offsets, matches[i])
+		if !ok {
+			matches[i].ReplaceSnippet(redactor.Redact(matches[i].Snippet))
+			continue
+		}
+		startContext := max(0, start-contextLength)
+		endContext := min(len(content), end+contextLength)
+		snippet := redactor.RedactWindow(content, startContext, endContext, secrets)
+		if startContext > 0 {
+			snippet = "..." + snippet
+		}
+		if endContext < len(content) {
+			snippet = snippet + "..."
+		}
+		matches[i].ReplaceSnippet(snippet)
+	}
+}
+
+/*
+documentSecrets returns the positions of all values of a decrypted document.
+Values which are not found verbatim at their position (escaped or multi-line values)
+are redacted until the end of their last line. Documents without entries (e.g. binary files) are secret as a whole.
+*/
+func documentSecrets(content string, entries []sops.Entry) []redact.Span {
+	if len(entries) == 0 {
+		return []redact.Span{{Start: 0, End: len(content), Value: content}}
+	}
+	offsets := lineOffsets(content)
+	var secrets []redact.Span
+	for _, entry := range entries {
+		if entry.Value == "" || entry.Line < 1 || entry.Line > len(offsets) {
+			continue
+		}
+		start := offsets[entry.Line-1] + entry.Column - 1
+		if start < 0 || start > len(content) {
+			continue
+		}
+		if strings.HasPrefix(content[start:], entry.Value) {
+			secrets = append(secrets, redact.Span{Start: start, End: start + len(entry.Value), Value: entry.Value})
+			continue
+		}
+		lastLine := entry.Line + strings.Count(strings.TrimSuffix(entry.Value, "\n"), "\n")
+		if strings.Contains(entry.Value, "\n") {
+			// block values start on the line after their indicator
+			lastLine++
+		}
+		secrets = append(secrets, redact.Span{Start: start, End: lineEnd(content, offsets, lastLine), Value: entry.Value})
+	}
+	return secrets
+}
+
+// matchSecrets returns the positions of the matches themselves, used to redact plain files
+func matchSecrets(content string, matches []matcher.MatchResult) []redact.Span {
+	offsets := lineOffsets(content)
+	var secrets []redact.Span
+	for _, m := range matches {
+		start, end, ok := matchRange(content, offsets, m)
+		if ok {
+			secrets = append(secrets, redact.Span{Start: start, End: end, Value: content[start:end]})
+		}
+	}
+	return secrets
+}
+
+// matchRange converts the line and columns of a match back to the byte range in the content
+func matchRange(content string, offsets []int, m matcher.MatchResult) (int, int, bool) {
+	if m.ExactMatch {
+		return 0, len(content), true
+	}
+	if m.Line < 1 || m.Line > len(offsets) {
+		return 0, 0, false
+	}
+	start := offsets[m.Line-1] + m.StartCol - 1
+	end := offsets[m.Line-1] + m.EndCol
+	if start < 0 || end > len(content) || start > end {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+// lineOffsets returns the byte offset of the start of every line
+func lineOffsets(content string) []int {
+	offsets := []int{0}
+	for i := 0; i < len(content); i++ {
+		if content[i] == '\n' {
+			offsets = append(offsets, i+1)
+		}
+	}
+	return offsets
+}
+
+func lineEnd(content string, offsets []int, line int) int {
+	if line >= len(offsets) {
+		return len(content)
+	}
+	return offsets[line] - 1
+}
@@ -0,0 +1,48 @@
+//go:build unit
+
+package scanner
+
+import (
+	"testing"
+
+	"github.com/alican-uelger/deep-scan/internal/matcher"
+	"github.com/alican-uelger/deep-scan/internal/redact"
+	"github.com/alican-uelger/deep-scan/internal/sops"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactContentMatches(t *testing.T) {
+	content := "db:\n  user: admin\n  password: changeme\n"
+	entries, err := sops.ParseDocument([]byte(content), sops.YAML)
+	require.NoError(t, err)
+
+	_, _, matches := matcher.NewText().Match(content, "password", matcher.TextSearch, contextLength)
+	require.Len(t, matches, 1)
+	redactContentMatches(content, matches, documentSecrets(content, entries), redact.Redactor{Mode: redact.Mask})
+
+	assert.Equal(t, "... user: ********\n  password: ********\n", matches[0].Snippet)
+	assert.NotContains(t, matches[0].FormattedSnippet, "changeme")
+	assert.NotContains(t, matches[0].CompressedFormattedSnippet, "changeme")
+	assert.Equal(t, 3, matches[0].Line)
+}
+
+func TestDocumentSecrets(t *testing.T) {
+	content := "token: \"a\\tb\"\ncert: |\n  line1\n  line2\nkey: value\n"
+	entries, err := sops.ParseDocument([]byte(content), sops.YAML)
+	require.NoError(t, err)
+
+	redacted := redact.Redactor{Mode: redact.Mask}.RedactWindow(content, 0, len(content), documentSecrets(content, entries))
+	assert.Equal(t, "token: \"********\ncert: ********\nkey: ********\n", redacted)
+
+	assert.Equal(t, []redact.Span{{Start: 0, End: 6, Value: "binary"}}, documentSecrets("binary", nil))
+}
+
+func TestRedactDocumentMatches(t *testing.T) {
+	entries := []sops.Entry{{KeyPath: "db.password", Value: "changeme", Line: 3, Column: 13}}
+	matches := []matcher.MatchResult{{KeyPath: "db.password", Line: 3, StartCol: 13, EndCol: 18, Snippet: "change..."}}
+
+	redactDocumentMatches(matches, entries, redact.Redactor{Mode: redact.Hash, Salt: "salt"})
+
+	assert.Equal(t, redact.Redactor{Mode: redact.Hash, Salt: "salt"}.Redact("changeme"), matches[0].Snippet)
+}
@@ -2,7 +2,12 @@
 
 package scanner
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	context "context"
+
+	sops "github.com/alican-uelger/deep-scan/internal/sops"
+	mock "github.com/stretchr/testify/mock"
+)
 
 // SopsMock is an autogenerated mock type for the Sops type
 type SopsMock struct {
@@ -17,9 +22,67 @@ func (_m *SopsMock) EXPECT() *SopsMock_Expecter {
 	return &SopsMock_Expecter{mock: &_m.Mock}
 }
 
-// DecryptFile provides a mock function with given fields: path
-func (_m *SopsMock) DecryptFile(path string) (string, error) {
-	ret := _m.Called(path)
+// Decrypt provides a mock function with given fields: ctx, content, fileType
+func (_m *SopsMock) Decrypt(ctx context.Context, content []byte, fileType string) (string, error) {
+	ret := _m.Called(ctx, content, fileType)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Decrypt")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []byte, string) (string, error)); ok {
+		return rf(ctx, content, fileType)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []byte, string) string); ok {
+		r0 = rf(ctx, content, fileType)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []byte, string) error); ok {
+		r1 = rf(ctx, content, fileType)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SopsMock_Decrypt_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Decrypt'
+type SopsMock_Decrypt_Call struct {
+	*mock.Call
+}
+
+// Decrypt is a helper method to define mock.On call
+//   - ctx context.Context
+//   - content []byte
+//   - fileType string
+func (_e *SopsMock_Expecter) Decrypt(ctx interface{}, content interface{}, fileType interface{}) *SopsMock_Decrypt_Call {
+	return &SopsMock_Decrypt_Call{Call: _e.mock.On("Decrypt", ctx, content, fileType)}
+}
+
+func (_c *SopsMock_Decrypt_Call) Run(run func(ctx context.Context, content []byte, fileType string)) *SopsMock_Decrypt_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].([]byte), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *SopsMock_Decrypt_Call) Return(_a0 string, _a1 error) *SopsMock_Decrypt_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *SopsMock_Decrypt_Call) RunAndReturn(run func(context.Context, []byte, string) (string, error)) *SopsMock_Decrypt_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DecryptFile provides a mock function with given fields: ctx, path, fileType
+func (_m *SopsMock) DecryptFile(ctx context.Context, path string, fileType string) (string, error) {
+	ret := _m.Called(ctx, path, fileType)
 
 	if len(ret) == 0 {
 		panic("no return value specified for DecryptFile")
@@ -27,17 +90,17 @@ func (_m *SopsMock) DecryptFile(path string) (string, error) {
 
 	var r0 string
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
-		return rf(path)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (string, error)); ok {
+		return rf(ctx, path, fileType)
 	}
-	if rf, ok := ret.Get(0).(func(string) string); ok {
-		r0 = rf(path)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
+		r0 = rf(ctx, path, fileType)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(path)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, path, fileType)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -51,14 +114,16 @@ type SopsMock_DecryptFile_Call struct {
 }
 
 // DecryptFile is a helper method to define mock.On call
+//   - ctx context.Context
 //   - path string
-func (_e *SopsMock_Expecter) DecryptFile(path interface{}) *SopsMock_DecryptFile_Call {
-	return &SopsMock_DecryptFile_Call{Call: _e.mock.On("DecryptFile", path)}
+//   - fileType string
+func (_e *SopsMock_Expecter) DecryptFile(ctx interface{}, path interface{}, fileType interface{}) *SopsMock_DecryptFile_Call {
+	return &SopsMock_DecryptFile_Call{Call: _e.mock.On("DecryptFile", ctx, path, fileType)}
 }
 
-func (_c *SopsMock_DecryptFile_Call) Run(run func(path string)) *SopsMock_DecryptFile_Call {
+func (_c *SopsMock_DecryptFile_Call) Run(run func(ctx context.Context, path string, fileType string)) *SopsMock_DecryptFile_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string))
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
 	})
 	return _c
 }
@@ -68,7 +133,64 @@ func (_c *SopsMock_DecryptFile_Call) Return(_a0 string, _a1 error) *SopsMock_Dec
 	return _c
 }
 
-func (_c *SopsMock_DecryptFile_Call) RunAndReturn(run func(string) (string, error)) *SopsMock_DecryptFile_Call {
+func (_c *SopsMock_DecryptFile_Call) RunAndReturn(run func(context.Context, string, string) (string, error)) *SopsMock_DecryptFile_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Metadata provides a mock function with given fields: content, fileType
+func (_m *SopsMock) Metadata(content []byte, fileType string) (sops.Metadata, error) {
+	ret := _m.Called(content, fileType)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Metadata")
+	}
+
+	var r0 sops.Metadata
+	var r1 error
+	if rf, ok := ret.Get(0).(func([]byte, string) (sops.Metadata, error)); ok {
+		return rf(content, fileType)
+	}
+	if rf, ok := ret.Get(0).(func([]byte, string) sops.Metadata); ok {
+		r0 = rf(content, fileType)
+	} else {
+		r0 = ret.Get(0).(sops.Metadata)
+	}
+
+	if rf, ok := ret.Get(1).(func([]byte, string) error); ok {
+		r1 = rf(content, fileType)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SopsMock_Metadata_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Metadata'
+type SopsMock_Metadata_Call struct {
+	*mock.Call
+}
+
+// Metadata is a helper method to define mock.On call
+//   - content []byte
+//   - fileType string
+func (_e *SopsMock_Expecter) Metadata(content interface{}, fileType interface{}) *SopsMock_Metadata_Call {
+	return &SopsMock_Metadata_Call{Call: _e.mock.On("Metadata", content, fileType)}
+}
+
+func (_c *SopsMock_Metadata_Call) Run(run func(content []byte, fileType string)) *SopsMock_Metadata_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]byte), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *SopsMock_Metadata_Call) Return(_a0 sops.Metadata, _a1 error) *SopsMock_Metadata_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *SopsMock_Metadata_Call) RunAndReturn(run func([]byte, string) (sops.Metadata, error)) *SopsMock_Metadata_Call {
 	_c.Call.Return(run)
 	return _c
 }
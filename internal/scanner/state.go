@@ -0,0 +1,273 @@
+package scanner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/alican-uelger/deep-scan/internal/git"
+	"github.com/alican-uelger/deep-scan/internal/matcher"
+)
+
+// Statuses of the findings of incremental scans
+const (
+	StatusNew       = "new"
+	StatusUnchanged = "unchanged"
+	StatusResolved  = "resolved"
+)
+
+const stateVersion = 1
+
+// State holds the last scanned commit and the findings of every ref of the projects, it makes the next scan incremental
+type State struct {
+	Version int                 `json:"version"`
+	Refs    map[string]RefState `json:"refs"`
+}
+
+// RefState is the scan of a ref, Options is the hash of the search options of the scan (see optionsHash)
+type RefState struct {
+	Repository string      `json:"repository"`
+	Ref        string      `json:"ref"`
+	Commit     string      `json:"commit"`
+	Options    string      `json:"options,omitempty"`
+	Findings   []FileMatch `json:"findings"`
+}
+
+func NewState() *State {
+	return &State{Version: stateVersion, Refs: map[string]RefState{}}
+}
+
+// ReadState parses a state written by a previous scan
+func ReadState(data []byte) (*State, error) {
+	state := NewState()
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, err
+	}
+	if state.Version != stateVersion {
+		return nil, fmt.Errorf("unsupported state version %d, expected %d", state.Version, stateVersion)
+	}
+	if state.Refs == nil {
+		state.Refs = map[string]RefState{}
+	}
+	return state, nil
+}
+
+func refKey(repository, ref string) string {
+	return repository + "@" + ref
+}
+
+func fileLocation(file File) string {
+	return filepath.Join(file.Path, file.Name)
+}
+
+/*
+incremental compares the findings of a search with the state of the previous search.
+Every ref of a project is registered before it is scanned, files of refs which are not registered are ignored.
+*/
+type incremental struct {
+	state   *State
+	options string
+	mu      sync.Mutex
+	refs    map[string]*refScan
+}
+
+type refScan struct {
+	repository string
+	ref        string
+	commit     string
+	previous   map[string]FileMatch
+	// changed are the locations scanned again, nil when all files are scanned
+	changed  map[string]bool
+	findings []FileMatch
+	// incomplete refs have files which could not be scanned, their state is not updated
+	incomplete bool
+}
+
+// newIncremental compares the findings with the state, options is the hash of the search options
+func newIncremental(state *State, options string) *incremental {
+	if state == nil {
+		return nil
+	}
+	return &incremental{state: state, options: options, refs: map[string]*refScan{}}
+}
+
+/*
+optionsHash hashes the search options which change the findings of a file. The previous findings of the unchanged
+files are only valid for a scan with the same options, so a scan with other options scans all files.
+*/
+func optionsHash(options SearchOptions) string {
+	var rules []matcher.Rule
+	if options.Rules != nil {
+		rules = options.Rules.List()
+	}
+	// the fingerprints of the findings depend on the key of the baseline
+	var baselineKey string
+	if options.Baseline != nil {
+		baselineKey = options.Baseline.Key
+	}
+	var entropy []string
+	if options.Entropy != nil {
+		entropy = append(options.Entropy.Charsets(), fmt.Sprintf("min-length=%d", options.Entropy.MinLength()))
+	}
+	data, err := json.Marshal([]any{
+		options.Name, options.NameContains, options.NameRegex,
+		options.Path, options.PathContains, options.PathRegex,
+		options.Content, options.ContentRegex, options.ContentPatterns,
+		options.Sops, options.SopsOnly, options.SopsContentBeforeDecryption,
+		options.SopsKey, options.SopsKeyPath, options.SopsValue, options.SopsValueRegex,
+		options.SopsMetadata, options.SopsPolicy, options.SopsFormats,
+		options.ExcludeName, options.ExcludeNameContains, options.ExcludePath, options.ExcludePathContains, options.ExcludeContent,
+		rules, entropy,
+		options.NoSnippets, options.Redact, options.RedactAll, baselineKey,
+	})
+	if err != nil {
+		// the options are plain values, which are always marshalled
+		panic(err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+/*
+begin registers the scan of a ref and returns the commit of the previous scan, empty without a previous scan
+or when the previous scan used other search options.
+*/
+func (i *incremental) begin(project git.Project, ref git.Ref) string {
+	if i == nil {
+		return ""
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	key := refKey(project.PathWithNamespace, ref.Name)
+	previous := i.state.Refs[key]
+	scan := &refScan{
+		repository: project.PathWithNamespace,
+		ref:        ref.Name,
+		commit:     ref.SHA,
+		previous:   map[string]FileMatch{},
+	}
+	for _, finding := range previous.Findings {
+		scan.previous[fileLocation(finding.File)] = finding
+	}
+	i.refs[key] = scan
+	if previous.Commit != "" && previous.Options != i.options {
+		slog.Info(fmt.Sprintf("scanning all files of %s, the search options changed since the previous scan", key))
+		return ""
+	}
+	return previous.Commit
+}
+
+/*
+carry limits the scan of a ref to the changed files and returns the previous findings of all other files,
+which are unchanged. The findings are moved to the scanned commit.
+*/
+func (i *incremental) carry(project git.Project, ref git.Ref, changes []git.FileChange) []FileMatch {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	scan := i.refs[refKey(project.PathWithNamespace, ref.Name)]
+	scan.changed = map[string]bool{}
+	for _, change := range changes {
+		scan.changed[fileLocation(projectFile(project, ref, change.Path))] = true
+		if change.PreviousPath != "" {
+			scan.changed[fileLocation(projectFile(project, ref, change.PreviousPath))] = true
+		}
+	}
+	var carried []FileMatch
+	for location, finding := range scan.previous {
+		if scan.changed[location] {
+			continue
+		}
+		finding.Ref = ref.Name
+		finding.Commit = ref.SHA
+		finding.Status = StatusUnchanged
+		carried = append(carried, finding)
+	}
+	sortFileMatches(carried)
+	scan.findings = append(scan.findings, carried...)
+	return carried
+}
+
+// match sets the status of a finding, files found by the previous scan are unchanged
+func (i *incremental) match(fileMatch *FileMatch) {
+	if i == nil {
+		return
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	scan, ok := i.refs[refKey(fileMatch.Repository, fileMatch.Ref)]
+	if !ok {
+		return
+	}
+	fileMatch.Status = StatusNew
+	if _, found := scan.previous[fileLocation(fileMatch.File)]; found {
+		fileMatch.Status = StatusUnchanged
+	}
+	scan.findings = append(scan.findings, *fileMatch)
+}
+
+// skip marks the ref of a file which could not be scanned as incomplete
+func (i *incremental) skip(file File) {
+	if i == nil {
+		return
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if scan, ok := i.refs[refKey(file.Repository, file.Ref)]; ok {
+		scan.incomplete = true
+	}
+}
+
+/*
+finish updates the state with the scanned refs and returns the resolved findings: the findings of the previous
+scan which were scanned again and not found anymore. Incomplete refs keep their previous state.
+*/
+func (i *incremental) finish() []FileMatch {
+	if i == nil {
+		return nil
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	var resolved []FileMatch
+	for key, scan := range i.refs {
+		if scan.incomplete {
+			continue
+		}
+		found := map[string]bool{}
+		for _, finding := range scan.findings {
+			found[fileLocation(finding.File)] = true
+		}
+		for location, finding := range scan.previous {
+			if found[location] || (scan.changed != nil && !scan.changed[location]) {
+				continue
+			}
+			finding.Status = StatusResolved
+			resolved = append(resolved, finding)
+		}
+		findings := make([]FileMatch, len(scan.findings))
+		for j, finding := range scan.findings {
+			finding.Status = ""
+			findings[j] = finding
+		}
+		sortFileMatches(findings)
+		i.state.Refs[key] = RefState{Repository: scan.repository, Ref: scan.ref, Commit: scan.commit, Options: i.options, Findings: findings}
+	}
+	sortFileMatches(resolved)
+	return resolved
+}
+
+func sortFileMatches(fileMatches []FileMatch) {
+	sort.SliceStable(fileMatches, func(a, b int) bool {
+		if fileMatches[a].Repository != fileMatches[b].Repository {
+			return fileMatches[a].Repository < fileMatches[b].Repository
+		}
+		if fileMatches[a].Ref != fileMatches[b].Ref {
+			return fileMatches[a].Ref < fileMatches[b].Ref
+		}
+		return fileLocation(fileMatches[a].File) < fileLocation(fileMatches[b].File)
+	})
+}
@@ -0,0 +1,64 @@
+//go:build unit
+
+package scanner
+
+import (
+	"testing"
+
+	"github.com/alican-uelger/deep-scan/internal/git"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadState(t *testing.T) {
+	state, err := ReadState([]byte(`{"version": 1, "refs": {"org/repo@main": {"repository": "org/repo", "ref": "main", "commit": "abc123", "findings": []}}}`))
+	require.NoError(t, err)
+	assert.Equal(t, "abc123", state.Refs["org/repo@main"].Commit)
+
+	_, err = ReadState([]byte(`{"version": 2}`))
+	assert.EqualError(t, err, "unsupported state version 2, expected 1")
+}
+
+func TestIncrementalIncompleteRef(t *testing.T) {
+	project := git.Project{PathWithNamespace: "org/repo"}
+	previous := RefState{
+		Repository: "org/repo",
+		Ref:        "main",
+		Commit:     "old",
+		Findings:   []FileMatch{{File: File{Name: "a.txt", Path: "org/repo", Repository: "org/repo", Ref: "main"}}},
+	}
+	state := NewState()
+	state.Refs["org/repo@main"] = previous
+	inc := newIncremental(state, "")
+
+	assert.Equal(t, "old", inc.begin(project, git.Ref{Name: "main", SHA: "new"}))
+	// a file which could not be scanned can still contain the finding
+	inc.skip(File{Name: "a.txt", Path: "org/repo", Repository: "org/repo", Ref: "main"})
+
+	assert.Empty(t, inc.finish())
+	assert.Equal(t, previous, state.Refs["org/repo@main"])
+}
+
+func TestIncrementalIgnoresUnregisteredRefs(t *testing.T) {
+	inc := newIncremental(NewState(), "")
+	fileMatch := FileMatch{File: File{Name: "a.txt", Path: "dir"}}
+
+	inc.match(&fileMatch)
+	assert.Empty(t, fileMatch.Status)
+	assert.Empty(t, inc.finish())
+
+	var disabled *incremental
+	disabled.match(&fileMatch)
+	assert.Empty(t, disabled.begin(git.Project{}, git.Ref{}))
+}
+
+func TestOptionsHash(t *testing.T) {
+	options := SearchOptions{Content: []string{"secret"}}
+	hash := optionsHash(options)
+
+	// options which do not change the findings keep the hash
+	assert.Equal(t, hash, optionsHash(SearchOptions{Content: []string{"secret"}, Silent: true, Concurrency: 4, OnMatch: func(FileMatch) {}}))
+	assert.NotEqual(t, hash, optionsHash(SearchOptions{Content: []string{"password"}}))
+	assert.NotEqual(t, hash, optionsHash(SearchOptions{Content: []string{"secret"}, ExcludePath: []string{"vendor"}}))
+	assert.NotEqual(t, hash, optionsHash(SearchOptions{Content: []string{"secret"}, NoSnippets: true}))
+}
@@ -3,6 +3,8 @@
 package scanner
 
 import (
+	regexp "regexp"
+
 	matcher "github.com/alican-uelger/deep-scan/internal/matcher"
 	mock "github.com/stretchr/testify/mock"
 )
@@ -88,6 +90,73 @@ func (_c *TextMatcherMock_Match_Call) RunAndReturn(run func(string, string, matc
 	return _c
 }
 
+// MatchRegex provides a mock function with given fields: text, regex, context
+func (_m *TextMatcherMock) MatchRegex(text string, regex *regexp.Regexp, context int) (bool, bool, []matcher.MatchResult) {
+	ret := _m.Called(text, regex, context)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MatchRegex")
+	}
+
+	var r0 bool
+	var r1 bool
+	var r2 []matcher.MatchResult
+	if rf, ok := ret.Get(0).(func(string, *regexp.Regexp, int) (bool, bool, []matcher.MatchResult)); ok {
+		return rf(text, regex, context)
+	}
+	if rf, ok := ret.Get(0).(func(string, *regexp.Regexp, int) bool); ok {
+		r0 = rf(text, regex, context)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, *regexp.Regexp, int) bool); ok {
+		r1 = rf(text, regex, context)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	if rf, ok := ret.Get(2).(func(string, *regexp.Regexp, int) []matcher.MatchResult); ok {
+		r2 = rf(text, regex, context)
+	} else {
+		if ret.Get(2) != nil {
+			r2 = ret.Get(2).([]matcher.MatchResult)
+		}
+	}
+
+	return r0, r1, r2
+}
+
+// TextMatcherMock_MatchRegex_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MatchRegex'
+type TextMatcherMock_MatchRegex_Call struct {
+	*mock.Call
+}
+
+// MatchRegex is a helper method to define mock.On call
+//   - text string
+//   - regex *regexp.Regexp
+//   - context int
+func (_e *TextMatcherMock_Expecter) MatchRegex(text interface{}, regex interface{}, context interface{}) *TextMatcherMock_MatchRegex_Call {
+	return &TextMatcherMock_MatchRegex_Call{Call: _e.mock.On("MatchRegex", text, regex, context)}
+}
+
+func (_c *TextMatcherMock_MatchRegex_Call) Run(run func(text string, regex *regexp.Regexp, context int)) *TextMatcherMock_MatchRegex_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(*regexp.Regexp), args[2].(int))
+	})
+	return _c
+}
+
+func (_c *TextMatcherMock_MatchRegex_Call) Return(_a0 bool, _a1 bool, _a2 []matcher.MatchResult) *TextMatcherMock_MatchRegex_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *TextMatcherMock_MatchRegex_Call) RunAndReturn(run func(string, *regexp.Regexp, int) (bool, bool, []matcher.MatchResult)) *TextMatcherMock_MatchRegex_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewTextMatcherMock creates a new instance of TextMatcherMock. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewTextMatcherMock(t interface {
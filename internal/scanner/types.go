@@ -1,15 +1,40 @@
 package scanner
 
 import (
+	"context"
+	"io"
+	"regexp"
+
 	"github.com/alican-uelger/deep-scan/internal/git"
+	"github.com/alican-uelger/deep-scan/internal/history"
 	"github.com/alican-uelger/deep-scan/internal/matcher"
+	"github.com/alican-uelger/deep-scan/internal/redact"
+	"github.com/alican-uelger/deep-scan/internal/sops"
 )
 
 type GitClient interface {
-	ListGroupProjects(group string) ([]git.Project, error)
-	ListRepositoryTree(project git.Project) ([]git.TreeNode, error)
-	GetRawFile(project git.Project, path string) ([]byte, error)
-	GetProjectByName(name string) (git.Project, error)
+	ListGroupProjects(ctx context.Context, group string, filter git.ProjectFilter) ([]git.Project, error)
+	ListRepositoryTree(ctx context.Context, project git.Project, ref string) ([]git.TreeNode, error)
+	GetRawFile(ctx context.Context, project git.Project, ref, path string) ([]byte, error)
+	GetProjectByName(ctx context.Context, name string) (git.Project, error)
+	ResolveRef(ctx context.Context, project git.Project, ref string) (git.Ref, error)
+	ListBranches(ctx context.Context, project git.Project) ([]git.Ref, error)
+	GetArchive(ctx context.Context, project git.Project, ref string) (io.ReadCloser, error)
+	CompareCommits(ctx context.Context, project git.Project, base, head string) ([]git.FileChange, error)
+}
+
+// Cache stores the trees of commits and the contents of blobs across scans
+type Cache interface {
+	Tree(commit string) ([]git.TreeNode, bool)
+	PutTree(commit string, tree []git.TreeNode) error
+	Blob(sha string) ([]byte, bool)
+	HasBlob(sha string) bool
+	PutBlob(sha string, content []byte) error
+}
+
+type GitHistory interface {
+	Walk(ctx context.Context, options history.LogOptions, fn func(history.Change) error) error
+	Show(ctx context.Context, rev, path string) ([]byte, error)
 }
 
 type Storage interface {
@@ -21,47 +46,168 @@ type Storage interface {
 }
 
 type Sops interface {
-	DecryptFile(path string) (string, error)
+	DecryptFile(ctx context.Context, path string, fileType sops.FileType) (string, error)
+	Decrypt(ctx context.Context, content []byte, fileType sops.FileType) (string, error)
+	Metadata(content []byte, fileType sops.FileType) (sops.Metadata, error)
 }
 
 type FileType string
 
 // nolint
 const (
-	FILE        FileType = "FILE"
-	SOPS_SECRET FileType = "SOPS_SECRET"
-	SOPS_CONFIG FileType = "SOPS_CONFIG"
+	FILE             FileType = "FILE"
+	SOPS_SECRET      FileType = "SOPS_SECRET"
+	SOPS_CONFIG      FileType = "SOPS_CONFIG"
+	SOPS_UNENCRYPTED FileType = "SOPS_UNENCRYPTED" // plaintext file matching a creation rule of a sops config
 )
 
 type File struct {
-	Name string   `json:"name" yaml:"name"`
-	Path string   `json:"path" yaml:"path"`
-	Type FileType `json:"type" yaml:"type"`
+	Name       string   `json:"name" yaml:"name"`
+	Path       string   `json:"path" yaml:"path"`
+	Type       FileType `json:"type" yaml:"type"`
+	Repository string   `json:"repository,omitempty" yaml:"repository,omitempty"`
+	// RepositoryURL, Ref and Commit are only set for files of git hosts
+	RepositoryURL string `json:"repositoryUrl,omitempty" yaml:"repositoryUrl,omitempty"`
+	Ref           string `json:"ref,omitempty" yaml:"ref,omitempty"`
+	Commit        string `json:"commit,omitempty" yaml:"commit,omitempty"`
 }
 
+const (
+	FormatText   = "text"
+	FormatNDJSON = "ndjson"
+)
+
+// Fetch strategies of the git hosts
+const (
+	// FetchFiles requests every file on its own
+	FetchFiles = "files"
+	// FetchArchive downloads the archive of a repository once
+	FetchArchive = "archive"
+	// FetchAuto downloads the archive, when more than archiveThreshold files need their content
+	FetchAuto = "auto"
+)
+
+const archiveThreshold = 25
+
+// Rules identify the search option a match was found by
+const (
+	RuleName           = "name"
+	RuleNameContains   = "name-contains"
+	RuleNameRegex      = "name-regex"
+	RulePath           = "path"
+	RulePathContains   = "path-contains"
+	RulePathRegex      = "path-regex"
+	RuleContent        = "content"
+	RuleContentRegex   = "content-regex"
+	RuleContentFile    = "content-file"
+	RuleSopsKeyPath    = "sops-key-path"
+	RuleSopsValue      = "sops-value"
+	RuleSopsValueRegex = "sops-value-regex"
+	// RuleRules matches carry the ID of their rule of the rule pack in RuleID
+	RuleRules = "rules"
+	// RuleEntropy matches carry the charset of their token as search
+	RuleEntropy = "entropy"
+)
+
 type FileMatch struct {
 	File
-	Matches []matcher.MatchResult `json:"matches" yaml:"matches"`
+	Matches    []matcher.MatchResult `json:"matches" yaml:"matches"`
+	Sops       *sops.Metadata        `json:"sops,omitempty" yaml:"sops,omitempty"`
+	SopsConfig *sops.Config          `json:"sopsConfig,omitempty" yaml:"sopsConfig,omitempty"`
+	SopsRule   *sops.CreationRule    `json:"sopsRule,omitempty" yaml:"sopsRule,omitempty"`
+	// History is only set for files of a git history scan
+	History *HistoryCommit `json:"history,omitempty" yaml:"history,omitempty"`
+	// Status is only set for incremental scans: StatusNew, StatusUnchanged or StatusResolved
+	Status string `json:"status,omitempty" yaml:"status,omitempty"`
+	// Fingerprint identifies the file, it is used by baselines for findings without matches (e.g. SOPS keys)
+	Fingerprint string `json:"fingerprint,omitempty" yaml:"fingerprint,omitempty"`
+}
+
+// HistoryCommit is the commit which added the matching lines of a file
+type HistoryCommit struct {
+	history.Commit `yaml:",inline"`
+	// InHead reports if a match still exists in the file at HEAD
+	InHead bool `json:"inHead" yaml:"inHead"`
+}
+
+// SkippedFile is a file which could not be scanned, e.g. because its content could not be read
+type SkippedFile struct {
+	File
+	Reason string `json:"reason" yaml:"reason"`
+}
+
+// IgnoredMatch is a content match ignored by a deep-scan:ignore annotation
+type IgnoredMatch struct {
+	File
+	Line int    `json:"line" yaml:"line"`
+	Rule string `json:"rule" yaml:"rule"`
+	// Annotation is AnnotationIgnore, AnnotationIgnoreNextLine or AnnotationIgnoreFile
+	Annotation string `json:"annotation" yaml:"annotation"`
 }
 
 type SearchOptions struct {
 	Name                        []string
 	NameContains                []string
-	NameRegex                   []string
+	NameRegex                   []*regexp.Regexp
 	Path                        []string
 	PathContains                []string
-	PathRegex                   []string
+	PathRegex                   []*regexp.Regexp
 	Content                     []string
-	ContentRegex                []string
+	ContentRegex                []*regexp.Regexp
 	Sops                        bool
 	SopsOnly                    bool
 	SopsContentBeforeDecryption []string
+	SopsKey                     []string
+	SopsKeyPath                 []string
+	SopsValue                   []string
+	SopsValueRegex              []*regexp.Regexp
+	SopsMetadata                bool
+	SopsPolicy                  bool
+	SopsFormats                 []sops.FormatRule
 	ExcludeName                 []string
 	ExcludeNameContains         []string
 	ExcludePath                 []string
 	ExcludePathContains         []string
 	ExcludeContent              []string
-	NoSnippets                  bool
-	LogLate                     bool
-	Project                     string
+	// ContentPatterns are loaded from --content-file, the content has to match at least one of them
+	ContentPatterns []matcher.Pattern
+	// Rules of the rule packs matched in the content, nil disables them
+	Rules *matcher.Rules
+	// Entropy finds high-entropy tokens in the content, nil disables it
+	Entropy *matcher.Entropy
+	// query holds the content patterns compiled once per search, see newContentQuery
+	query      *contentQuery
+	NoSnippets bool
+	Redact     redact.Redactor
+	RedactAll  bool
+	LogLate    bool
+	Silent     bool
+	// Format of the console output, FormatText or FormatNDJSON
+	Format string
+	// OnMatch is called for every file found, while the search is running
+	OnMatch func(FileMatch)
+	// OnSkip is called for every file which could not be scanned
+	OnSkip func(SkippedFile)
+	// OnIgnore is called for every content match ignored by an annotation
+	OnIgnore func(IgnoredMatch)
+	// Projects are scanned instead of the projects of an org/group
+	Projects      []string
+	ProjectFilter git.ProjectFilter
+	// Ref is the branch, tag or commit scanned instead of the default branch
+	Ref         string
+	AllBranches bool
+	// BranchRegex scans all branches matching the regex
+	BranchRegex *regexp.Regexp
+	// Fetch is the fetch strategy of the git hosts: FetchFiles, FetchArchive or FetchAuto
+	Fetch string
+	// Cache of the git hosts, nil disables it
+	Cache Cache
+	// State of the previous scan, the git hosts only scan the files changed since, nil scans all files
+	State *State
+	// Baseline of accepted findings, they are not reported
+	Baseline *Baseline
+	// History selects the commits of a git history scan
+	History         history.LogOptions
+	Concurrency     int
+	SopsConcurrency int
 }
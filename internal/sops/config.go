@@ -0,0 +1,182 @@
+package sops
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Config is the parsed .sops.yaml file
+type Config struct {
+	CreationRules []CreationRule `json:"creationRules" yaml:"creationRules"`
+}
+
+type CreationRule struct {
+	PathRegex         string     `json:"pathRegex,omitempty" yaml:"pathRegex,omitempty"`
+	KeyGroups         []KeyGroup `json:"keyGroups" yaml:"keyGroups"`
+	EncryptedRegex    string     `json:"encryptedRegex,omitempty" yaml:"encryptedRegex,omitempty"`
+	UnencryptedRegex  string     `json:"unencryptedRegex,omitempty" yaml:"unencryptedRegex,omitempty"`
+	EncryptedSuffix   string     `json:"encryptedSuffix,omitempty" yaml:"encryptedSuffix,omitempty"`
+	UnencryptedSuffix string     `json:"unencryptedSuffix,omitempty" yaml:"unencryptedSuffix,omitempty"`
+}
+
+/*
+the raw types follow the sops config file format, see
+https://github.com/getsops/sops/blob/main/config/config.go
+*/
+
+type rawConfig struct {
+	CreationRules []rawCreationRule `yaml:"creation_rules"`
+}
+
+type rawCreationRule struct {
+	PathRegex         string        `yaml:"path_regex"`
+	KMS               string        `yaml:"kms"`
+	Age               string        `yaml:"age"`
+	PGP               string        `yaml:"pgp"`
+	GCPKMS            string        `yaml:"gcp_kms"`
+	AzureKeyVault     string        `yaml:"azure_keyvault"`
+	VaultURI          string        `yaml:"hc_vault_transit_uri"`
+	KeyGroups         []rawKeyGroup `yaml:"key_groups"`
+	EncryptedRegex    string        `yaml:"encrypted_regex"`
+	UnencryptedRegex  string        `yaml:"unencrypted_regex"`
+	EncryptedSuffix   string        `yaml:"encrypted_suffix"`
+	UnencryptedSuffix string        `yaml:"unencrypted_suffix"`
+}
+
+type rawKeyGroup struct {
+	Merge []rawKeyGroup `yaml:"merge"`
+	KMS   []struct {
+		Arn string `yaml:"arn"`
+	} `yaml:"kms"`
+	GCPKMS []struct {
+		ResourceID string `yaml:"resource_id"`
+	} `yaml:"gcp_kms"`
+	AzureKV []struct {
+		VaultURL string `yaml:"vaultUrl"`
+		Key      string `yaml:"key"`
+		Version  string `yaml:"version"`
+	} `yaml:"azure_keyvault"`
+	Vault []string `yaml:"hc_vault"`
+	Age   []string `yaml:"age"`
+	PGP   []string `yaml:"pgp"`
+}
+
+// IsConfigFile checks if the file name is a sops config file
+func IsConfigFile(name string) bool {
+	return name == ".sops.yaml" || name == ".sops.yml"
+}
+
+// ParseConfig parses the creation rules of a .sops.yaml file
+func ParseConfig(content []byte) (Config, error) {
+	var raw rawConfig
+	err := yaml.Unmarshal(content, &raw)
+	if err != nil {
+		return Config{}, fmt.Errorf("could not parse sops config: %w", err)
+	}
+	config := Config{}
+	for _, rawRule := range raw.CreationRules {
+		if rawRule.PathRegex != "" {
+			if _, err := regexp.Compile(rawRule.PathRegex); err != nil {
+				return Config{}, fmt.Errorf("invalid path_regex '%s': %w", rawRule.PathRegex, err)
+			}
+		}
+		config.CreationRules = append(config.CreationRules, newCreationRule(rawRule))
+	}
+	return config, nil
+}
+
+/*
+MatchingRule returns the first creation rule with a path_regex matching the path.
+The path has to be relative to the directory of the config file, like sops does it.
+Rules without path_regex are ignored, since they would match every file.
+*/
+func (c Config) MatchingRule(relativePath string) (CreationRule, bool) {
+	for _, rule := range c.CreationRules {
+		if rule.PathRegex == "" {
+			continue
+		}
+		re, err := regexp.Compile(rule.PathRegex)
+		if err != nil {
+			continue
+		}
+		if re.MatchString(relativePath) {
+			return rule, true
+		}
+	}
+	return CreationRule{}, false
+}
+
+func newCreationRule(raw rawCreationRule) CreationRule {
+	rule := CreationRule{
+		PathRegex:         raw.PathRegex,
+		EncryptedRegex:    raw.EncryptedRegex,
+		UnencryptedRegex:  raw.UnencryptedRegex,
+		EncryptedSuffix:   raw.EncryptedSuffix,
+		UnencryptedSuffix: raw.UnencryptedSuffix,
+	}
+	if len(raw.KeyGroups) > 0 {
+		for _, rawGroup := range raw.KeyGroups {
+			rule.KeyGroups = append(rule.KeyGroups, newConfigKeyGroup(rawGroup))
+		}
+		return rule
+	}
+
+	// without key_groups, all keys of the rule form a single group
+	var group KeyGroup
+	group = append(group, splitKeys(AWSKMS, raw.KMS)...)
+	group = append(group, splitKeys(PGP, raw.PGP)...)
+	group = append(group, splitKeys(GCPKMS, raw.GCPKMS)...)
+	group = append(group, splitKeys(AzureKV, raw.AzureKeyVault)...)
+	group = append(group, splitKeys(HCVault, raw.VaultURI)...)
+	group = append(group, splitKeys(Age, raw.Age)...)
+	if len(group) > 0 {
+		rule.KeyGroups = []KeyGroup{group}
+	}
+	return rule
+}
+
+func newConfigKeyGroup(raw rawKeyGroup) KeyGroup {
+	var group KeyGroup
+	for _, merged := range raw.Merge {
+		group = append(group, newConfigKeyGroup(merged)...)
+	}
+	for _, k := range raw.KMS {
+		group = append(group, Key{Type: AWSKMS, ID: k.Arn})
+	}
+	for _, k := range raw.PGP {
+		group = append(group, Key{Type: PGP, ID: k})
+	}
+	for _, k := range raw.GCPKMS {
+		group = append(group, Key{Type: GCPKMS, ID: k.ResourceID})
+	}
+	for _, k := range raw.AzureKV {
+		group = append(group, Key{Type: AzureKV, ID: fmt.Sprintf("%s/keys/%s/%s", strings.TrimSuffix(k.VaultURL, "/"), k.Key, k.Version)})
+	}
+	for _, k := range raw.Vault {
+		group = append(group, Key{Type: HCVault, ID: k})
+	}
+	for _, k := range raw.Age {
+		group = append(group, Key{Type: Age, ID: k})
+	}
+	return group
+}
+
+// splitKeys splits the comma separated keys of a creation rule
+func splitKeys(keyType KeyType, keys string) []Key {
+	var result []Key
+	for _, k := range strings.Split(keys, ",") {
+		k = strings.TrimSpace(k)
+		if k == "" {
+			continue
+		}
+		// kms keys may contain a role after a '+'
+		if keyType == AWSKMS {
+			k, _, _ = strings.Cut(k, "+")
+		}
+		result = append(result, Key{Type: keyType, ID: k})
+	}
+	return result
+}
@@ -0,0 +1,94 @@
+//go:build unit
+
+package sops
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sopsConfig = `creation_rules:
+  - path_regex: secrets/.*\.yaml$
+    encrypted_regex: ^(data|stringData)$
+    age: age1abc, age1def
+    kms: arn:aws:kms:eu-central-1:123456789012:key/abcd+arn:aws:iam::123456789012:role/sops
+  - path_regex: prod/.*
+    key_groups:
+      - pgp:
+          - FBC7B9E2A4F9289AC0C1D4843D16CEE4A27381B4
+        azure_keyvault:
+          - vaultUrl: https://vault.vault.azure.net
+            key: sops
+            version: "1234"
+      - merge:
+          - age:
+              - age1ghi
+        gcp_kms:
+          - resource_id: projects/p/locations/global/keyRings/r/cryptoKeys/k
+  - age: age1fallback
+`
+
+func TestIsConfigFile(t *testing.T) {
+	assert.True(t, IsConfigFile(".sops.yaml"))
+	assert.True(t, IsConfigFile(".sops.yml"))
+	assert.False(t, IsConfigFile("sops.yaml"))
+}
+
+func TestParseConfig(t *testing.T) {
+	config, err := ParseConfig([]byte(sopsConfig))
+	require.NoError(t, err)
+
+	expected := Config{
+		CreationRules: []CreationRule{
+			{
+				PathRegex:      `secrets/.*\.yaml$`,
+				EncryptedRegex: "^(data|stringData)$",
+				KeyGroups: []KeyGroup{{
+					{Type: AWSKMS, ID: "arn:aws:kms:eu-central-1:123456789012:key/abcd"},
+					{Type: Age, ID: "age1abc"},
+					{Type: Age, ID: "age1def"},
+				}},
+			},
+			{
+				PathRegex: "prod/.*",
+				KeyGroups: []KeyGroup{
+					{
+						{Type: PGP, ID: "FBC7B9E2A4F9289AC0C1D4843D16CEE4A27381B4"},
+						{Type: AzureKV, ID: "https://vault.vault.azure.net/keys/sops/1234"},
+					},
+					{
+						{Type: Age, ID: "age1ghi"},
+						{Type: GCPKMS, ID: "projects/p/locations/global/keyRings/r/cryptoKeys/k"},
+					},
+				},
+			},
+			{
+				KeyGroups: []KeyGroup{{{Type: Age, ID: "age1fallback"}}},
+			},
+		},
+	}
+	assert.Equal(t, expected, config)
+}
+
+func TestParseConfigInvalidRegex(t *testing.T) {
+	_, err := ParseConfig([]byte("creation_rules:\n  - path_regex: '('\n"))
+	require.Error(t, err)
+}
+
+func TestConfigMatchingRule(t *testing.T) {
+	config, err := ParseConfig([]byte(sopsConfig))
+	require.NoError(t, err)
+
+	rule, ok := config.MatchingRule("secrets/db.yaml")
+	assert.True(t, ok)
+	assert.Equal(t, `secrets/.*\.yaml$`, rule.PathRegex)
+
+	rule, ok = config.MatchingRule("prod/values.json")
+	assert.True(t, ok)
+	assert.Equal(t, "prod/.*", rule.PathRegex)
+
+	_, ok = config.MatchingRule("README.md")
+	assert.False(t, ok)
+}
@@ -0,0 +1,148 @@
+package sops
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Entry is a single scalar value of a decrypted document with its dot separated key path (e.g. spec.database.password)
+type Entry struct {
+	KeyPath string
+	Value   string
+	Line    int
+	Column  int
+}
+
+// ParseDocument flattens a decrypted YAML, JSON, dotenv or INI document into its scalar entries in document order.
+// Binary files have no structure, so they have no entries.
+func ParseDocument(content []byte, fileType FileType) ([]Entry, error) {
+	switch fileType {
+	case YAML, JSON:
+		// JSON is parsed as YAML to keep the positions of the values
+		return parseYamlDocument(content)
+	case DotEnv:
+		return parseDotEnvDocument(content), nil
+	case INI:
+		return parseIniDocument(content), nil
+	case Binary:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unsupported document format: %s", fileType)
+}
+
+func parseYamlDocument(content []byte) ([]Entry, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	var entries []Entry
+	for {
+		var node yaml.Node
+		if err := decoder.Decode(&node); err != nil {
+			if errors.Is(err, io.EOF) {
+				return entries, nil
+			}
+			return nil, fmt.Errorf("could not parse document: %w", err)
+		}
+		entries = appendYamlEntries(entries, nil, &node)
+	}
+}
+
+func appendYamlEntries(entries []Entry, path []string, node *yaml.Node) []Entry {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			entries = appendYamlEntries(entries, path, child)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			entries = appendYamlEntries(entries, append(path, node.Content[i].Value), node.Content[i+1])
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			entries = appendYamlEntries(entries, append(path, strconv.Itoa(i)), child)
+		}
+	case yaml.AliasNode:
+		entries = appendYamlEntries(entries, path, node.Alias)
+	case yaml.ScalarNode:
+		column := node.Column
+		// the position of quoted scalars points to the quote
+		if node.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0 {
+			column++
+		}
+		entries = append(entries, Entry{
+			KeyPath: strings.Join(path, "."),
+			Value:   node.Value,
+			Line:    node.Line,
+			Column:  column,
+		})
+	}
+	return entries
+}
+
+func parseDotEnvDocument(content []byte) []Entry {
+	var entries []Entry
+	forEachLine(content, func(line string, lineNumber int) {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			return
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return
+		}
+		entries = append(entries, Entry{
+			KeyPath: strings.TrimSpace(key),
+			Value:   value,
+			Line:    lineNumber,
+			Column:  len(key) + 2,
+		})
+	})
+	return entries
+}
+
+func parseIniDocument(content []byte) []Entry {
+	var entries []Entry
+	section := ""
+	forEachLine(content, func(line string, lineNumber int) {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, ";") || strings.HasPrefix(trimmed, "#") {
+			return
+		}
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			section = strings.TrimSpace(trimmed[1 : len(trimmed)-1])
+			return
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return
+		}
+		keyPath := strings.TrimSpace(key)
+		// keys before the first section belong to the DEFAULT section
+		if section != "" && section != "DEFAULT" {
+			keyPath = section + "." + keyPath
+		}
+		leading := len(value) - len(strings.TrimLeft(value, " \t"))
+		entries = append(entries, Entry{
+			KeyPath: keyPath,
+			Value:   strings.TrimSpace(value),
+			Line:    lineNumber,
+			Column:  len(key) + 2 + leading,
+		})
+	})
+	return entries
+}
+
+func forEachLine(content []byte, fn func(line string, lineNumber int)) {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(content)+1)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		fn(strings.TrimSuffix(scanner.Text(), "\r"), lineNumber)
+	}
+}
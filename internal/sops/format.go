@@ -0,0 +1,128 @@
+package sops
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// FileType is the SOPS store format of an encrypted file
+type FileType = string
+
+const (
+	YAML   FileType = "yaml"
+	JSON   FileType = "json"
+	DotEnv FileType = "dotenv"
+	INI    FileType = "ini"
+	// Binary files are stored as JSON with the encrypted content in the "data" key
+	Binary FileType = "binary"
+)
+
+var fileTypesByExtension = map[string]FileType{
+	"yaml":   YAML,
+	"yml":    YAML,
+	"json":   JSON,
+	"env":    DotEnv,
+	"dotenv": DotEnv,
+	"ini":    INI,
+	"binary": Binary,
+	"bin":    Binary,
+}
+
+// FormatRule maps file names matching the glob Pattern to a FileType
+type FormatRule struct {
+	Pattern  string   `json:"pattern" yaml:"pattern"`
+	FileType FileType `json:"fileType" yaml:"fileType"`
+}
+
+// ParseFileType returns the FileType of a format name or file extension (e.g. yml, env, json)
+func ParseFileType(name string) (FileType, error) {
+	fileType, ok := fileTypesByExtension[strings.ToLower(strings.TrimPrefix(name, "."))]
+	if !ok {
+		return "", fmt.Errorf("unknown sops format '%s', supported: yaml, json, dotenv, ini, binary", name)
+	}
+	return fileType, nil
+}
+
+// ParseFormatRules parses rules in the form <glob pattern>=<format>, e.g. '*.enc=json'
+func ParseFormatRules(values []string) ([]FormatRule, error) {
+	var rules []FormatRule
+	for _, value := range values {
+		pattern, format, ok := strings.Cut(value, "=")
+		if !ok || pattern == "" {
+			return nil, fmt.Errorf("invalid sops format rule '%s', expected <pattern>=<format>", value)
+		}
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid sops format rule '%s': %w", value, err)
+		}
+		fileType, err := ParseFileType(format)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sops format rule '%s': %w", value, err)
+		}
+		rules = append(rules, FormatRule{Pattern: pattern, FileType: fileType})
+	}
+	return rules, nil
+}
+
+func (r FormatRule) matches(path string) bool {
+	if ok, _ := filepath.Match(r.Pattern, path); ok {
+		return true
+	}
+	ok, _ := filepath.Match(r.Pattern, filepath.Base(path))
+	return ok
+}
+
+/*
+DetectFileType determines the SOPS store format of a file. In order of precedence:
+  - the first user provided rule matching the path or file name
+  - a known file extension
+  - the sops metadata in the content (e.g. 'secret.yaml.enc', files without extension)
+
+Unknown files are treated as binary, like sops itself does.
+*/
+func DetectFileType(path string, content []byte, rules []FormatRule) FileType {
+	for _, rule := range rules {
+		if rule.matches(path) {
+			return rule.FileType
+		}
+	}
+	if fileType, ok := fileTypesByExtension[strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))]; ok {
+		return fileType
+	}
+	if fileType, ok := sniffFileType(content); ok {
+		return fileType
+	}
+	return Binary
+}
+
+// sniffFileType looks for the sops metadata in the formats of the different stores
+func sniffFileType(content []byte) (FileType, bool) {
+	trimmed := bytes.TrimSpace(content)
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		var document map[string]json.RawMessage
+		if err := json.Unmarshal(trimmed, &document); err != nil {
+			return "", false
+		}
+		if _, ok := document["sops"]; !ok {
+			return "", false
+		}
+		if _, ok := document["data"]; ok && len(document) == 2 {
+			return Binary, true
+		}
+		return JSON, true
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		switch {
+		case line == "sops:":
+			return YAML, true
+		case line == "[sops]":
+			return INI, true
+		case strings.HasPrefix(line, "sops_version=") || strings.HasPrefix(line, "sops_mac="):
+			return DotEnv, true
+		}
+	}
+	return "", false
+}
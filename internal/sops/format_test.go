@@ -0,0 +1,60 @@
+//go:build unit
+
+package sops
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectFileType(t *testing.T) {
+	rules := []FormatRule{
+		{Pattern: "*.enc", FileType: JSON},
+		{Pattern: "config/secrets", FileType: DotEnv},
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		content  string
+		rules    []FormatRule
+		expected FileType
+	}{
+		{name: "yaml extension", path: "secret.yaml", expected: YAML},
+		{name: "yml extension", path: "secret.yml", expected: YAML},
+		{name: "json extension", path: "secret.json", expected: JSON},
+		{name: "env extension", path: ".env", expected: DotEnv},
+		{name: "ini extension", path: "secret.INI", expected: INI},
+		{name: "rule by name", path: "dir/secret.enc", rules: rules, expected: JSON},
+		{name: "rule by path", path: "config/secrets", rules: rules, expected: DotEnv},
+		{name: "rule before extension", path: "secret.yaml", rules: []FormatRule{{Pattern: "*.yaml", FileType: Binary}}, expected: Binary},
+		{name: "sniff yaml", path: "secret.yaml.enc", content: "password: ENC[AES256_GCM,data:abc]\nsops:\n    version: 3.9.4\n", expected: YAML},
+		{name: "sniff json", path: "secret", content: `{"password": "ENC[AES256_GCM,data:abc]", "sops": {}}`, expected: JSON},
+		{name: "sniff binary", path: "id_rsa", content: `{"data": "ENC[AES256_GCM,data:abc]", "sops": {}}`, expected: Binary},
+		{name: "sniff dotenv", path: "secret", content: "PASSWORD=ENC[AES256_GCM,data:abc]\nsops_version=3.9.4\n", expected: DotEnv},
+		{name: "sniff ini", path: "secret", content: "[db]\npassword = ENC[AES256_GCM,data:abc]\n\n[sops]\nversion = 3.9.4\n", expected: INI},
+		{name: "unknown", path: "secret.txt", content: "password", expected: Binary},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, DetectFileType(tt.path, []byte(tt.content), tt.rules))
+		})
+	}
+}
+
+func TestParseFormatRules(t *testing.T) {
+	rules, err := ParseFormatRules([]string{"*.enc=json", "secrets/*=yml"})
+	require.NoError(t, err)
+	assert.Equal(t, []FormatRule{
+		{Pattern: "*.enc", FileType: JSON},
+		{Pattern: "secrets/*", FileType: YAML},
+	}, rules)
+
+	for _, invalid := range []string{"*.enc", "=json", "*.enc=toml", "[=json"} {
+		_, err := ParseFormatRules([]string{invalid})
+		assert.Error(t, err, invalid)
+	}
+}
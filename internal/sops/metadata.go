@@ -0,0 +1,115 @@
+package sops
+
+import (
+	"strings"
+	"time"
+
+	sopslib "github.com/getsops/sops/v3"
+	"github.com/getsops/sops/v3/age"
+	"github.com/getsops/sops/v3/azkv"
+	"github.com/getsops/sops/v3/gcpkms"
+	"github.com/getsops/sops/v3/hcvault"
+	"github.com/getsops/sops/v3/keys"
+	"github.com/getsops/sops/v3/kms"
+	"github.com/getsops/sops/v3/pgp"
+)
+
+type KeyType = string
+
+const (
+	Age     KeyType = age.KeyTypeIdentifier
+	PGP     KeyType = pgp.KeyTypeIdentifier
+	AWSKMS  KeyType = kms.KeyTypeIdentifier
+	GCPKMS  KeyType = gcpkms.KeyTypeIdentifier
+	AzureKV KeyType = azkv.KeyTypeIdentifier
+	HCVault KeyType = hcvault.KeyTypeIdentifier
+)
+
+// Key is a recipient or KMS key a SOPS file is encrypted with
+type Key struct {
+	Type KeyType `json:"type" yaml:"type"`
+	ID   string  `json:"id" yaml:"id"`
+}
+
+type KeyGroup []Key
+
+// Metadata is the unencrypted sops block of an encrypted file
+type Metadata struct {
+	KeyGroups    []KeyGroup `json:"keyGroups" yaml:"keyGroups"`
+	LastModified time.Time  `json:"lastModified" yaml:"lastModified"`
+	Version      string     `json:"version" yaml:"version"`
+}
+
+/*
+Matches checks if the key is identified by the given id:
+  - age recipients, AWS KMS ARNs, GCP KMS resource IDs and Vault transit paths have to match exactly
+  - PGP keys also match by a fingerprint suffix (e.g. the long key ID)
+  - Azure Key Vault keys also match without the key version
+*/
+func (k Key) Matches(id string) bool {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return false
+	}
+	if strings.EqualFold(k.ID, id) {
+		return true
+	}
+	switch k.Type {
+	case PGP:
+		fingerprint := strings.ReplaceAll(id, " ", "")
+		return strings.HasSuffix(strings.ToUpper(k.ID), strings.ToUpper(fingerprint))
+	case AzureKV:
+		return strings.HasPrefix(k.ID, strings.TrimSuffix(id, "/")+"/")
+	}
+	return false
+}
+
+// Keys returns the keys of all key groups
+func (m Metadata) Keys() []Key {
+	var result []Key
+	for _, group := range m.KeyGroups {
+		result = append(result, group...)
+	}
+	return result
+}
+
+// MatchingKeys returns all keys of the metadata matching at least one of the given ids
+func (m Metadata) MatchingKeys(ids []string) []Key {
+	var result []Key
+	for _, key := range m.Keys() {
+		for _, id := range ids {
+			if key.Matches(id) {
+				result = append(result, key)
+				break
+			}
+		}
+	}
+	return result
+}
+
+func newMetadata(metadata sopslib.Metadata) Metadata {
+	result := Metadata{
+		LastModified: metadata.LastModified,
+		Version:      metadata.Version,
+	}
+	for _, group := range metadata.KeyGroups {
+		keyGroup := make(KeyGroup, 0, len(group))
+		for _, masterKey := range group {
+			keyGroup = append(keyGroup, newKey(masterKey))
+		}
+		result.KeyGroups = append(result.KeyGroups, keyGroup)
+	}
+	return result
+}
+
+func newKey(masterKey keys.MasterKey) Key {
+	key := Key{
+		Type: masterKey.TypeToIdentifier(),
+		ID:   masterKey.ToString(),
+	}
+	// the string representation of AWS KMS keys contains the role and encryption context
+	if kmsKey, ok := masterKey.(*kms.MasterKey); ok {
+		key.ID = kmsKey.Arn
+	}
+	return key
+}
@@ -0,0 +1,100 @@
+//go:build unit
+
+package sops
+
+import (
+	"testing"
+	"time"
+
+	sopslib "github.com/getsops/sops/v3"
+	"github.com/getsops/sops/v3/age"
+	"github.com/getsops/sops/v3/kms"
+	"github.com/getsops/sops/v3/pgp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const encryptedYaml = `password: ENC[AES256_GCM,data:Zm9v,iv:Zm9v,tag:Zm9v,type:str]
+sops:
+    kms:
+        - arn: arn:aws:kms:eu-central-1:123456789012:key/abcd
+          role: arn:aws:iam::123456789012:role/sops
+          created_at: "2024-01-01T00:00:00Z"
+          enc: Zm9v
+          aws_profile: ""
+    age:
+        - recipient: age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p
+          enc: |
+            -----BEGIN AGE ENCRYPTED FILE-----
+            Zm9v
+            -----END AGE ENCRYPTED FILE-----
+    lastmodified: "2024-01-02T03:04:05Z"
+    mac: ENC[AES256_GCM,data:Zm9v,iv:Zm9v,tag:Zm9v,type:str]
+    unencrypted_suffix: _unencrypted
+    version: 3.9.4
+`
+
+func TestKeyMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		key      Key
+		id       string
+		expected bool
+	}{
+		{name: "Age exact", key: Key{Type: Age, ID: "age1abc"}, id: "age1abc", expected: true},
+		{name: "Age other", key: Key{Type: Age, ID: "age1abc"}, id: "age1ab", expected: false},
+		{name: "PGP fingerprint", key: Key{Type: PGP, ID: "FBC7B9E2A4F9289AC0C1D4843D16CEE4A27381B4"}, id: "fbc7b9e2a4f9289ac0c1d4843d16cee4a27381b4", expected: true},
+		{name: "PGP long key id", key: Key{Type: PGP, ID: "FBC7B9E2A4F9289AC0C1D4843D16CEE4A27381B4"}, id: "3D16CEE4A27381B4", expected: true},
+		{name: "Azure without version", key: Key{Type: AzureKV, ID: "https://vault.vault.azure.net/keys/sops/1234"}, id: "https://vault.vault.azure.net/keys/sops", expected: true},
+		{name: "KMS exact", key: Key{Type: AWSKMS, ID: "arn:aws:kms:eu-central-1:123456789012:key/abcd"}, id: "arn:aws:kms:eu-central-1:123456789012:key/abcd", expected: true},
+		{name: "Empty id", key: Key{Type: Age, ID: "age1abc"}, id: " ", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.key.Matches(tt.id))
+		})
+	}
+}
+
+func TestNewMetadata(t *testing.T) {
+	lastModified := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	metadata := newMetadata(sopslib.Metadata{
+		LastModified: lastModified,
+		Version:      "3.9.4",
+		KeyGroups: []sopslib.KeyGroup{
+			{&age.MasterKey{Recipient: "age1abc"}, &kms.MasterKey{Arn: "arn:aws:kms:key/abcd", Role: "arn:aws:iam::role/sops"}},
+			{&pgp.MasterKey{Fingerprint: "ABCD"}},
+		},
+	})
+
+	expected := Metadata{
+		LastModified: lastModified,
+		Version:      "3.9.4",
+		KeyGroups: []KeyGroup{
+			{{Type: Age, ID: "age1abc"}, {Type: AWSKMS, ID: "arn:aws:kms:key/abcd"}},
+			{{Type: PGP, ID: "ABCD"}},
+		},
+	}
+	assert.Equal(t, expected, metadata)
+	assert.Equal(t, []Key{{Type: PGP, ID: "ABCD"}}, metadata.MatchingKeys([]string{"abcd", "age1other"}))
+}
+
+func TestLoadMetadata(t *testing.T) {
+	w := &sopsCLientWrapper{}
+
+	metadata, err := w.LoadMetadata([]byte(encryptedYaml), YAML)
+	require.NoError(t, err)
+	assert.Equal(t, "3.9.4", metadata.Version)
+	assert.Equal(t, Metadata{
+		LastModified: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Version:      "3.9.4",
+		KeyGroups: []KeyGroup{{
+			{Type: AWSKMS, ID: "arn:aws:kms:eu-central-1:123456789012:key/abcd"},
+			{Type: Age, ID: "age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"},
+		}},
+	}, newMetadata(metadata))
+
+	_, err = w.LoadMetadata([]byte("password: plain"), YAML)
+	require.Error(t, err)
+}
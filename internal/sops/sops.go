@@ -1,20 +1,15 @@
 package sops
 
 import (
+	"context"
 	"fmt"
-	"path/filepath"
-	"strings"
-)
-
-type FileType = string
 
-const (
-	YAML   FileType = "yaml"
-	DotEnv FileType = "dotenv"
+	sopslib "github.com/getsops/sops/v3"
 )
 
 type SopsAPI interface {
 	DecryptFile([]byte, string) ([]byte, error)
+	LoadMetadata([]byte, string) (sopslib.Metadata, error)
 }
 
 type Storage interface {
@@ -33,12 +28,24 @@ func New(storage Storage) *Sops {
 	}
 }
 
-func (s *Sops) DecryptFile(path string) (string, error) {
-	fileType := getFileType(path)
+// DecryptFile decrypts the file at the given path, which is stored in the given SOPS file type (see DetectFileType)
+func (s *Sops) DecryptFile(ctx context.Context, path string, fileType FileType) (string, error) {
+	// the sops decryption itself is not cancelable, so at least don't start it after cancellation
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
 	content, err := s.Storage.ReadFile(path)
 	if err != nil {
 		return "", fmt.Errorf("could not read secret file: %w", err)
 	}
+	return s.Decrypt(ctx, content, fileType)
+}
+
+// Decrypt decrypts the content of a file, which is stored in the given SOPS file type (see DetectFileType)
+func (s *Sops) Decrypt(ctx context.Context, content []byte, fileType FileType) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
 	decryptedContent, err := s.Client.DecryptFile(content, fileType)
 	if err != nil {
 		return "", fmt.Errorf("could not decrypt secret file: %w", err)
@@ -46,13 +53,12 @@ func (s *Sops) DecryptFile(path string) (string, error) {
 	return string(decryptedContent), nil
 }
 
-func getFileType(path string) FileType {
-	fileType := strings.Replace(filepath.Ext(path), ".", "", 1)
-	switch fileType {
-	case "env":
-		return DotEnv
-	case "yml":
-		return YAML
+// Metadata parses the sops metadata of the encrypted content without decrypting it.
+// An error is returned if the content is not SOPS encrypted.
+func (s *Sops) Metadata(content []byte, fileType FileType) (Metadata, error) {
+	metadata, err := s.Client.LoadMetadata(content, fileType)
+	if err != nil {
+		return Metadata{}, fmt.Errorf("could not read sops metadata: %w", err)
 	}
-	return fileType
+	return newMetadata(metadata), nil
 }
@@ -2,7 +2,10 @@
 
 package sops
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	v3 "github.com/getsops/sops/v3"
+	mock "github.com/stretchr/testify/mock"
+)
 
 // SopsAPIMock is an autogenerated mock type for the SopsAPI type
 type SopsAPIMock struct {
@@ -76,6 +79,63 @@ func (_c *SopsAPIMock_DecryptFile_Call) RunAndReturn(run func([]byte, string) ([
 	return _c
 }
 
+// LoadMetadata provides a mock function with given fields: _a0, _a1
+func (_m *SopsAPIMock) LoadMetadata(_a0 []byte, _a1 string) (v3.Metadata, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LoadMetadata")
+	}
+
+	var r0 v3.Metadata
+	var r1 error
+	if rf, ok := ret.Get(0).(func([]byte, string) (v3.Metadata, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func([]byte, string) v3.Metadata); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(v3.Metadata)
+	}
+
+	if rf, ok := ret.Get(1).(func([]byte, string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SopsAPIMock_LoadMetadata_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'LoadMetadata'
+type SopsAPIMock_LoadMetadata_Call struct {
+	*mock.Call
+}
+
+// LoadMetadata is a helper method to define mock.On call
+//   - _a0 []byte
+//   - _a1 string
+func (_e *SopsAPIMock_Expecter) LoadMetadata(_a0 interface{}, _a1 interface{}) *SopsAPIMock_LoadMetadata_Call {
+	return &SopsAPIMock_LoadMetadata_Call{Call: _e.mock.On("LoadMetadata", _a0, _a1)}
+}
+
+func (_c *SopsAPIMock_LoadMetadata_Call) Run(run func(_a0 []byte, _a1 string)) *SopsAPIMock_LoadMetadata_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].([]byte), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *SopsAPIMock_LoadMetadata_Call) Return(_a0 v3.Metadata, _a1 error) *SopsAPIMock_LoadMetadata_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *SopsAPIMock_LoadMetadata_Call) RunAndReturn(run func([]byte, string) (v3.Metadata, error)) *SopsAPIMock_LoadMetadata_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewSopsAPIMock creates a new instance of SopsAPIMock. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewSopsAPIMock(t interface {
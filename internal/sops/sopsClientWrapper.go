@@ -1,9 +1,25 @@
 package sops
 
-import "github.com/getsops/sops/v3/decrypt"
+import (
+	sopslib "github.com/getsops/sops/v3"
+	"github.com/getsops/sops/v3/cmd/sops/common"
+	"github.com/getsops/sops/v3/cmd/sops/formats"
+	"github.com/getsops/sops/v3/config"
+	"github.com/getsops/sops/v3/decrypt"
+)
 
 type sopsCLientWrapper struct{}
 
 func (w *sopsCLientWrapper) DecryptFile(data []byte, format string) (cleartext []byte, err error) {
 	return decrypt.Data(data, format)
 }
+
+// LoadMetadata reads the sops metadata of an encrypted file without decrypting it
+func (w *sopsCLientWrapper) LoadMetadata(data []byte, format string) (sopslib.Metadata, error) {
+	store := common.StoreForFormat(formats.FormatFromString(format), config.NewStoresConfig())
+	tree, err := store.LoadEncryptedFile(data)
+	if err != nil {
+		return sopslib.Metadata{}, err
+	}
+	return tree.Metadata, nil
+}
@@ -3,6 +3,7 @@
 package sops
 
 import (
+	"context"
 	"errors"
 	"testing"
 
@@ -36,7 +37,7 @@ func TestDecryptFile(t *testing.T) {
 			Client:  mockSopsClient,
 		}
 
-		actualContent, err := s.DecryptFile(path)
+		actualContent, err := s.DecryptFile(context.Background(), path, YAML)
 		mockStorage.AssertNumberOfCalls(t, "ReadFile", 1)
 		mockSopsClient.AssertNumberOfCalls(t, "DecryptFile", 1)
 		require.NoError(t, err)
@@ -58,7 +59,7 @@ func TestDecryptFile(t *testing.T) {
 			Client:  mockSopsClient,
 		}
 
-		_, actualErr := s.DecryptFile("test")
+		_, actualErr := s.DecryptFile(context.Background(), "test", Binary)
 		mockStorage.AssertNumberOfCalls(t, "ReadFile", 1)
 		mockSopsClient.AssertNumberOfCalls(t, "DecryptFile", 0)
 		require.Error(t, actualErr)
@@ -83,10 +84,27 @@ func TestDecryptFile(t *testing.T) {
 			Client:  mockSopsClient,
 		}
 
-		_, actualErr := s.DecryptFile("test")
+		_, actualErr := s.DecryptFile(context.Background(), "test", Binary)
 		mockStorage.AssertNumberOfCalls(t, "ReadFile", 1)
 		mockSopsClient.AssertNumberOfCalls(t, "DecryptFile", 1)
 		require.Error(t, actualErr)
 		assert.EqualError(t, expectedErr, actualErr.Error())
 	})
+
+	t.Run("Canceled context", func(t *testing.T) {
+		mockStorage := NewStorageMock(t)
+		mockSopsClient := NewSopsAPIMock(t)
+
+		s := &Sops{
+			Storage: mockStorage,
+			Client:  mockSopsClient,
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, actualErr := s.DecryptFile(ctx, "test", Binary)
+		mockStorage.AssertNumberOfCalls(t, "ReadFile", 0)
+		mockSopsClient.AssertNumberOfCalls(t, "DecryptFile", 0)
+		require.ErrorIs(t, actualErr, context.Canceled)
+	})
 }
@@ -0,0 +1,35 @@
+package worker
+
+import (
+	"context"
+	"runtime"
+)
+
+// Limiter bounds the number of concurrent calls of an expensive operation (e.g. SOPS decryption).
+type Limiter struct {
+	slots chan struct{}
+}
+
+// NewLimiter creates a limiter with the given size. A size < 1 defaults to the number of CPUs.
+func NewLimiter(size int) *Limiter {
+	if size < 1 {
+		size = runtime.NumCPU()
+	}
+	return &Limiter{
+		slots: make(chan struct{}, size),
+	}
+}
+
+// Acquire blocks until a slot is free or the context is done
+func (l *Limiter) Acquire(ctx context.Context) error {
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *Limiter) Release() {
+	<-l.slots
+}
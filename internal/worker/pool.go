@@ -0,0 +1,75 @@
+package worker
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+/*
+Pool runs submitted tasks on a fixed number of goroutines.
+The queue is unbounded, so tasks can submit further tasks (e.g. nested directories)
+without blocking and without risking a deadlock when all workers are busy.
+Once the context is done, queued tasks are dropped without being run.
+*/
+type Pool struct {
+	ctx     context.Context
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   []func()
+	pending sync.WaitGroup
+	stopped bool
+}
+
+// NewPool starts a pool with the given number of workers. A size < 1 defaults to the number of CPUs.
+func NewPool(ctx context.Context, size int) *Pool {
+	if size < 1 {
+		size = runtime.NumCPU()
+	}
+	p := &Pool{ctx: ctx}
+	p.cond = sync.NewCond(&p.mu)
+	for i := 0; i < size; i++ {
+		go p.work()
+	}
+	return p
+}
+
+func (p *Pool) Submit(task func()) {
+	p.pending.Add(1)
+	p.mu.Lock()
+	p.queue = append(p.queue, task)
+	p.mu.Unlock()
+	p.cond.Signal()
+}
+
+// Wait blocks until all submitted tasks (including nested ones) are done and stops the workers.
+// The pool must not be used afterwards.
+func (p *Pool) Wait() {
+	p.pending.Wait()
+	p.mu.Lock()
+	p.stopped = true
+	p.mu.Unlock()
+	p.cond.Broadcast()
+}
+
+func (p *Pool) work() {
+	for {
+		p.mu.Lock()
+		for len(p.queue) == 0 && !p.stopped {
+			p.cond.Wait()
+		}
+		if len(p.queue) == 0 {
+			p.mu.Unlock()
+			return
+		}
+		task := p.queue[0]
+		p.queue[0] = nil
+		p.queue = p.queue[1:]
+		p.mu.Unlock()
+
+		if p.ctx.Err() == nil {
+			task()
+		}
+		p.pending.Done()
+	}
+}
@@ -0,0 +1,111 @@
+//go:build unit
+
+package worker
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoolRunsAllTasks(t *testing.T) {
+	p := NewPool(context.Background(), 4)
+	var count atomic.Int32
+	for i := 0; i < 100; i++ {
+		p.Submit(func() {
+			count.Add(1)
+		})
+	}
+	p.Wait()
+	assert.Equal(t, int32(100), count.Load())
+}
+
+func TestPoolNestedSubmit(t *testing.T) {
+	p := NewPool(context.Background(), 1)
+	var count atomic.Int32
+	var submit func(depth int)
+	submit = func(depth int) {
+		count.Add(1)
+		if depth == 0 {
+			return
+		}
+		for i := 0; i < 2; i++ {
+			p.Submit(func() { submit(depth - 1) })
+		}
+	}
+	p.Submit(func() { submit(5) })
+	p.Wait()
+	assert.Equal(t, int32(63), count.Load())
+}
+
+func TestPoolBoundsConcurrency(t *testing.T) {
+	p := NewPool(context.Background(), 3)
+	var running, maxRunning atomic.Int32
+	for i := 0; i < 30; i++ {
+		p.Submit(func() {
+			n := running.Add(1)
+			for {
+				m := maxRunning.Load()
+				if n <= m || maxRunning.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			running.Add(-1)
+		})
+	}
+	p.Wait()
+	assert.LessOrEqual(t, maxRunning.Load(), int32(3))
+}
+
+func TestLimiterBoundsConcurrency(t *testing.T) {
+	l := NewLimiter(2)
+	var wg sync.WaitGroup
+	var running, maxRunning atomic.Int32
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = l.Acquire(context.Background())
+			defer l.Release()
+			n := running.Add(1)
+			for {
+				m := maxRunning.Load()
+				if n <= m || maxRunning.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			running.Add(-1)
+		}()
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, maxRunning.Load(), int32(2))
+}
+
+func TestPoolDropsTasksAfterCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := NewPool(ctx, 1)
+	var count atomic.Int32
+	p.Submit(func() {
+		cancel()
+		for i := 0; i < 10; i++ {
+			p.Submit(func() { count.Add(1) })
+		}
+	})
+	p.Wait()
+	assert.Equal(t, int32(0), count.Load())
+}
+
+func TestLimiterAcquireCanceled(t *testing.T) {
+	l := NewLimiter(1)
+	require.NoError(t, l.Acquire(context.Background()))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, l.Acquire(ctx), context.Canceled)
+}
@@ -1,16 +1,24 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/alican-uelger/deep-scan/cmd"
 )
 
 func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 	rootCmd := cmd.NewRootCmd()
-	err := rootCmd.Execute()
+	err := rootCmd.ExecuteContext(ctx)
 	if err != nil {
 		slog.Error(fmt.Sprintf("Error executing root cmd: %v", err))
+		stop()
+		os.Exit(1)
 	}
 }
@@ -1,6 +1,7 @@
 package e2e
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -39,7 +40,7 @@ func BenchmarkSearch(b *testing.B) {
 	b.ResetTimer()
 	b.StartTimer()
 	for i := 0; i < b.N; i++ {
-		_, err := searcher.Search(tempDir, options)
+		_, err := searcher.Search(context.Background(), tempDir, options)
 		if err != nil {
 			b.Fatalf("search failed: %v", err)
 		}